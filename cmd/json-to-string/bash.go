@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// toBashArray writes a JSON array as a bash array literal for --bash-array,
+// such as ('1' '{\"a\":2}'), in which each element is escaped as a separate
+// document, as with --explode, and single-quoted for the shell
+func toBashArray(input []byte, opts *options) (string, error) {
+	typ, err := jsonstr.TopLevelType(input)
+	if err != nil {
+		return "", err
+	}
+	if typ != "array" {
+		return "", fmt.Errorf("--bash-array requires a JSON array, got %s", typ)
+	}
+
+	var elements []json.RawMessage
+	if err := json.Unmarshal(input, &elements); err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	quoted := make([]string, len(elements))
+	for i, element := range elements {
+		escaped, err := jsonstr.EncodeWithOptions(element, opts.encodeOptions())
+		if err != nil {
+			return "", fmt.Errorf("element %d: %w", i, err)
+		}
+		quoted[i] = singleQuote(escaped)
+	}
+	return "(" + strings.Join(quoted, " ") + ")", nil
+}
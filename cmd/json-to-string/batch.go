@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// batchResult holds the outcome of converting a single batch file
+type batchResult struct {
+	output string
+	err    error
+	done   chan struct{}
+}
+
+// processBatch converts every file in paths using a bounded pool of workers.
+// Results are written to w in the same order as paths, regardless of which
+// worker finishes first. Errors from individual files are collected and
+// returned once all files have been processed.
+func processBatch(w io.Writer, paths []string, opts *options) []error {
+	results := make([]batchResult, len(paths))
+	for i := range results {
+		results[i].done = make(chan struct{})
+	}
+
+	jobs := opts.jobs
+	if jobs > len(paths) {
+		jobs = len(paths)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < jobs; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i].output, results[i].err = convertFile(paths[i], opts)
+				close(results[i].done)
+			}
+		}()
+	}
+
+	go func() {
+		for i := range paths {
+			indexes <- i
+		}
+		close(indexes)
+	}()
+
+	// Flush results in input order as soon as each one is ready
+	var errs []error
+	for i := range results {
+		<-results[i].done
+		if results[i].err != nil {
+			errs = append(errs, fmt.Errorf("processing %s: %w", paths[i], results[i].err))
+			continue
+		}
+		writeResult(w, results[i].output, opts)
+		if (opts.rawOutput || opts.stripFinalNL) && i < len(results)-1 {
+			// Keep batch results separable even without trailing newlines
+			fmt.Fprint(w, opts.newline())
+		}
+	}
+
+	wg.Wait()
+	return errs
+}
+
+// convertFile reads a single file and converts its contents
+func convertFile(path string, opts *options) (string, error) {
+	input, release, err := readFile(path, opts.useMmap)
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+	defer release()
+
+	return convertInput(input, opts)
+}
+
+// prepareInput turns raw input into the JSON to convert, from hex and charset
+// decoding through --strict-utf8 and --preserve-header to the input formats.
+// It returns the header set aside by --preserve-header and the comments removed
+// for --comments-to along with the JSON.
+func prepareInput(input []byte, opts *options) (prepared, header []byte, comments []jsonstr.Comment, err error) {
+	if opts.hex && opts.decode {
+		if input, err = decodeHex(input); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if input, err = decodeCharset(input, opts); err != nil {
+		return nil, nil, nil, err
+	}
+	if opts.strictUTF8 {
+		if err := jsonstr.CheckUTF8(input); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if opts.preserveHeader {
+		header, input = splitHeader(input)
+	}
+	if input, comments, err = readInputFormat(input, header, opts); err != nil {
+		return nil, nil, nil, err
+	}
+	return input, header, comments, nil
+}
+
+// convertInput runs the whole conversion of raw input, from hex and charset
+// decoding through to the output-stage options
+func convertInput(input []byte, opts *options) (string, error) {
+	// --comments-to is rejected with batch files, --listen and --repl, the
+	// callers that convert many inputs, so there are no comments to keep
+	input, header, _, err := prepareInput(input, opts)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := convert(input, opts)
+	if err != nil {
+		return "", err
+	}
+	output, err := finishOutput(result, opts)
+	if err != nil {
+		return "", err
+	}
+	return attachHeader(header, output), nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// bothOutput combines a document and its escaped form for --both. The JSON is
+// compacted so that it fits on a labeled line like the escaped form, and with
+// --both-json the two are the values of a JSON object instead.
+func bothOutput(input []byte, escaped string, opts *options) (string, error) {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, input); err != nil {
+		return "", fmt.Errorf("encoding JSON: invalid JSON: %w", err)
+	}
+
+	if !opts.bothJSON {
+		return convertNewlines("json: "+compacted.String()+"\nescaped: "+escaped, opts), nil
+	}
+
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	// The escaped form is shown as it is, without HTML escapes added to it
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(struct {
+		JSON    json.RawMessage `json:"json"`
+		Escaped string          `json:"escaped"`
+	}{compacted.Bytes(), escaped})
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// charsets maps the names accepted by --input-charset and --output-charset to
+// their encodings. UTF-8 needs no transcoding and is handled separately.
+var charsets = map[string]encoding.Encoding{
+	"latin1":       charmap.ISO8859_1,
+	"iso-8859-1":   charmap.ISO8859_1,
+	"iso-8859-15":  charmap.ISO8859_15,
+	"windows-1252": charmap.Windows1252,
+	"shift_jis":    japanese.ShiftJIS,
+	"euc-jp":       japanese.EUCJP,
+	"iso-2022-jp":  japanese.ISO2022JP,
+	"euc-kr":       korean.EUCKR,
+	"gbk":          simplifiedchinese.GBK,
+	"gb18030":      simplifiedchinese.GB18030,
+	"big5":         traditionalchinese.Big5,
+	"utf-16":       unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM),
+	"utf-16le":     unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	"utf-16be":     unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+}
+
+// asciiIncompatible lists the charsets that cannot be used for output, as the
+// newline and quotes added around the output are written as ASCII
+var asciiIncompatible = map[string]bool{
+	"utf-16":   true,
+	"utf-16le": true,
+	"utf-16be": true,
+}
+
+// charsetNames returns the accepted charset names in alphabetical order
+func charsetNames() []string {
+	names := []string{"utf-8"}
+	for name := range charsets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// outputCharsetNames returns the charset names accepted for output in alphabetical order
+func outputCharsetNames() []string {
+	var names []string
+	for _, name := range charsetNames() {
+		if !asciiIncompatible[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// lookupCharset returns the encoding for a charset name, or nil for UTF-8.
+// Names are case-insensitive, "utf8" is accepted for "utf-8" and an empty name
+// also means UTF-8.
+func lookupCharset(name string) (encoding.Encoding, error) {
+	name = strings.ToLower(name)
+	if name == "" || name == "utf-8" || name == "utf8" {
+		return nil, nil
+	}
+	enc, ok := charsets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown charset %q: supported charsets are %s", name, strings.Join(charsetNames(), ", "))
+	}
+	return enc, nil
+}
+
+// validateCharsets checks the --input-charset and --output-charset names
+func validateCharsets(opts *options) error {
+	if _, err := lookupCharset(opts.inputCharset); err != nil {
+		return fmt.Errorf("invalid --input-charset: %w", err)
+	}
+	if _, err := lookupCharset(opts.outputCharset); err != nil {
+		return fmt.Errorf("invalid --output-charset: %w", err)
+	}
+	if asciiIncompatible[strings.ToLower(opts.outputCharset)] {
+		return fmt.Errorf("invalid --output-charset %q: output charsets must be ASCII-compatible", opts.outputCharset)
+	}
+	switch opts.onUnmappable {
+	case "", "error":
+	case "replace":
+		if enc, _ := lookupCharset(opts.outputCharset); enc == nil {
+			return fmt.Errorf("--on-unmappable replace requires --output-charset")
+		}
+	default:
+		return fmt.Errorf("invalid --on-unmappable value %q: must be error or replace", opts.onUnmappable)
+	}
+	return nil
+}
+
+// decodeCharset transcodes input from --input-charset to UTF-8
+func decodeCharset(input []byte, opts *options) ([]byte, error) {
+	enc, err := lookupCharset(opts.inputCharset)
+	if err != nil || enc == nil {
+		return input, err
+	}
+	decoded, err := enc.NewDecoder().Bytes(input)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s input: %w", opts.inputCharset, err)
+	}
+	return decoded, nil
+}
+
+// encodeCharset transcodes output from UTF-8 to --output-charset. Characters the
+// charset cannot represent are an error, or with --on-unmappable replace become '?'.
+func encodeCharset(output string, opts *options) (string, error) {
+	enc, err := lookupCharset(opts.outputCharset)
+	if err != nil || enc == nil {
+		return output, err
+	}
+	if opts.onUnmappable == "replace" {
+		output = replaceUnmappable(output, enc)
+	}
+	encoded, err := enc.NewEncoder().String(output)
+	if err != nil {
+		return "", fmt.Errorf("encoding output as %s: %w", opts.outputCharset, err)
+	}
+	return encoded, nil
+}
+
+// replaceUnmappable replaces the characters of s that enc cannot represent with
+// '?'. The charset's own replacement is often an invisible control character,
+// while every supported output charset has '?'. Characters are checked one at a
+// time, so stateful charsets such as iso-2022-jp are still encoded as a whole.
+func replaceUnmappable(s string, enc encoding.Encoding) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	encoder := enc.NewEncoder()
+	for _, r := range s {
+		if _, err := encoder.String(string(r)); err != nil {
+			r = '?'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
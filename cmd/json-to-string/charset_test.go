@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLookupCharset verifies charset names are validated
+func TestLookupCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		isUTF8      bool
+		expectError bool
+	}{
+		{name: "utf-8", isUTF8: true},
+		{name: "UTF8", isUTF8: true},
+		{name: "latin1"},
+		{name: "Shift_JIS"},
+		{name: "utf-16le"},
+		{name: "ebcdic", expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			enc, err := lookupCharset(tc.name)
+			if tc.expectError {
+				if err == nil || !strings.Contains(err.Error(), "supported charsets are") || !strings.Contains(err.Error(), "shift_jis") {
+					t.Errorf("expected an error listing the supported charsets but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (enc == nil) != tc.isUTF8 {
+				t.Errorf("expected UTF-8=%v but got encoding %v", tc.isUTF8, enc)
+			}
+		})
+	}
+}
+
+// TestReplaceUnmappable verifies unrepresentable characters become '?'
+func TestReplaceUnmappable(t *testing.T) {
+	tests := []struct {
+		charset  string
+		input    string
+		expected string
+	}{
+		{charset: "latin1", input: "café 日本", expected: "café ??"},
+		{charset: "shift_jis", input: "日本 € é", expected: "日本 ? ?"},
+		{charset: "iso-2022-jp", input: "日€本", expected: "日?本"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.charset, func(t *testing.T) {
+			enc, err := lookupCharset(tc.charset)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := replaceUnmappable(tc.input, enc); result != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+// TestCharsets verifies input is transcoded before parsing and output after converting
+func TestCharsets(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		args        []string
+		input       []byte
+		expected    []byte
+		expectError bool
+	}{
+		{
+			name:     "Latin-1 input",
+			args:     []string{"--input-charset", "latin1"},
+			input:    []byte("{\"name\":\"caf\xe9\"}"),
+			expected: []byte(`{\"name\":\"café\"}` + "\n"),
+		},
+		{
+			name:     "Latin-1 input and output",
+			args:     []string{"--input-charset", "latin1", "--output-charset", "latin1"},
+			input:    []byte("{\"name\":\"caf\xe9\"}"),
+			expected: []byte("{\\\"name\\\":\\\"caf\xe9\\\"}\n"),
+		},
+		{
+			name:     "Shift-JIS input",
+			args:     []string{"--input-charset", "shift_jis"},
+			input:    []byte("{\"a\":\"\x93\xfa\x96\x7b\"}"),
+			expected: []byte(`{\"a\":\"日本\"}` + "\n"),
+		},
+		{
+			name:     "UTF-16 input with a byte order mark",
+			args:     []string{"--input-charset", "utf-16"},
+			input:    []byte("\xff\xfe[\x001\x00]\x00"),
+			expected: []byte("[1]\n"),
+		},
+		{
+			name:     "Decoded output in Shift-JIS",
+			args:     []string{"decode", "--output-charset", "shift_jis"},
+			input:    []byte(`{\"a\":\"日本\"}`),
+			expected: []byte("{\"a\":\"\x93\xfa\x96\x7b\"}\n"),
+		},
+		{
+			name:        "Character not representable in the output charset",
+			args:        []string{"--output-charset", "latin1"},
+			input:       []byte(`{"a":"日本"}`),
+			expectError: true,
+		},
+		{
+			name:     "Unmappable characters replaced",
+			args:     []string{"--output-charset", "latin1", "--on-unmappable", "replace"},
+			input:    []byte(`{"a":"日é"}`),
+			expected: []byte("{\\\"a\\\":\\\"?\xe9\\\"}\n"),
+		},
+		{
+			name:        "Unmappable characters rejected explicitly",
+			args:        []string{"--output-charset", "latin1", "--on-unmappable", "error"},
+			input:       []byte(`{"a":"日"}`),
+			expectError: true,
+		},
+		{
+			name:        "Invalid on-unmappable value",
+			args:        []string{"--output-charset", "latin1", "--on-unmappable", "skip"},
+			input:       []byte(`{}`),
+			expectError: true,
+		},
+		{
+			name:        "Replace without an output charset",
+			args:        []string{"--on-unmappable", "replace"},
+			input:       []byte(`{}`),
+			expectError: true,
+		},
+		{
+			name:     "Invalid UTF-8 is replaced by default",
+			args:     []string{"--compact"},
+			input:    []byte("{\"a\":\"caf\xc3\x28\"}"),
+			expected: []byte("{\\\"a\\\":\\\"caf\ufffd(\\\"}\n"),
+		},
+		{
+			name:        "Invalid UTF-8 with --strict-utf8",
+			args:        []string{"--strict-utf8"},
+			input:       []byte("{\"a\":\"caf\xc3\x28\"}"),
+			expectError: true,
+		},
+		{
+			name:     "Transcoded input with --strict-utf8",
+			args:     []string{"--strict-utf8", "--input-charset", "latin1"},
+			input:    []byte("{\"a\":\"caf\xe9\"}"),
+			expected: []byte("{\\\"a\\\":\\\"café\\\"}\n"),
+		},
+		{
+			name:        "Unknown charset",
+			args:        []string{"--input-charset", "ebcdic"},
+			input:       []byte(`{}`),
+			expectError: true,
+		},
+		{
+			name:        "ASCII-incompatible output charset",
+			args:        []string{"--output-charset", "utf-16"},
+			input:       []byte(`{}`),
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "input"+string(rune('a'+i)))
+			if err := os.WriteFile(path, tc.input, 0o644); err != nil {
+				t.Fatalf("failed to write input: %v", err)
+			}
+
+			var stdout bytes.Buffer
+			cmd := exec.Command(binaryPath, append(tc.args, "--file", path)...)
+			cmd.Stdout = &stdout
+			err := cmd.Run()
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(stdout.Bytes(), tc.expected) {
+				t.Errorf("expected %q but got %q", tc.expected, stdout.Bytes())
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// command describes a subcommand of the CLI
+type command struct {
+	name    string
+	summary string
+}
+
+// commands lists the subcommands in the order they are shown in usage messages.
+// Without a subcommand, the legacy interface accepts every flag and encodes
+// unless --decode is given.
+var commands = []command{
+	{name: "encode", summary: "Convert JSON to an escaped string"},
+	{name: "decode", summary: "Convert an escaped string back to JSON"},
+	{name: "format", summary: "Pretty-print or compact JSON without escaping it"},
+	{name: "validate", summary: "Check that the input is valid JSON"},
+}
+
+// stringList is a flag value that collects every occurrence of a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// lookupCommand returns the subcommand with the given name
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// parseArgs parses the command-line arguments into options. If the first
+// argument names a subcommand, only the flags of that subcommand are accepted.
+func parseArgs(args []string) *options {
+	// Flags that a subcommand does not accept keep these defaults
+	opts := &options{
+		fd:            -1,
+		indent:        jsonstr.DefaultIndent,
+		eol:           "lf",
+		unicode:       "literal",
+		sqlDialect:    "ansi",
+		inputCharset:  "utf-8",
+		outputCharset: "utf-8",
+		onUnmappable:  "error",
+		jobs:          runtime.NumCPU(),
+		listenLimit:   defaultListenLimit,
+		listenTimeout: 10 * time.Second,
+	}
+	if len(args) > 0 {
+		if _, ok := lookupCommand(args[0]); ok {
+			opts.command = args[0]
+			args = args[1:]
+		}
+	}
+
+	opts.flags = newFlagSet(opts.command, opts)
+	opts.flags.Usage = func() { printUsage(opts) }
+	// The flag set exits on error, so the error never needs handling here
+	_ = opts.flags.Parse(args)
+	opts.files = opts.flags.Args()
+	if flagPassed(opts.flags, "indent-width") && opts.indentWidth > 0 {
+		// Widths out of range are reported when the options are validated
+		opts.indent = strings.Repeat(" ", opts.indentWidth)
+	}
+	resolveIndent(opts)
+	if opts.bothJSON {
+		opts.both = true
+	}
+	if opts.pointersJSON {
+		opts.pointers = true
+	}
+	if opts.decodeElements {
+		opts.decode = true
+	}
+	if opts.strictRoundtrip {
+		opts.roundtrip = true
+	}
+	if opts.redactSecrets {
+		opts.detectSecrets = true
+	}
+	if opts.dataURIPlain {
+		opts.dataURI = true
+	}
+
+	switch opts.command {
+	case "decode":
+		opts.decode = true
+	case "format":
+		opts.format = true
+	case "validate":
+		opts.validate = true
+	}
+	return opts
+}
+
+// maxIndentWidth is the largest number of spaces accepted by --indent-width
+const maxIndentWidth = 16
+
+// newFlagSet returns the flags accepted by the named subcommand, bound to opts.
+// The empty name selects the legacy interface, which accepts every flag.
+func newFlagSet(name string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet("json-to-string", flag.ExitOnError)
+
+	// in reports whether a flag applies to the subcommand being parsed
+	in := func(names ...string) bool {
+		if name == "" {
+			return true
+		}
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Input
+	fs.StringVar(&opts.inputFile, "file", "", "Input JSON file path")
+	fs.StringVar(&opts.inputString, "json", "", "JSON string input, or @path to read a file and @- to read stdin (@@ for a literal leading @)")
+	fs.StringVar(&opts.envVar, "env", "", "Read the input from the named environment variable")
+	fs.IntVar(&opts.fd, "fd", -1, "Read the input from this open file descriptor, e.g. 3 for 3<(command) (Unix only)")
+	fs.BoolVar(&opts.useMmap, "mmap", false, "Memory-map input files instead of reading them into memory (Unix only)")
+	fs.DurationVar(&opts.stdinTimeout, "stdin-timeout", 0, "Fail if no input arrives on stdin within this duration, e.g. 5s (default: wait forever)")
+	fs.StringVar(&opts.inputCharset, "input-charset", "utf-8", "Character set of the input, transcoded to UTF-8 before parsing (e.g. latin1, shift_jis, utf-16)")
+	fs.BoolVar(&opts.strictUTF8, "strict-utf8", false, "Fail with the byte offset of the first invalid UTF-8 sequence instead of replacing it with U+FFFD")
+	if in("encode") {
+		fs.StringVar(&opts.byteRange, "range", "", "Escape only the bytes [start:end) of the input as raw text, without checking that they are JSON (e.g. 1024:2048)")
+	}
+	fs.BoolVar(&opts.ndjson, "ndjson", false, "Treat each input line as a separate JSON document (newline-delimited JSON)")
+	if in("encode", "format") {
+		fs.BoolVar(&opts.fromQuery, "from-querystring", false, "Read the input as a URL query string (a.b=1&c=1&c=2) and convert it to a JSON object first")
+		fs.BoolVar(&opts.fromCSV, "from-csv", false, "Read the input as CSV with a header row and convert it to a JSON array of objects first")
+		fs.BoolVar(&opts.csvInferTypes, "csv-infer-types", false, "With --from-csv, write numbers, true, false and null cells as JSON values instead of strings")
+		fs.BoolVar(&opts.csvLenient, "csv-lenient", false, "With --from-csv, accept rows with fewer or more cells than the header")
+		fs.BoolVar(&opts.stripComments, "strip-comments", false, "Remove // and /* */ comments from JSONC input, such as configuration files, before converting it")
+		fs.StringVar(&opts.commentsTo, "comments-to", "", "With --strip-comments, write the removed comments with their offsets and line numbers to this file as JSON")
+	}
+	if in("encode") {
+		fs.BoolVar(&opts.preserveHeader, "preserve-header", false, "Set aside leading # or // comment lines, such as a #! line, and print them unchanged before the output")
+	}
+	if in("encode", "decode", "format") {
+		fs.BoolVar(&opts.follow, "follow", false, "Keep reading lines appended to --file, like tail -f (requires --ndjson)")
+	}
+	if in("encode", "decode") {
+		fs.StringVar(&opts.listen, "listen", "", "Serve conversions on a socket (unix:/path or tcp:host:port): each connection sends one payload and receives the result")
+		fs.IntVar(&opts.listenLimit, "listen-limit", defaultListenLimit, "With --listen, the largest payload accepted, in bytes")
+		fs.DurationVar(&opts.listenTimeout, "listen-timeout", 10*time.Second, "With --listen, the time a connection has to send its payload and read the result")
+		fs.BoolVar(&opts.repl, "repl", false, "Start an interactive loop that converts each line entered (type :help for commands)")
+		fs.BoolVar(&opts.implode, "implode", false, "Combine the lines of NDJSON input into a single JSON array before converting it")
+		fs.BoolVar(&opts.hex, "hex", false, "Hex-encode the encoded output, or hex-decode the input before decoding it")
+	}
+	if in("decode") {
+		fs.BoolVar(&opts.decodeElements, "decode-elements", false, "Decode each string of a JSON array of escaped JSON strings, printing a JSON array of the decoded values (implies --decode)")
+	}
+
+	// Conversion
+	if name == "" {
+		fs.BoolVar(&opts.decode, "decode", false, "Decode an escaped JSON string back to JSON")
+	}
+	if in("encode", "format") {
+		fs.BoolVar(&opts.compact, "compact", false, "Remove newlines and extra spaces from pretty-printed JSON")
+	}
+	if in("encode", "decode") {
+		fs.BoolVar(&opts.stream, "stream", false, "Write the output while the input is read, for large piped inputs; with --decode each line is decoded separately (a late error can follow partial output)")
+	}
+	if in("encode") {
+		fs.BoolVar(&opts.rejectEscaped, "reject-escaped", false, "Fail instead of encoding input that looks already escaped, which would be escaped twice")
+		fs.BoolVar(&opts.stripWS, "strip-ws", false, "Remove only insignificant whitespace, keeping key order, duplicate keys and number formatting")
+	}
+	fs.BoolVar(&opts.strictKeys, "strict-keys", false, "Fail if an object has duplicate keys (when encoding, or in the decoded JSON)")
+	fs.StringVar(&opts.expect, "expect", "", "Fail unless the top-level type is one of these (comma-separated: object, array, string, number, boolean, null)")
+	if in("encode", "decode") {
+		fs.BoolVar(&opts.pretty, "pretty", false, "Format JSON with indentation: the decoded output, or the JSON before it is encoded")
+	}
+	if in("encode", "decode", "format") {
+		fs.StringVar(&opts.indent, "indent", jsonstr.DefaultIndent, "Indentation used by --pretty and --escape-values")
+		fs.IntVar(&opts.indentWidth, "indent-width", 0, fmt.Sprintf("Indent with this many spaces instead of an --indent string (1-%d)", maxIndentWidth))
+	}
+	if in("decode") {
+		fs.IntVar(&opts.wrap, "wrap", 0, "Soft-wrap long string values in --decode --pretty output at this many columns (display only, 0 disables)")
+		fs.BoolVar(&opts.align, "align", false, "Align the colons of each object in --decode --pretty output by padding its keys")
+		fs.IntVar(&opts.compactThreshold, "compact-threshold", 0, "Keep objects and arrays in --decode --pretty output on one line when shorter than this many characters (0 disables)")
+		fs.StringVar(&opts.unicode, "unicode", "literal", "How non-ASCII characters appear in decoded output: literal characters, or escaped as \\uXXXX")
+	}
+	if in("decode", "format") {
+		fs.BoolVar(&opts.lineNumbers, "line-numbers", false, "Prefix each line of --decode --pretty and format output with its line number (display only, not valid JSON)")
+		fs.IntVar(&opts.prettyDepth, "pretty-depth", 0, "Indent --decode --pretty and format output only this many levels deep, writing deeper objects and arrays on one line (0 disables)")
+		fs.BoolVar(&opts.indentJSONStrings, "indent-json-strings", false, "Pretty-print string values that hold JSON objects or arrays, keeping them strings")
+	}
+	if in("encode") {
+		fs.BoolVar(&opts.asJSONStr, "as-json-string", false, "Keep the surrounding quotes so the output is itself a valid JSON string")
+		fs.BoolVar(&opts.checkIdem, "check-idempotent", false, "Warn on stderr if encoding the output again would do more than escape it as raw text")
+		fs.BoolVar(&opts.compareOpts, "compare-options", false, "Print the output size with the default, --strip-ws, --compact and --pretty layouts to stderr instead of converting")
+		fs.BoolVar(&opts.readableCtrl, "readable-controls", false, "Write escaped control characters in string values as \\t, \\n, \\r, \\b and \\f where possible")
+		fs.BoolVar(&opts.byteEscape, "byte-escape", false, "Write each byte above 0x7F as \\xNN (not valid JSON escaping, for consumers that require it)")
+		fs.StringVar(&opts.lang, "lang", "", "Encode as a string literal for another language (c, sql, properties, yaml, toml)")
+		fs.IntVar(&opts.litWidth, "literal-width", 0, "Split --lang c literals into lines of at most this many characters, quotes included (0 disables splitting)")
+		fs.StringVar(&opts.sqlDialect, "sql-dialect", "ansi", "SQL dialect used by --lang sql (ansi, postgres, mysql)")
+		fs.BoolVar(&opts.propsASCII, "properties-ascii", false, "With --lang properties, write non-ASCII characters as \\uXXXX escapes")
+		fs.BoolVar(&opts.queryString, "querystring", false, "Write a JSON object as a URL query string (a.b=1&c=1&c=2) instead of escaping it")
+		fs.BoolVar(&opts.toCSV, "to-csv", false, "Write a JSON array of objects as CSV with a header row instead of escaping it")
+		fs.BoolVar(&opts.htmlAttr, "html-attr", false, "HTML-encode quotes, <, > and & in the escaped output so it can be used inside a quoted HTML attribute")
+		fs.BoolVar(&opts.asciiKeys, "ensure-ascii-keys", false, "Fail if an object key contains non-ASCII characters, for consumers that only accept ASCII keys")
+		fs.BoolVar(&opts.escapeASCIIKeys, "escape-nonascii-keys", false, "With --ensure-ascii-keys, write non-ASCII characters in keys as \\uXXXX escapes instead of failing")
+		fs.BoolVar(&opts.both, "both", false, "Print the compacted JSON and its escaped form on two labeled lines, for documentation")
+		fs.BoolVar(&opts.bothJSON, "both-json", false, "Like --both, but print them as a JSON object {\"json\": ..., \"escaped\": ...}")
+		fs.BoolVar(&opts.pointers, "pointers", false, "List the JSON Pointer and value of every leaf, one tab-separated line each, instead of escaping")
+		fs.BoolVar(&opts.pointersJSON, "pointers-json", false, "Like --pointers, but write a JSON object mapping each pointer to its value")
+		fs.BoolVar(&opts.bashArray, "bash-array", false, "Write a JSON array as a bash array literal of its escaped, single-quoted elements, e.g. ('1' '\\\"a\\\"')")
+		fs.BoolVar(&opts.toJSON5, "to-json5", false, "Write the JSON as JSON5 (unquoted keys, single-quoted strings, trailing commas) instead of escaping it")
+		fs.BoolVar(&opts.escapeVals, "escape-values", false, "Escape only string values and pretty-print the surrounding JSON structure")
+		fs.StringVar(&opts.pointer, "pointer", "", "Encode only the value at this JSON Pointer (RFC 6901, e.g. /user/addresses/0)")
+		fs.Var(&opts.sets, "set", "Set a value before encoding, as <pointer>=<JSON value> (repeatable, e.g. /user/name=\"Jane\")")
+		fs.Var(&opts.removes, "remove", "Remove the value at this JSON Pointer before encoding (repeatable)")
+		fs.StringVar(&opts.editsFile, "edits", "", "Apply a JSON array of {op, path, value} edits from this file, in order, before encoding (op is set or remove)")
+		fs.BoolVar(&opts.sortArrays, "sort-arrays", false, "Sort the elements of every array by their canonical JSON before encoding, for arrays used as sets")
+		fs.BoolVar(&opts.expandUnicode, "expand-unicode", false, "Replace \\uXXXX escapes in strings and keys with the characters they stand for before encoding")
+		fs.IntVar(&opts.floatPrecision, "float-precision", 0, fmt.Sprintf("Round floating-point numbers to this many significant digits before encoding (1-%d, 0 keeps them)", jsonstr.MaxFloatPrecision))
+		fs.BoolVar(&opts.dedupeArrays, "dedupe-arrays", false, "Remove array elements whose canonical JSON repeats an earlier element before encoding")
+		fs.BoolVar(&opts.detectSecrets, "detect-secrets", false, "Warn on stderr about string values that look like API keys, tokens or private keys, with their JSON Pointer")
+		fs.BoolVar(&opts.redactSecrets, "redact-secrets", false, "Replace the secrets found by --detect-secrets with [REDACTED] before encoding (implies --detect-secrets)")
+		fs.StringVar(&opts.filter, "filter", "", "Pipe the JSON through this shell command, such as 'jq .items', after the other transforms and encode its output")
+		fs.IntVar(&opts.maxArrayLen, "max-array-length", 0, "Keep only this many elements of each array, adding a \"…(+N more)\" element, before encoding (0 disables)")
+		fs.BoolVar(&opts.noTruncationMarker, "no-truncation-marker", false, "Drop truncated elements and characters without adding a marker")
+		fs.Var(&opts.replaces, "replace", "Replace text in every string value before encoding, as <old>=<new> (repeatable, keys are kept)")
+		fs.Var(&opts.replaceRegexes, "replace-regex", "Like --replace, with a regular expression: <pattern>=<replacement>, where $1 expands to a group (repeatable)")
+		fs.BoolVar(&opts.trimStrings, "trim-strings", false, "Trim surrounding whitespace from every string value before encoding (keys are kept, see --trim-keys)")
+		fs.BoolVar(&opts.trimKeys, "trim-keys", false, "Trim surrounding whitespace from every object key before encoding")
+		fs.IntVar(&opts.maxStringLen, "max-string-length", 0, "Truncate string values longer than this many characters, adding \"…\", before encoding (0 disables)")
+		fs.StringVar(&opts.caseName, "case", "", "Change every string value to lower or upper case before encoding (keys are kept, see --case-keys)")
+		fs.BoolVar(&opts.caseKeys, "case-keys", false, "With --case, also change the case of object keys")
+		fs.BoolVar(&opts.ignoreMissing, "ignore-missing", false, "With --remove or --edits, skip removals of pointers that do not resolve instead of failing")
+		fs.StringVar(&opts.embedInto, "embed-into", "", "Embed the escaped input as a string value inside this JSON template file")
+		fs.StringVar(&opts.embedAt, "at", "", "JSON Pointer location used by --embed-into (e.g. /body)")
+		fs.BoolVar(&opts.explode, "explode", false, "Encode each element of a top-level JSON array separately, one per line")
+		fs.BoolVar(&opts.each, "each", false, "Encode each document of a top-level JSON array, treating nested arrays as groups of documents")
+		fs.BoolVar(&opts.asArray, "as-array", false, "With --each, print a JSON array of the escaped strings instead of one per line")
+		fs.BoolVar(&opts.explodePass, "explode-passthrough", false, "With --explode, encode non-array input as a single document instead of failing")
+		fs.BoolVar(&opts.showType, "type", false, "Print the top-level JSON type of the input (object, array, string, number, boolean, null) and exit")
+		fs.StringVar(&opts.equalFile, "equal", "", "Compare the input with another JSON file and exit non-zero if they differ")
+		fs.BoolVar(&opts.roundtrip, "roundtrip", false, "Encode the input, decode the result and print it, exiting non-zero if it differs from the input")
+		fs.BoolVar(&opts.strictRoundtrip, "strict-roundtrip", false, "Like --roundtrip, but decode without re-formatting and require the result to match the input byte for byte")
+	}
+
+	// Output
+	if in("encode", "decode", "format") {
+		fs.BoolVar(&opts.rawOutput, "raw", false, "Output without trailing newline (useful for piping)")
+		fs.BoolVar(&opts.stripFinalNL, "strip-final-newline", false, "Remove line endings from the end of the output instead of ending it with one")
+		fs.StringVar(&opts.eol, "eol", "lf", "Line ending for pretty decoded output, NDJSON records and the trailing newline (lf, crlf)")
+		fs.StringVar(&opts.outputFile, "output", "", "Write the output to this file instead of stdout")
+		fs.BoolVar(&opts.gzip, "gzip", false, "Compress the --output file with gzip")
+		fs.StringVar(&opts.outputCharset, "output-charset", "utf-8", "Character set the output is written in (e.g. latin1, shift_jis)")
+		fs.StringVar(&opts.onUnmappable, "on-unmappable", "error", "How to handle characters --output-charset cannot represent: error, or replace them with '?'")
+		fs.BoolVar(&opts.dataURI, "data-uri", false, "Write the output as a base64 data: URI, e.g. data:application/json;base64,eyJhIjoxfQ==")
+		fs.BoolVar(&opts.dataURIPlain, "data-uri-plain", false, "Like --data-uri, but percent-encode the output instead of using base64")
+		fs.BoolVar(&opts.regexEscape, "regex-escape", false, "Escape regular expression metacharacters in the output so it matches itself literally")
+		fs.StringVar(&opts.quote, "quote", "", "Wrap the output in shell quotes (single, double)")
+		fs.StringVar(&opts.envName, "env-name", "", "Print the output as an environment variable assignment NAME=<output>")
+		fs.BoolVar(&opts.export, "export", false, "With --env-name, print a single-quoted export NAME='<output>' line to eval in a shell")
+		fs.BoolVar(&opts.markdown, "markdown", false, "Wrap the output in a fenced Markdown code block, for pasting into docs and issues")
+		fs.IntVar(&opts.pad, "pad", 0, "Pad the output with spaces to exactly this many characters for fixed-width records, failing if it is longer (0 disables)")
+		fs.BoolVar(&opts.padLeft, "pad-left", false, "With --pad, add the spaces before the output instead of after it")
+		fs.IntVar(&opts.warnSize, "warn-size", 0, "Warn on stderr when the output exceeds this many bytes (0 disables)")
+		fs.IntVar(&opts.failSize, "fail-size", 0, "Fail when the output exceeds this many bytes (0 disables)")
+		fs.BoolVar(&opts.count, "count", false, "Print structural statistics (keys, elements, depth, nodes) to stderr")
+		fs.BoolVar(&opts.countJSON, "count-json", false, "Print structural statistics as JSON to stdout instead of the converted output")
+		fs.BoolVar(&opts.summary, "summary", false, "Print a one-line digest of the conversion (type, sizes, escapes) to stderr")
+	}
+	fs.BoolVar(&opts.quiet, "quiet", false, "Suppress warnings on stderr")
+	fs.IntVar(&opts.jobs, "jobs", runtime.NumCPU(), "Number of files to process concurrently in batch mode")
+	if in("encode", "decode", "format") {
+		fs.IntVar(&opts.repeat, "repeat", 0, "Run the conversion this many times and report the timings on stderr, for profiling")
+	}
+	if name == "" {
+		fs.BoolVar(&opts.showVersion, "version", false, "Show version information")
+		fs.StringVar(&opts.completion, "completion", "", "Print a shell completion script (bash, zsh, fish) and exit")
+		fs.BoolVar(&opts.man, "man", false, "Print a roff manual page and exit")
+	}
+	if name == "" {
+		fs.BoolVar(&opts.showHelp, "help", false, "Show help with examples")
+	} else {
+		fs.BoolVar(&opts.showHelp, "help", false, "Show help for this command")
+	}
+
+	return fs
+}
+
+// flagPassed reports whether the named flag was given on the command line
+func flagPassed(fs *flag.FlagSet, name string) bool {
+	passed := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
+
+// checkAllowedFlags returns an error naming the first flag given on the command
+// line that is not in allowed, which lists the flags option can be combined with
+func checkAllowedFlags(fs *flag.FlagSet, option string, allowed map[string]bool) error {
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if err == nil && !allowed[f.Name] {
+			err = fmt.Errorf("%s cannot be used with --%s", option, f.Name)
+		}
+	})
+	return err
+}
+
+// hiddenFlags lists flags that work but are left out of help output, completion
+// scripts and the manual page
+var hiddenFlags = map[string]bool{
+	"man":    true,
+	"repeat": true,
+}
+
+// printDefaults prints the usage of the visible flags of fs to stderr
+func printDefaults(fs *flag.FlagSet) {
+	visible := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	visible.SetOutput(os.Stderr)
+	fs.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+			// Var takes the current value as the default, so restore the real one
+			visible.Lookup(f.Name).DefValue = f.DefValue
+		}
+	})
+	visible.PrintDefaults()
+}
+
+// printCommandUsage prints the usage message of a subcommand
+func printCommandUsage(opts *options) {
+	cmd, _ := lookupCommand(opts.command)
+	fmt.Fprintf(os.Stderr, "json-to-string %s - %s\n\n", cmd.name, cmd.summary)
+	fmt.Fprintf(os.Stderr, "Usage:\n")
+	fmt.Fprintf(os.Stderr, "  json-to-string %s [options]\n", cmd.name)
+	fmt.Fprintf(os.Stderr, "  json-to-string %s [options] file1.json file2.json ...\n\n", cmd.name)
+	fmt.Fprintf(os.Stderr, "Options:\n")
+	printDefaults(opts.flags)
+}
+
+// formatDocument pretty-prints a JSON document, or compacts it with --compact,
+// without escaping it. Key order, duplicate keys and number formatting are kept.
+func formatDocument(input []byte, opts *options) (string, error) {
+	if opts.strictKeys {
+		if err := jsonstr.CheckDuplicateKeys(input); err != nil {
+			return "", fmt.Errorf("formatting JSON: %w", err)
+		}
+	}
+
+	// json.Indent copies trailing whitespace, so remove it first
+	input = bytes.TrimSpace(input)
+	if opts.indentJSONStrings {
+		indented, err := jsonstr.IndentJSONStrings(input, opts.indent)
+		if err != nil {
+			return "", fmt.Errorf("formatting JSON: %w", err)
+		}
+		input = indented
+	}
+	var b bytes.Buffer
+	var err error
+	switch {
+	case opts.compact:
+		err = json.Compact(&b, input)
+	case opts.prettyDepth > 0:
+		err = jsonstr.IndentDepth(&b, input, opts.indent, opts.prettyDepth)
+	default:
+		err = json.Indent(&b, input, "", opts.indent)
+	}
+	if err != nil {
+		return "", fmt.Errorf("formatting JSON: invalid JSON: %w", err)
+	}
+	formatted := b.String()
+	if opts.lineNumbers {
+		formatted = numberLines(formatted)
+	}
+	return convertNewlines(formatted, opts), nil
+}
+
+// validateInput checks that the input is a valid JSON document, or with --ndjson
+// that every non-blank line is, and returns "valid"
+func validateInput(input []byte, opts *options) (string, error) {
+	if !opts.ndjson {
+		if err := validateDocument(input, opts); err != nil {
+			return "", fmt.Errorf("validating JSON: %w", err)
+		}
+		return "valid", nil
+	}
+
+	for i, line := range bytes.Split(input, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if err := validateDocument(line, opts); err != nil {
+			return "", fmt.Errorf("validating JSON: line %d: %w", i+1, err)
+		}
+	}
+	return "valid", nil
+}
+
+// validateDocument checks a single JSON document
+func validateDocument(input []byte, opts *options) error {
+	if _, err := jsonstr.Parse(input); err != nil {
+		return err
+	}
+	if err := checkExpected(input, opts); err != nil {
+		return err
+	}
+	if opts.strictKeys {
+		return jsonstr.CheckDuplicateKeys(input)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseArgs verifies subcommand dispatch and that legacy flags still parse
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		command string
+		decode  bool
+		pretty  bool
+		format  bool
+		files   []string
+	}{
+		{name: "Legacy encode", args: []string{"--pretty", "a.json"}, pretty: true, files: []string{"a.json"}},
+		{name: "Legacy decode", args: []string{"--decode"}, decode: true, files: []string{}},
+		{name: "Encode command", args: []string{"encode", "a.json", "b.json"}, command: "encode", files: []string{"a.json", "b.json"}},
+		{name: "Decode command", args: []string{"decode", "--pretty"}, command: "decode", decode: true, pretty: true, files: []string{}},
+		{name: "Format command", args: []string{"format"}, command: "format", format: true, files: []string{}},
+		{name: "Command name after flags is a file", args: []string{"--pretty", "decode"}, pretty: true, files: []string{"decode"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := parseArgs(tc.args)
+
+			if opts.command != tc.command {
+				t.Errorf("expected command %q but got %q", tc.command, opts.command)
+			}
+			if opts.decode != tc.decode || opts.pretty != tc.pretty || opts.format != tc.format {
+				t.Errorf("expected decode=%v pretty=%v format=%v but got decode=%v pretty=%v format=%v",
+					tc.decode, tc.pretty, tc.format, opts.decode, opts.pretty, opts.format)
+			}
+			if !reflect.DeepEqual(opts.files, tc.files) {
+				t.Errorf("expected files %v but got %v", tc.files, opts.files)
+			}
+			if opts.eol != "lf" || opts.fd != -1 || opts.jobs < 1 {
+				t.Errorf("expected defaults for flags the command does not accept, got eol=%q fd=%d jobs=%d", opts.eol, opts.fd, opts.jobs)
+			}
+		})
+	}
+}
+
+// TestSubcommands verifies each subcommand end to end
+func TestSubcommands(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	tests := []struct {
+		name        string
+		args        []string
+		input       string
+		expected    string
+		expectError string
+	}{
+		{
+			name:     "Encode",
+			args:     []string{"encode", "--compact"},
+			input:    "{\n  \"a\": 1\n}",
+			expected: `{\"a\":1}`,
+		},
+		{
+			name:     "Decode",
+			args:     []string{"decode", "--pretty", "--json", `{\"a\":[1]}`},
+			expected: "{\n  \"a\": [\n    1\n  ]\n}",
+		},
+		{
+			name:     "Format keeps key order and numbers",
+			args:     []string{"format"},
+			input:    `{"b":1.50,"a":[1e2]}`,
+			expected: "{\n  \"b\": 1.50,\n  \"a\": [\n    1e2\n  ]\n}",
+		},
+		{
+			name:     "Format compact",
+			args:     []string{"format", "--compact"},
+			input:    "{\n  \"b\": 1,\n  \"a\": 2\n}\n",
+			expected: `{"b":1,"a":2}`,
+		},
+		{
+			name:     "Format NDJSON",
+			args:     []string{"format", "--ndjson", "--indent", "\t"},
+			input:    "[1]\n{}\n",
+			expected: "[\n\t1\n]\n{}",
+		},
+		{
+			name:     "Format to a maximum depth",
+			args:     []string{"format", "--pretty-depth", "2"},
+			input:    `{"b":{"c":{"d":[1, 2]}},"a":[1, {"e":1.50}]}`,
+			expected: "{\n  \"b\": {\n    \"c\": {\"d\":[1,2]}\n  },\n  \"a\": [\n    1,\n    {\"e\":1.50}\n  ]\n}",
+		},
+		{
+			name:        "Format compact with a maximum depth",
+			args:        []string{"format", "--compact", "--pretty-depth", "2"},
+			input:       `{}`,
+			expectError: "--pretty-depth requires",
+		},
+		{
+			name:     "Decode with an indent width",
+			args:     []string{"decode", "--pretty", "--indent-width", "4", "--json", `{\"a\":[1]}`},
+			expected: "{\n    \"a\": [\n        1\n    ]\n}",
+		},
+		{
+			name:     "Format with an indent width",
+			args:     []string{"format", "--indent-width", "3"},
+			input:    `{"a":1}`,
+			expected: "{\n   \"a\": 1\n}",
+		},
+		{
+			name:        "Indent width with an indent string",
+			args:        []string{"format", "--indent-width", "4", "--indent", "\t"},
+			input:       `{}`,
+			expectError: "--indent-width cannot be used with --indent",
+		},
+		{
+			name:        "Indent width too large",
+			args:        []string{"format", "--indent-width", "17"},
+			input:       `{}`,
+			expectError: "invalid --indent-width value 17: must be between 1 and 16",
+		},
+		{
+			name:        "Indent width of zero",
+			args:        []string{"format", "--indent-width", "0"},
+			input:       `{}`,
+			expectError: "invalid --indent-width value 0",
+		},
+		{
+			name:        "Format invalid JSON",
+			args:        []string{"format"},
+			input:       `{"a":`,
+			expectError: "formatting JSON: invalid JSON",
+		},
+		{
+			name:     "Validate valid JSON",
+			args:     []string{"validate"},
+			input:    `{"a":[1,2]}`,
+			expected: "valid",
+		},
+		{
+			name:        "Validate invalid JSON",
+			args:        []string{"validate"},
+			input:       `{"a":[1,2}`,
+			expectError: "validating JSON: invalid JSON",
+		},
+		{
+			name:        "Validate NDJSON reports the line",
+			args:        []string{"validate", "--ndjson"},
+			input:       "1\n\n{\"a\":\n",
+			expectError: "validating JSON: line 3",
+		},
+		{
+			name:        "Validate with strict keys",
+			args:        []string{"validate", "--strict-keys"},
+			input:       `{"a":1,"a":2}`,
+			expectError: `duplicate key "a"`,
+		},
+		{
+			name:        "Flag of another command",
+			args:        []string{"decode", "--lang", "c"},
+			input:       `{}`,
+			expectError: "flag provided but not defined: -lang",
+		},
+		{
+			name:        "Decode flag is implied by the command",
+			args:        []string{"encode", "--decode"},
+			input:       `{}`,
+			expectError: "flag provided but not defined: -decode",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := exec.Command(binaryPath, tc.args...)
+			cmd.Stdin = strings.NewReader(tc.input)
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			err := cmd.Run()
+
+			if tc.expectError != "" {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				if !strings.Contains(stderr.String(), tc.expectError) {
+					t.Errorf("expected error containing %q but got %q", tc.expectError, stderr.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v, stderr: %s", err, stderr.String())
+			}
+			if output := strings.TrimSuffix(stdout.String(), "\n"); output != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, output)
+			}
+		})
+	}
+}
+
+// TestCommandHelp verifies each command lists only its own flags
+func TestCommandHelp(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	tests := []struct {
+		command  string
+		includes []string
+		excludes []string
+	}{
+		{command: "encode", includes: []string{"-lang", "-compact", "-pointer"}, excludes: []string{"-decode", "-wrap"}},
+		{command: "decode", includes: []string{"-pretty", "-wrap", "-unicode"}, excludes: []string{"-lang", "-compact"}},
+		{command: "format", includes: []string{"-compact", "-indent"}, excludes: []string{"-pretty", "-lang"}},
+		{command: "validate", includes: []string{"-strict-keys", "-ndjson"}, excludes: []string{"-raw", "-indent"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.command, func(t *testing.T) {
+			cmd := exec.Command(binaryPath, tc.command, "--help")
+			var stderr bytes.Buffer
+			cmd.Stderr = &stderr
+			if err := cmd.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			help := stderr.String()
+			if !strings.Contains(help, "json-to-string "+tc.command+" [options]") {
+				t.Errorf("expected usage line for %s but got:\n%s", tc.command, help)
+			}
+			for _, name := range tc.includes {
+				if !strings.Contains(help, "  "+name+"\n") && !strings.Contains(help, "  "+name+" ") {
+					t.Errorf("expected %s in help for %s", name, tc.command)
+				}
+			}
+			for _, name := range tc.excludes {
+				if strings.Contains(help, "  "+name+"\n") || strings.Contains(help, "  "+name+" ") {
+					t.Errorf("did not expect %s in help for %s", name, tc.command)
+				}
+			}
+		})
+	}
+}
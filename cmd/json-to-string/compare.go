@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// compareSetting is one of the encode layouts measured by --compare-options
+type compareSetting struct {
+	name  string
+	apply func(o *options)
+}
+
+// compareSettings lists the layouts --compare-options measures, in the order
+// they are printed. Each starts from the other options given on the command line.
+var compareSettings = []compareSetting{
+	{name: "default", apply: func(o *options) {}},
+	{name: "--strip-ws", apply: func(o *options) { o.stripWS = true }},
+	{name: "--compact", apply: func(o *options) { o.compact = true }},
+	{name: "--pretty", apply: func(o *options) { o.pretty = true }},
+}
+
+// compareOptions encodes input with each of compareSettings and writes a table of
+// the output sizes in bytes to w, marking the smallest. The layout flags given on
+// the command line are replaced by each setting, while the other options apply.
+func compareOptions(w io.Writer, input []byte, opts *options) error {
+	sizes := make([]int, len(compareSettings))
+	smallest := 0
+	for i, setting := range compareSettings {
+		o := *opts
+		o.compact, o.stripWS, o.pretty = false, false, false
+		setting.apply(&o)
+
+		result, err := convertDocument(input, &o)
+		if err != nil {
+			return fmt.Errorf("comparing %s: %w", setting.name, err)
+		}
+		sizes[i] = len(result)
+		if sizes[i] < sizes[smallest] {
+			smallest = i
+		}
+	}
+
+	fmt.Fprintf(w, "%-12s %8s\n", "Setting", "Bytes")
+	for i, setting := range compareSettings {
+		marker := ""
+		if i == smallest {
+			marker = "  (smallest)"
+		}
+		fmt.Fprintf(w, "%-12s %8d%s\n", setting.name, sizes[i], marker)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestCompareOptions verifies the size table of --compare-options
+func TestCompareOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     options
+		lines    []string
+		smallest string
+	}{
+		{
+			name:     "Whitespace is removed",
+			input:    `{ "b": [1, 2], "a": 1 }`,
+			lines:    []string{"default            27", "--strip-ws         21", "--compact          21", "--pretty           49"},
+			smallest: "--strip-ws",
+		},
+		{
+			name:     "Already compact input",
+			input:    `{"a":1}`,
+			lines:    []string{"default             9", "--strip-ws          9", "--compact           9"},
+			smallest: "default",
+		},
+		{
+			name:     "Layout flags are replaced",
+			input:    `{"a":1}`,
+			opts:     options{pretty: true},
+			lines:    []string{"default             9", "--pretty           16"},
+			smallest: "default",
+		},
+		{
+			name:     "Other options apply",
+			input:    `{"a":1}`,
+			opts:     options{asJSONStr: true},
+			lines:    []string{"default            11"},
+			smallest: "default",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := tc.opts
+			opts.indent = "  "
+			var stderr bytes.Buffer
+			if err := compareOptions(&stderr, []byte(tc.input), &opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, line := range tc.lines {
+				if !strings.Contains(stderr.String(), line) {
+					t.Errorf("expected a line containing %q but got:\n%s", line, stderr.String())
+				}
+			}
+			for _, line := range strings.Split(stderr.String(), "\n") {
+				if strings.HasSuffix(line, "(smallest)") && !strings.HasPrefix(line, tc.smallest+" ") {
+					t.Errorf("expected %s to be the smallest but got %q", tc.smallest, line)
+				}
+			}
+		})
+	}
+
+	var stderr bytes.Buffer
+	if err := compareOptions(&stderr, []byte(`{`), &options{}); err == nil {
+		t.Errorf("expected invalid JSON to fail")
+	}
+}
+
+// TestCompareOptionsFlag verifies the table goes to stderr and nothing to stdout
+func TestCompareOptionsFlag(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	cmd := exec.Command(binaryPath, "--compare-options", "--json", `{"a": 1}`)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.Len() > 0 {
+		t.Errorf("expected no output on stdout but got %q", stdout.String())
+	}
+	if !strings.HasPrefix(stderr.String(), "Setting") {
+		t.Errorf("expected a size table on stderr but got %q", stderr.String())
+	}
+
+	if err := exec.Command(binaryPath, "--compare-options", "--decode", "--json", `{}`).Run(); err == nil {
+		t.Errorf("expected --compare-options with --decode to fail")
+	}
+}
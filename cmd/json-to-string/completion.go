@@ -0,0 +1,262 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// flagChoices lists the accepted values of flags that take one of a fixed set,
+// so completion scripts can offer them
+var flagChoices = map[string][]string{
+	"case":           {"lower", "upper"},
+	"expect":         jsonTypes,
+	"lang":           {"c", "sql", "properties", "yaml", "toml"},
+	"sql-dialect":    {"ansi", "postgres", "mysql"},
+	"quote":          {"single", "double"},
+	"eol":            {"lf", "crlf"},
+	"unicode":        {"literal", "escaped"},
+	"completion":     {"bash", "zsh", "fish"},
+	"input-charset":  charsetNames(),
+	"output-charset": outputCharsetNames(),
+	"on-unmappable":  {"error", "replace"},
+}
+
+// fileFlags lists the flags whose value is a file path
+var fileFlags = map[string]bool{
+	"file":       true,
+	"embed-into": true,
+	"edits":      true,
+	"output":     true,
+	"equal":      true,
+}
+
+// completionFlag describes a flag for completion scripts
+type completionFlag struct {
+	name   string
+	usage  string
+	isBool bool
+}
+
+// commandFlags returns the flags accepted by the named subcommand, or by the
+// legacy interface if name is empty, in alphabetical order. Hidden flags are
+// left out.
+func commandFlags(name string) []completionFlag {
+	var flags []completionFlag
+	newFlagSet(name, &options{}).VisitAll(func(f *flag.Flag) {
+		if hiddenFlags[f.Name] {
+			return
+		}
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:   f.Name,
+			usage:  f.Usage,
+			isBool: ok && boolFlag.IsBoolFlag(),
+		})
+	})
+	return flags
+}
+
+// writeCompletion writes a completion script for the given shell
+func writeCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		writeBashCompletion(w)
+	case "zsh":
+		writeZshCompletion(w)
+	case "fish":
+		writeFishCompletion(w)
+	default:
+		return fmt.Errorf("unsupported shell %q for --completion: must be bash, zsh or fish", shell)
+	}
+	return nil
+}
+
+// commandNames returns the names of all subcommands
+func commandNames() []string {
+	names := make([]string, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.name
+	}
+	return names
+}
+
+// flagWords returns the flags as --name words separated by spaces
+func flagWords(flags []completionFlag) string {
+	words := make([]string, len(flags))
+	for i, f := range flags {
+		words[i] = "--" + f.name
+	}
+	return strings.Join(words, " ")
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintf(w, "# bash completion for json-to-string\n")
+	fmt.Fprintf(w, "#\n")
+	fmt.Fprintf(w, "# Install by saving this script as one of:\n")
+	fmt.Fprintf(w, "#   /etc/bash_completion.d/json-to-string                      (system-wide)\n")
+	fmt.Fprintf(w, "#   ~/.local/share/bash-completion/completions/json-to-string  (current user)\n")
+	fmt.Fprintf(w, "# or load it in the current shell with: source <(json-to-string --completion bash)\n\n")
+
+	fmt.Fprintf(w, "_json_to_string() {\n")
+	fmt.Fprintf(w, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(w, "    local prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(w, "    local command=\"\"\n")
+	fmt.Fprintf(w, "    if [[ ${COMP_CWORD} -gt 1 ]]; then\n")
+	fmt.Fprintf(w, "        case \"${COMP_WORDS[1]}\" in\n")
+	fmt.Fprintf(w, "            %s) command=\"${COMP_WORDS[1]}\" ;;\n", strings.Join(commandNames(), "|"))
+	fmt.Fprintf(w, "        esac\n")
+	fmt.Fprintf(w, "    fi\n\n")
+
+	// Values of the previous flag
+	fmt.Fprintf(w, "    case \"${prev}\" in\n")
+	for _, f := range commandFlags("") {
+		switch {
+		case f.isBool:
+			continue
+		case fileFlags[f.name]:
+			fmt.Fprintf(w, "        --%s|-%s)\n            COMPREPLY=($(compgen -f -- \"${cur}\"))\n            return ;;\n", f.name, f.name)
+		case flagChoices[f.name] != nil:
+			fmt.Fprintf(w, "        --%s|-%s)\n            COMPREPLY=($(compgen -W \"%s\" -- \"${cur}\"))\n            return ;;\n",
+				f.name, f.name, strings.Join(flagChoices[f.name], " "))
+		default:
+			fmt.Fprintf(w, "        --%s|-%s)\n            COMPREPLY=()\n            return ;;\n", f.name, f.name)
+		}
+	}
+	fmt.Fprintf(w, "    esac\n\n")
+
+	// Flags of the current command
+	fmt.Fprintf(w, "    local flags\n")
+	fmt.Fprintf(w, "    case \"${command}\" in\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "        %s) flags=\"%s\" ;;\n", cmd.name, flagWords(commandFlags(cmd.name)))
+	}
+	fmt.Fprintf(w, "        *) flags=\"%s\" ;;\n", flagWords(commandFlags("")))
+	fmt.Fprintf(w, "    esac\n\n")
+
+	fmt.Fprintf(w, "    if [[ \"${cur}\" == -* ]]; then\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"${flags}\" -- \"${cur}\"))\n")
+	fmt.Fprintf(w, "    elif [[ ${COMP_CWORD} -eq 1 ]]; then\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"%s\" -- \"${cur}\") $(compgen -f -- \"${cur}\"))\n", strings.Join(commandNames(), " "))
+	fmt.Fprintf(w, "    else\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -f -- \"${cur}\"))\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "complete -o filenames -F _json_to_string json-to-string\n")
+}
+
+// zshSpec returns the _arguments specification of a flag
+func zshSpec(f completionFlag) string {
+	usage := strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`).Replace(f.usage)
+	spec := fmt.Sprintf("--%s[%s]", f.name, usage)
+	switch {
+	case f.isBool:
+	case fileFlags[f.name]:
+		spec += ":file:_files"
+	case flagChoices[f.name] != nil:
+		spec += fmt.Sprintf(":%s:(%s)", f.name, strings.Join(flagChoices[f.name], " "))
+	default:
+		spec += fmt.Sprintf(":%s: ", f.name)
+	}
+	return "'" + spec + "'"
+}
+
+// writeZshArguments writes an _arguments call completing the given flags
+func writeZshArguments(w io.Writer, flags []completionFlag, indent string, extra ...string) {
+	fmt.Fprintf(w, "%s_arguments -s \\\n", indent)
+	for _, f := range flags {
+		fmt.Fprintf(w, "%s    %s \\\n", indent, zshSpec(f))
+	}
+	for _, spec := range extra {
+		fmt.Fprintf(w, "%s    %s \\\n", indent, spec)
+	}
+	fmt.Fprintf(w, "%s    '*:file:_files'\n", indent)
+}
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintf(w, "#compdef json-to-string\n")
+	fmt.Fprintf(w, "#\n")
+	fmt.Fprintf(w, "# zsh completion for json-to-string\n")
+	fmt.Fprintf(w, "#\n")
+	fmt.Fprintf(w, "# Install by saving this script as _json-to-string in a directory on $fpath, e.g.\n")
+	fmt.Fprintf(w, "#   /usr/local/share/zsh/site-functions/_json-to-string  (system-wide)\n")
+	fmt.Fprintf(w, "#   ~/.zsh/completions/_json-to-string                   (current user, add the directory to $fpath)\n")
+	fmt.Fprintf(w, "# then restart zsh, or load it in the current shell with: source <(json-to-string --completion zsh)\n\n")
+
+	fmt.Fprintf(w, "_json-to-string() {\n")
+	fmt.Fprintf(w, "    local -a commands\n")
+	fmt.Fprintf(w, "    commands=(\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "        '%s:%s'\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "    )\n\n")
+
+	fmt.Fprintf(w, "    case \"${words[2]}\" in\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "    %s)\n", cmd.name)
+		fmt.Fprintf(w, "        shift words\n")
+		fmt.Fprintf(w, "        (( CURRENT-- ))\n")
+		writeZshArguments(w, commandFlags(cmd.name), "        ")
+		fmt.Fprintf(w, "        ;;\n")
+	}
+	fmt.Fprintf(w, "    *)\n")
+	fmt.Fprintf(w, "        local state\n")
+	writeZshArguments(w, commandFlags(""), "        ", "'1:command or file:->first'")
+	fmt.Fprintf(w, "        if [[ \"$state\" == first ]]; then\n")
+	fmt.Fprintf(w, "            _describe -t commands command commands\n")
+	fmt.Fprintf(w, "            _files\n")
+	fmt.Fprintf(w, "        fi\n")
+	fmt.Fprintf(w, "        ;;\n")
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "}\n\n")
+
+	// Work both when autoloaded from $fpath and when sourced
+	fmt.Fprintf(w, "if [[ \"${funcstack[1]}\" == _json-to-string ]]; then\n")
+	fmt.Fprintf(w, "    _json-to-string \"$@\"\n")
+	fmt.Fprintf(w, "else\n")
+	fmt.Fprintf(w, "    compdef _json-to-string json-to-string\n")
+	fmt.Fprintf(w, "fi\n")
+}
+
+// writeFishFlag writes a fish complete command for a flag, shown under condition
+func writeFishFlag(w io.Writer, f completionFlag, condition string) {
+	usage := strings.ReplaceAll(f.usage, `'`, `\'`)
+	line := fmt.Sprintf("complete -c json-to-string -n '%s' -l %s -d '%s'", condition, f.name, usage)
+	switch {
+	case f.isBool:
+	case fileFlags[f.name]:
+		line += " -r -F"
+	case flagChoices[f.name] != nil:
+		line += fmt.Sprintf(" -x -a '%s'", strings.Join(flagChoices[f.name], " "))
+	default:
+		line += " -x"
+	}
+	fmt.Fprintln(w, line)
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintf(w, "# fish completion for json-to-string\n")
+	fmt.Fprintf(w, "#\n")
+	fmt.Fprintf(w, "# Install by saving this script as one of:\n")
+	fmt.Fprintf(w, "#   ~/.config/fish/completions/json-to-string.fish                   (current user)\n")
+	fmt.Fprintf(w, "#   /usr/share/fish/vendor_completions.d/json-to-string.fish         (system-wide)\n")
+	fmt.Fprintf(w, "# or load it in the current shell with: json-to-string --completion fish | source\n\n")
+
+	names := strings.Join(commandNames(), " ")
+	fmt.Fprintf(w, "# Commands\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "complete -c json-to-string -n 'not __fish_seen_subcommand_from %s' -a %s -d '%s'\n", names, cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintf(w, "\n# Flags without a command\n")
+	for _, f := range commandFlags("") {
+		writeFishFlag(w, f, "not __fish_seen_subcommand_from "+names)
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "\n# Flags of the %s command\n", cmd.name)
+		for _, f := range commandFlags(cmd.name) {
+			writeFishFlag(w, f, "__fish_seen_subcommand_from "+cmd.name)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestWriteCompletion verifies every shell script mentions all commands and flags
+func TestWriteCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeCompletion(&buf, shell); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			script := buf.String()
+
+			if !strings.HasPrefix(script, "#") || !strings.Contains(script, "Install") {
+				t.Errorf("expected the script to start with install instructions")
+			}
+			for _, name := range commandNames() {
+				if !strings.Contains(script, name) {
+					t.Errorf("expected command %q in the %s script", name, shell)
+				}
+			}
+			for _, name := range append([]string{""}, commandNames()...) {
+				for _, f := range commandFlags(name) {
+					if !strings.Contains(script, f.name) {
+						t.Errorf("expected flag %q in the %s script", f.name, shell)
+					}
+				}
+			}
+			for _, choice := range flagChoices["lang"] {
+				if !strings.Contains(script, choice) {
+					t.Errorf("expected --lang value %q in the %s script", choice, shell)
+				}
+			}
+		})
+	}
+
+	if err := writeCompletion(&bytes.Buffer{}, "powershell"); err == nil {
+		t.Errorf("expected an error for an unsupported shell")
+	}
+}
+
+// TestBashCompletion checks the generated bash script parses and completes flags
+func TestBashCompletion(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	binaryPath := buildTestBinary(t)
+
+	script, err := exec.Command(binaryPath, "--completion", "bash").Output()
+	if err != nil {
+		t.Fatalf("failed to generate completion: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		words    string
+		expected string
+	}{
+		{name: "Command names", words: "json-to-string de", expected: "decode"},
+		{name: "Command flags", words: "json-to-string decode --un", expected: "--unicode"},
+		{name: "Flag values", words: "json-to-string --quote s", expected: "single"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			words := strings.Fields(tc.words)
+			cmd := exec.Command("bash", "-c", `eval "$SCRIPT"; COMP_WORDS=($WORDS); COMP_CWORD=$((${#COMP_WORDS[@]}-1)); _json_to_string; echo "${COMPREPLY[@]}"`)
+			cmd.Env = append(cmd.Environ(), "SCRIPT="+string(script), "WORDS="+strings.Join(words, " "))
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("bash failed: %v\n%s", err, out)
+			}
+			if got := strings.TrimSpace(string(out)); got != tc.expected {
+				t.Errorf("expected completions %q but got %q", tc.expected, got)
+			}
+		})
+	}
+}
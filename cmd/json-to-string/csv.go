@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// jsonNumberPattern matches the JSON number grammar, used by --csv-infer-types
+// so that cells such as 007 or 1. stay strings
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// fromCSV reads CSV for --from-csv as a JSON array with one object per row,
+// keyed by the header in the first row. Keys keep the order of the columns.
+// Cells are strings unless --csv-infer-types is set. Rows must have as many
+// cells as the header, except with --csv-lenient, where missing cells are left
+// out of the object and extra cells are ignored.
+func fromCSV(input []byte, opts *options) ([]byte, error) {
+	// Spreadsheet programs often start CSV files with a UTF-8 byte order mark
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(input, []byte("\ufeff"))))
+	if opts.csvLenient {
+		reader.FieldsPerRecord = -1
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []byte("[]"), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	seen := make(map[string]bool, len(header))
+	for _, name := range header {
+		if seen[name] {
+			return nil, fmt.Errorf("invalid CSV: duplicate column %q in the header", name)
+		}
+		seen[name] = true
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for rows := 0; ; rows++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("invalid CSV: %w (use --csv-lenient to accept it)", err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		if rows > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('{')
+		for i, cell := range record {
+			if i >= len(header) {
+				break
+			}
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(header[i])
+			b.Write(key)
+			b.WriteByte(':')
+			b.Write(csvValue(cell, opts.csvInferTypes))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(']')
+	return b.Bytes(), nil
+}
+
+// csvValue returns the JSON form of a CSV cell. With infer set, numbers and the
+// literals true, false and null are written as such instead of as strings.
+func csvValue(cell string, infer bool) []byte {
+	if infer {
+		switch {
+		case cell == "true" || cell == "false" || cell == "null":
+			return []byte(cell)
+		case jsonNumberPattern.MatchString(cell):
+			return []byte(cell)
+		}
+	}
+	value, _ := json.Marshal(cell)
+	return value
+}
+
+// toCSV writes a JSON array of objects as CSV for --to-csv. The header is the
+// union of the keys of all objects, in the order they first appear, and a key
+// missing from an object gives an empty cell. Strings are written without
+// quotes, null as an empty cell, and nested objects and arrays as compact JSON.
+func toCSV(input []byte, opts *options) (string, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(input, &elements); err != nil {
+		typ, typeErr := jsonstr.TopLevelType(input)
+		if typeErr != nil {
+			return "", typeErr
+		}
+		if typ != "array" {
+			return "", fmt.Errorf("top-level value must be an array of objects, not %s", article(typ))
+		}
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var header []string
+	columns := make(map[string]int)
+	rows := make([]map[string]string, len(elements))
+	for i, element := range elements {
+		row, keys, err := csvRow(element)
+		if err != nil {
+			return "", fmt.Errorf("element %d: %w", i, err)
+		}
+		for _, key := range keys {
+			if _, ok := columns[key]; !ok {
+				columns[key] = len(header)
+				header = append(header, key)
+			}
+		}
+		rows[i] = row
+	}
+	if len(header) == 0 {
+		return "", nil
+	}
+
+	var b bytes.Buffer
+	writer := csv.NewWriter(&b)
+	writer.UseCRLF = opts.eol == "crlf"
+	if err := writer.Write(header); err != nil {
+		return "", err
+	}
+	record := make([]string, len(header))
+	for _, row := range rows {
+		for i, key := range header {
+			record[i] = row[key]
+		}
+		if err := writer.Write(record); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+	// Like every other result, the CSV does not end with a line ending
+	return strings.TrimRight(b.String(), "\r\n"), nil
+}
+
+// csvRow returns the cells of a JSON object keyed by its keys, and the keys in
+// the order they appear. A repeated key keeps its first position and last value.
+func csvRow(element json.RawMessage) (map[string]string, []string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(element))
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		typ, _ := jsonstr.TopLevelType(element)
+		return nil, nil, fmt.Errorf("expected an object but got %s", article(typ))
+	}
+
+	row := make(map[string]string)
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		cell, err := csvCell(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := row[key]; !ok {
+			keys = append(keys, key)
+		}
+		row[key] = cell
+	}
+	return row, keys, nil
+}
+
+// csvCell returns the CSV cell for a JSON value
+func csvCell(value json.RawMessage) (string, error) {
+	switch value[0] {
+	case '"':
+		var s string
+		err := json.Unmarshal(value, &s)
+		return s, err
+	case 'n':
+		return "", nil
+	case '{', '[':
+		var b bytes.Buffer
+		err := json.Compact(&b, value)
+		return b.String(), err
+	default:
+		// Numbers keep their original form, and booleans are true or false
+		return string(value), nil
+	}
+}
+
+// article returns a JSON type name with its indefinite article, as in "an object"
+func article(typ string) string {
+	switch typ {
+	case "object", "array":
+		return "an " + typ
+	case "null":
+		return typ
+	default:
+		return "a " + typ
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestFromCSV verifies how --from-csv builds a JSON array of objects
+func TestFromCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     options
+		expected string
+		errorMsg string
+	}{
+		{
+			name:     "Header keys keep the column order",
+			input:    "name,age\nann,30\nbob,41\n",
+			expected: `[{"name":"ann","age":"30"},{"name":"bob","age":"41"}]`,
+		},
+		{
+			name:     "Quoted fields with commas, quotes and newlines",
+			input:    "id,note\n1,\"a, \"\"b\"\"\"\n2,\"line\nbreak\"\n",
+			expected: `[{"id":"1","note":"a, \"b\""},{"id":"2","note":"line\nbreak"}]`,
+		},
+		{
+			name:     "Inferred types",
+			input:    "n,f,e,z,b,x,s,empty\n-12,1.5,2e3,007,true,null,1.,\n",
+			opts:     options{csvInferTypes: true},
+			expected: `[{"n":-12,"f":1.5,"e":2e3,"z":"007","b":true,"x":null,"s":"1.","empty":""}]`,
+		},
+		{
+			name:     "Without inference everything is a string",
+			input:    "n,b\n1,true\n",
+			expected: `[{"n":"1","b":"true"}]`,
+		},
+		{
+			name:     "Byte order mark",
+			input:    "\ufeffa\n1\n",
+			expected: `[{"a":"1"}]`,
+		},
+		{name: "Header only", input: "a,b\n", expected: `[]`},
+		{name: "Empty input", input: "", expected: `[]`},
+		{
+			name:     "Ragged rows",
+			input:    "a,b\n1\n",
+			errorMsg: "wrong number of fields (use --csv-lenient to accept it)",
+		},
+		{
+			name:     "Lenient ragged rows",
+			input:    "a,b\n1\n1,2,3\n",
+			opts:     options{csvLenient: true},
+			expected: `[{"a":"1"},{"a":"1","b":"2"}]`,
+		},
+		{name: "Duplicate header", input: "a,a\n1,2\n", errorMsg: `duplicate column "a"`},
+		{name: "Bare quote", input: "a\nx\"y\n", errorMsg: "invalid CSV"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := fromCSV([]byte(tc.input), &tc.opts)
+			if tc.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+					t.Errorf("expected error containing %q but got %v", tc.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+// TestToCSV verifies how --to-csv writes an array of objects
+func TestToCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		eol      string
+		expected string
+		errorMsg string
+	}{
+		{
+			name:     "Header follows the order of the keys",
+			input:    `[{"name":"ann","age":30},{"name":"bob","age":41}]`,
+			expected: "name,age\nann,30\nbob,41",
+		},
+		{
+			name:     "Union of keys with missing cells",
+			input:    `[{"a":1},{"b":2},{"c":3,"a":4}]`,
+			expected: "a,b,c\n1,,\n,2,\n4,,3",
+		},
+		{
+			name:     "Scalars",
+			input:    `[{"s":"x","n":1.50,"t":true,"f":false,"z":null}]`,
+			expected: "s,n,t,f,z\nx,1.50,true,false,",
+		},
+		{
+			name:     "Quoting",
+			input:    `[{"note":"a, \"b\"","multi":"line\nbreak"}]`,
+			expected: "note,multi\n\"a, \"\"b\"\"\",\"line\nbreak\"",
+		},
+		{
+			name:     "Nested values as JSON",
+			input:    `[{"tags":["x", "y"],"owner":{"id": 7}}]`,
+			expected: "tags,owner\n\"[\"\"x\"\",\"\"y\"\"]\",\"{\"\"id\"\":7}\"",
+		},
+		{
+			name:     "Repeated key keeps its position and last value",
+			input:    `[{"a":1,"b":2,"a":3}]`,
+			expected: "a,b\n3,2",
+		},
+		{name: "CRLF line endings", input: `[{"a":1},{"a":2}]`, eol: "crlf", expected: "a\r\n1\r\n2"},
+		{name: "Empty array", input: `[]`, expected: ""},
+		{name: "Top-level object", input: `{"a":1}`, errorMsg: "top-level value must be an array of objects, not an object"},
+		{name: "Array of numbers", input: `[{"a":1},2]`, errorMsg: "element 1: expected an object but got a number"},
+		{name: "Array of arrays", input: `[[1]]`, errorMsg: "element 0: expected an object but got an array"},
+		{name: "Invalid JSON", input: `[{"a":`, errorMsg: "invalid JSON"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := toCSV([]byte(tc.input), &options{eol: tc.eol})
+			if tc.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+					t.Errorf("expected error containing %q but got %v", tc.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, result)
+			}
+		})
+	}
+
+	// Reading the CSV back gives the same table
+	table := "id,name\n1,\"Doe, Jane\"\n2,"
+	document, err := fromCSV([]byte(table), &options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := toCSV(document, &options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != table {
+		t.Errorf("expected %q after a roundtrip but got %q", table, result)
+	}
+}
+
+// TestCSVFlags verifies --from-csv and --to-csv on the command line
+func TestCSVFlags(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	cmd := exec.Command(binaryPath, "encode", "--from-csv", "--csv-infer-types")
+	cmd.Stdin = strings.NewReader("id,name\n1,\"Doe, Jane\"\n")
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(output) != `[{\"id\":1,\"name\":\"Doe, Jane\"}]`+"\n" {
+		t.Errorf("expected the table encoded as JSON but got %q", output)
+	}
+
+	output, err = exec.Command(binaryPath, "encode", "--to-csv", "--json", `[{"id":1,"name":"Doe, Jane"}]`).Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(output) != "id,name\n1,\"Doe, Jane\"\n" {
+		t.Errorf("expected CSV output but got %q", output)
+	}
+
+	if err := exec.Command(binaryPath, "--csv-infer-types", "--json", `{}`).Run(); err == nil {
+		t.Errorf("expected --csv-infer-types without --from-csv to fail")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// dataURI wraps the output in a data: URI for --data-uri, base64-encoded or,
+// with --data-uri-plain, percent-encoded
+func dataURI(result string, opts *options) string {
+	if opts.dataURIPlain {
+		return "data:" + dataURIMediaType(opts) + "," + percentEncode(result)
+	}
+	return "data:" + dataURIMediaType(opts) + ";base64," + base64.StdEncoding.EncodeToString([]byte(result))
+}
+
+// dataURIMediaType returns the media type of the --data-uri output. Like the
+// language of a --markdown code block, it is JSON only when the output is a
+// JSON document, and plain text for a bare escaped string.
+func dataURIMediaType(opts *options) string {
+	switch {
+	case opts.regexEscape:
+	case opts.both:
+		if opts.bothJSON {
+			return "application/json"
+		}
+	case opts.decode || opts.format || opts.asJSONStr || opts.asArray || opts.embedInto != "" || opts.escapeVals || opts.pointersJSON:
+		return "application/json"
+	case opts.toCSV:
+		return "text/csv;charset=" + opts.outputCharset
+	case opts.toJSON5:
+		return "application/json5"
+	}
+	// Without a charset, data: URIs default to US-ASCII
+	return "text/plain;charset=" + opts.outputCharset
+}
+
+// percentEncode escapes every byte of s except the unreserved characters of
+// RFC 3986 as %XX, which is safe anywhere in a URI
+func percentEncode(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&0x0F])
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/url"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// parseDataURI returns the media type and the decoded data of a data: URI
+func parseDataURI(t *testing.T, uri string) (mediaType, data string) {
+	t.Helper()
+	header, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok || !strings.HasPrefix(uri, "data:") {
+		t.Fatalf("not a data URI: %s", uri)
+	}
+	if mediaType, ok = strings.CutSuffix(header, ";base64"); ok {
+		decoded, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			t.Fatalf("invalid base64 in %s: %v", uri, err)
+		}
+		return mediaType, string(decoded)
+	}
+	decoded, err := url.PathUnescape(payload)
+	if err != nil {
+		t.Fatalf("invalid percent-encoding in %s: %v", uri, err)
+	}
+	return mediaType, decoded
+}
+
+// TestDataURI verifies that the data URIs of --data-uri decode back to the output
+func TestDataURI(t *testing.T) {
+	tests := []struct {
+		name      string
+		result    string
+		opts      options
+		mediaType string
+	}{
+		{name: "Escaped string", result: `{\"a\":\"é\"}`, mediaType: "text/plain;charset=utf-8"},
+		{name: "Formatted JSON", result: "{\n  \"a\": [1, \"x/y?z\"]\n}", opts: options{format: true}, mediaType: "application/json"},
+		{name: "JSON string", result: `"{\"a\":1}"`, opts: options{asJSONStr: true}, mediaType: "application/json"},
+		{name: "CSV", result: "a,b\n1,2", opts: options{toCSV: true}, mediaType: "text/csv;charset=utf-8"},
+		{name: "Plain escaped string", result: `{\"a\":\"b c+d%\"}`, opts: options{dataURIPlain: true}, mediaType: "text/plain;charset=utf-8"},
+		{name: "Plain JSON", result: `{"a":"é&b=c#d"}`, opts: options{decode: true, dataURIPlain: true}, mediaType: "application/json"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := tc.opts
+			opts.outputCharset = "utf-8"
+			uri := dataURI(tc.result, &opts)
+			mediaType, data := parseDataURI(t, uri)
+			if mediaType != tc.mediaType {
+				t.Errorf("expected media type %s but got %s", tc.mediaType, mediaType)
+			}
+			if data != tc.result {
+				t.Errorf("expected %s to decode to %q but got %q", uri, tc.result, data)
+			}
+		})
+	}
+}
+
+// TestDataURIFlag verifies that the encode and format output of --data-uri
+// decodes back to the usual output
+func TestDataURIFlag(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "Encode", args: []string{"--data-uri", "--json", `{"a": "b"}`}, expected: `{\"a\": \"b\"}`},
+		{name: "Encode as a JSON string", args: []string{"--data-uri-plain", "--as-json-string", "--json", `{"a":1}`}, expected: `"{\"a\":1}"`},
+		{name: "Format", args: []string{"format", "--data-uri", "--json", `{"a":[1]}`}, expected: "{\n  \"a\": [\n    1\n  ]\n}"},
+		{name: "Format with percent-encoding", args: []string{"format", "--data-uri-plain", "--compact", "--json", `{"a": "b c"}`}, expected: `{"a":"b c"}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := exec.Command(binaryPath, tc.args...).Output()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, data := parseDataURI(t, strings.TrimSuffix(string(output), "\n"))
+			if data != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, data)
+			}
+		})
+	}
+
+	if err := exec.Command(binaryPath, "--data-uri", "--hex", "--json", `{}`).Run(); err == nil {
+		t.Errorf("expected --data-uri with --hex to fail")
+	}
+}
+
+// TestPercentEncode verifies that only unreserved characters are left as they are
+func TestPercentEncode(t *testing.T) {
+	input := `aZ09-._~ "{}%/é`
+	expected := `aZ09-._~%20%22%7B%7D%25%2F%C3%A9`
+	if result := percentEncode(input); result != expected {
+		t.Errorf("expected %s but got %s", expected, result)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// edit is one entry of an --edits file: a "set" of a value or a "remove" at a
+// JSON Pointer
+type edit struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// readEdits reads and checks the JSON array of edits in an --edits file
+func readEdits(path string) ([]edit, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading edits: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	var edits []edit
+	if err := decoder.Decode(&edits); err != nil {
+		return nil, fmt.Errorf("invalid edits in %s: expected an array of {op, path, value} objects: %w", path, err)
+	}
+
+	for i, e := range edits {
+		if e.Path == nil {
+			return nil, fmt.Errorf("invalid edit %d in %s: missing path", i, path)
+		}
+		switch e.Op {
+		case "set":
+			if e.Value == nil {
+				return nil, fmt.Errorf("invalid edit %d in %s: set requires a value", i, path)
+			}
+		case "remove":
+			if e.Value != nil {
+				return nil, fmt.Errorf("invalid edit %d in %s: remove does not take a value", i, path)
+			}
+		default:
+			return nil, fmt.Errorf("invalid edit %d in %s: unknown op %q, must be set or remove", i, path, e.Op)
+		}
+	}
+	return edits, nil
+}
+
+// applyEdits applies the edits of the --edits file to data in order, returning
+// the edited data. An edit that fails is reported with its index in the file.
+// With ignoreMissing, removals of pointers that do not resolve are skipped, as
+// for --remove.
+func applyEdits(data interface{}, path string, ignoreMissing bool) (interface{}, error) {
+	edits, err := readEdits(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range edits {
+		if e.Op == "remove" {
+			removed, err := jsonstr.RemovePointer(data, *e.Path)
+			if errors.Is(err, jsonstr.ErrNoValue) && ignoreMissing {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("edit %d (remove %s): %w", i, *e.Path, err)
+			}
+			data = removed
+			continue
+		}
+
+		value, err := jsonstr.Parse(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("edit %d (set %s): %w", i, *e.Path, err)
+		}
+		if err := jsonstr.SetPointer(data, *e.Path, value); err != nil {
+			return nil, fmt.Errorf("edit %d (set %s): %w", i, *e.Path, err)
+		}
+	}
+	return data, nil
+}
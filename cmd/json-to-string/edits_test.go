@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// TestApplyEdits verifies that the edits of an --edits file are applied in order
+// and that a failing edit is reported with its index
+func TestApplyEdits(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		edits         string
+		ignoreMissing bool
+		expected      string
+		errMsg        string
+	}{
+		{
+			name:     "Set and remove on nested paths",
+			input:    `{"user":{"name":"John","password":"secret","roles":["admin","dev"]},"meta":{}}`,
+			edits:    `[{"op":"set","path":"/user/name","value":"Jane"},{"op":"remove","path":"/user/password"},{"op":"set","path":"/user/roles/-","value":"ops"},{"op":"remove","path":"/user/roles/0"},{"op":"set","path":"/meta/build","value":{"id":1.50}}]`,
+			expected: `{"meta":{"build":{"id":1.50}},"user":{"name":"Jane","roles":["dev","ops"]}}`,
+		},
+		{
+			name:     "Later edits see earlier ones",
+			input:    `{"a":{"b":1}}`,
+			edits:    `[{"op":"set","path":"/a/c","value":[1,2]},{"op":"remove","path":"/a/c/0"},{"op":"set","path":"/a/b","value":null}]`,
+			expected: `{"a":{"b":null,"c":[2]}}`,
+		},
+		{
+			name:     "No edits",
+			input:    `{"a":1}`,
+			edits:    `[]`,
+			expected: `{"a":1}`,
+		},
+		{
+			name:   "Failing remove",
+			input:  `{"a":{"b":1}}`,
+			edits:  `[{"op":"remove","path":"/a/b"},{"op":"remove","path":"/a/b"}]`,
+			errMsg: "edit 1 (remove /a/b)",
+		},
+		{
+			name:          "Missing remove with ignore missing",
+			input:         `{"a":{"b":1}}`,
+			edits:         `[{"op":"remove","path":"/a/b"},{"op":"remove","path":"/a/b"},{"op":"remove","path":"/x/0"}]`,
+			ignoreMissing: true,
+			expected:      `{"a":{}}`,
+		},
+		{
+			name:          "Invalid remove with ignore missing",
+			input:         `{"a":1}`,
+			edits:         `[{"op":"remove","path":"a"}]`,
+			ignoreMissing: true,
+			errMsg:        "edit 0 (remove a)",
+		},
+		{
+			name:          "Failing set with ignore missing",
+			input:         `{"a":[]}`,
+			edits:         `[{"op":"set","path":"/a/5","value":2}]`,
+			ignoreMissing: true,
+			errMsg:        "edit 0 (set /a/5)",
+		},
+		{
+			name:   "Failing set",
+			input:  `{"a":[]}`,
+			edits:  `[{"op":"set","path":"/a/-","value":1},{"op":"set","path":"/a/5","value":2}]`,
+			errMsg: "edit 1 (set /a/5)",
+		},
+		{
+			name:   "Unknown op",
+			input:  `{}`,
+			edits:  `[{"op":"set","path":"/a","value":1},{"op":"move","path":"/a"}]`,
+			errMsg: `invalid edit 1 in edits.json: unknown op "move", must be set or remove`,
+		},
+		{
+			name:   "Missing path",
+			input:  `{}`,
+			edits:  `[{"op":"remove"}]`,
+			errMsg: "invalid edit 0 in edits.json: missing path",
+		},
+		{
+			name:   "Set without a value",
+			input:  `{}`,
+			edits:  `[{"op":"set","path":"/a"}]`,
+			errMsg: "invalid edit 0 in edits.json: set requires a value",
+		},
+		{
+			name:   "Remove with a value",
+			input:  `{"a":1}`,
+			edits:  `[{"op":"remove","path":"/a","value":1}]`,
+			errMsg: "invalid edit 0 in edits.json: remove does not take a value",
+		},
+		{
+			name:   "Not an array",
+			input:  `{}`,
+			edits:  `{"op":"remove","path":"/a"}`,
+			errMsg: "invalid edits in edits.json",
+		},
+		{
+			name:   "Unknown field",
+			input:  `{}`,
+			edits:  `[{"op":"set","path":"/a","val":1}]`,
+			errMsg: "invalid edits in edits.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			if err := os.WriteFile(filepath.Join(dir, "edits.json"), []byte(tt.edits), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := jsonstr.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := applyEdits(data, "edits.json", tt.ignoreMissing)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			output, err := json.Marshal(result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, output)
+			}
+		})
+	}
+}
+
+// TestEditsFlag verifies --edits from the command line, together with --set and --remove
+func TestEditsFlag(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+	editsPath := filepath.Join(t.TempDir(), "edits.json")
+	edits := `[{"op": "set", "path": "/user/name", "value": "Jane"}, {"op": "remove", "path": "/user/token"}]`
+	if err := os.WriteFile(editsPath, []byte(edits), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Edits",
+			args:     []string{"--edits", editsPath, "--json", `{"user":{"name":"John","token":"x","id":7}}`},
+			expected: `{\"user\":{\"id\":7,\"name\":\"Jane\"}}`,
+		},
+		{
+			name:     "Edits before set and remove",
+			args:     []string{"--edits", editsPath, "--set", `/user/name="Max"`, "--remove", "/user/id", "--json", `{"user":{"name":"John","token":"x","id":7}}`},
+			expected: `{\"user\":{\"name\":\"Max\"}}`,
+		},
+		{
+			name:     "Failing edit",
+			args:     []string{"--edits", editsPath, "--json", `{"user":{"name":"John"}}`},
+			expected: "edit 1 (remove /user/token)",
+			wantErr:  true,
+		},
+		{
+			name:     "Failing edit with ignore missing",
+			args:     []string{"--edits", editsPath, "--ignore-missing", "--json", `{"user":{"name":"John"}}`},
+			expected: `{\"user\":{\"name\":\"Jane\"}}`,
+		},
+		{
+			name:     "Missing file",
+			args:     []string{"--edits", editsPath + ".missing", "--json", `{}`},
+			expected: "reading edits",
+			wantErr:  true,
+		},
+		{
+			name:     "With decode",
+			args:     []string{"--edits", editsPath, "--decode", "--json", `{}`},
+			expected: "--edits",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := exec.Command(binaryPath, tt.args...).CombinedOutput()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(string(output), tt.expected) {
+					t.Fatalf("expected error containing %q, got %v: %s", tt.expected, err, output)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v: %s", err, output)
+			}
+			if strings.TrimSpace(string(output)) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, output)
+			}
+		})
+	}
+}
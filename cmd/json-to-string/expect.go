@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// jsonTypes lists the top-level types accepted by --expect, as named by
+// jsonstr.TopLevelType
+var jsonTypes = []string{"object", "array", "string", "number", "boolean", "null"}
+
+// parseExpect splits a comma-separated --expect list into the allowed types
+func parseExpect(list string) ([]string, error) {
+	var types []string
+	for _, typ := range strings.Split(list, ",") {
+		typ = strings.ToLower(strings.TrimSpace(typ))
+		if !slices.Contains(jsonTypes, typ) {
+			return nil, fmt.Errorf("invalid --expect type %q: must be one of %s", typ, strings.Join(jsonTypes, ", "))
+		}
+		types = append(types, typ)
+	}
+	return types, nil
+}
+
+// checkExpected fails unless the top-level type of a JSON document is one of the
+// types allowed by --expect
+func checkExpected(document []byte, opts *options) error {
+	if opts.expect == "" {
+		return nil
+	}
+	allowed, err := parseExpect(opts.expect)
+	if err != nil {
+		return err
+	}
+	typ, err := jsonstr.TopLevelType(document)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(allowed, typ) {
+		return fmt.Errorf("top-level value is %s, expected %s", article(typ), strings.Join(allowed, " or "))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCheckExpected verifies --expect type lists against documents
+func TestCheckExpected(t *testing.T) {
+	tests := []struct {
+		name          string
+		expect        string
+		document      string
+		errorContains string
+	}{
+		{name: "No list", expect: "", document: `1`},
+		{name: "Allowed object", expect: "object,array", document: `{"a":1}`},
+		{name: "Allowed array with spaces and case", expect: "Object, ARRAY", document: `[1]`},
+		{name: "Allowed null", expect: "null", document: `null`},
+		{
+			name:          "Scalar where an object was expected",
+			expect:        "object",
+			document:      `"text"`,
+			errorContains: "top-level value is a string, expected object",
+		},
+		{
+			name:          "Array where a scalar was expected",
+			expect:        "number,boolean",
+			document:      `[]`,
+			errorContains: "top-level value is an array, expected number or boolean",
+		},
+		{name: "Unknown type", expect: "object,list", document: `{}`, errorContains: `invalid --expect type "list"`},
+		{name: "Empty entry", expect: "object,", document: `{}`, errorContains: `invalid --expect type ""`},
+		{name: "Invalid JSON", expect: "object", document: `}`, errorContains: "invalid JSON"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkExpected([]byte(tc.document), &options{expect: tc.expect})
+			if tc.errorContains == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+				t.Errorf("expected error containing %q but got %v", tc.errorContains, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+// readFD is not available on this platform, where open files are identified by
+// handles rather than numbered descriptors
+func readFD(fd int) ([]byte, error) {
+	return nil, errFDUnsupported
+}
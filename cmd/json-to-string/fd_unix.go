@@ -0,0 +1,37 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// readFD reads all data from the already open file descriptor fd, as passed
+// by process substitution such as 3<(command)
+func readFD(fd int) ([]byte, error) {
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd %d", fd))
+	if f == nil {
+		return nil, fmt.Errorf("invalid file descriptor %d", fd)
+	}
+	if fd > 2 {
+		// The standard streams stay open for the output and error messages
+		defer f.Close()
+	}
+
+	if _, err := f.Stat(); err != nil {
+		return nil, fmt.Errorf("file descriptor %d is not open", fd)
+	}
+
+	data, err := io.ReadAll(f)
+	if errors.Is(err, syscall.EBADF) {
+		return nil, fmt.Errorf("file descriptor %d is not open for reading", fd)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading file descriptor %d: %w", fd, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runFilter pipes input through the --filter command, run by the system shell,
+// and returns what the command writes to stdout. The output must be a single
+// JSON document, which is returned without surrounding whitespace.
+func runFilter(input []byte, command string) ([]byte, error) {
+	cmd := filterCommand(command)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if message := strings.TrimSpace(stderr.String()); errors.As(err, &exitErr) && message != "" {
+			return nil, fmt.Errorf("--filter %q failed with %v: %s", command, err, message)
+		}
+		return nil, fmt.Errorf("--filter %q failed: %w", command, err)
+	}
+
+	output := bytes.TrimSpace(stdout.Bytes())
+	if len(output) == 0 {
+		return nil, fmt.Errorf("--filter %q wrote no output", command)
+	}
+	var value interface{}
+	if err := json.Unmarshal(output, &value); err != nil {
+		return nil, fmt.Errorf("--filter %q did not write a single JSON document: %w", command, err)
+	}
+	return output, nil
+}
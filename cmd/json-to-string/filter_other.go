@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// filterCommand returns the command that runs a --filter command line with the
+// Windows command interpreter
+func filterCommand(command string) *exec.Cmd {
+	return exec.Command("cmd.exe", "/C", command)
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import "os/exec"
+
+// filterCommand returns the command that runs a --filter command line with the
+// POSIX shell, so quoting and pipes work as they do in a terminal
+func filterCommand(command string) *exec.Cmd {
+	return exec.Command("/bin/sh", "-c", command)
+}
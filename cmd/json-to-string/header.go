@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+)
+
+// splitHeader separates the leading comment lines of the input, such as a
+// #! line or a // generated file notice, from the JSON after them for
+// --preserve-header. A JSON document cannot start with # or /, so any line
+// starting with # or // before it is part of the header. The header keeps its
+// line endings.
+func splitHeader(input []byte) (header, body []byte) {
+	body = input
+	for bytes.HasPrefix(body, []byte("#")) || bytes.HasPrefix(body, []byte("//")) {
+		end := bytes.IndexByte(body, '\n')
+		if end < 0 {
+			end = len(body) - 1
+		}
+		body = body[end+1:]
+	}
+	// The header is copied, as the input may be memory-mapped and released first
+	return bytes.Clone(input[:len(input)-len(body)]), body
+}
+
+// attachHeader puts a header set aside by splitHeader back in front of the
+// output, on its own lines
+func attachHeader(header []byte, output string) string {
+	if len(header) == 0 {
+		return output
+	}
+	if !bytes.HasSuffix(header, []byte("\n")) {
+		return string(header) + "\n" + output
+	}
+	return string(header) + output
+}
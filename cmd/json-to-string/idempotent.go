@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// checkIdempotent writes warnings to w about surprising double-escaping for
+// --check-idempotent. Encoding is idempotent when encoding the output again, with
+// the same options, only adds one more layer of escaping: the second encoding must
+// equal the output escaped as raw text. The output is treated as the JSON string
+// it is the body of, or as is with --as-json-string. It also warns when the input
+// is itself an escaped JSON document, which is then escaped a second time.
+func checkIdempotent(w io.Writer, input []byte, output string, opts *options) {
+	// The input was encoded, so it is valid JSON: a JSON string holding a document
+	if jsonstr.IsEscaped(input) {
+		fmt.Fprintf(w, "Warning: the input is a JSON string holding a JSON document, so it is escaped a second time (use --decode to unescape it)\n")
+	}
+
+	document := output
+	if !opts.asJSONStr {
+		document = `"` + output + `"`
+	}
+
+	var second string
+	var err error
+	if opts.asJSONStr {
+		second, err = jsonstr.EncodeJSONString([]byte(document), opts.encodeOptions())
+	} else {
+		second, err = jsonstr.EncodeWithOptions([]byte(document), opts.encodeOptions())
+	}
+	if err != nil {
+		fmt.Fprintf(w, "Warning: the output cannot be encoded again: %v\n", err)
+		return
+	}
+	if opts.readableCtrl {
+		second = jsonstr.ReadableControls(second)
+	}
+
+	raw := rawEscape(document, opts.asJSONStr)
+	if second != raw {
+		fmt.Fprintf(w, "Warning: encoding the output again does more than escape it as raw text\n")
+		fmt.Fprintf(w, "  escaped as raw text: %s\n", raw)
+		fmt.Fprintf(w, "  encoded again:       %s\n", second)
+	}
+}
+
+// rawEscape escapes text as the body of a JSON string, keeping the quotes if quoted is set
+func rawEscape(text string, quoted bool) string {
+	escaped, err := json.Marshal(text)
+	if err != nil {
+		// Marshaling a string cannot fail
+		return text
+	}
+	if quoted {
+		return string(escaped)
+	}
+	return string(escaped[1 : len(escaped)-1])
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestCheckIdempotent verifies the warnings of --check-idempotent
+func TestCheckIdempotent(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		output   string
+		opts     options
+		warnings []string
+	}{
+		{name: "Plain encode", input: `{"a":"b"}`, output: `{\"a\":\"b\"}`},
+		{name: "Control characters", input: `{"a":"x\u0009"}`, output: `{\"a\":\"x\\u0009\"}`},
+		{name: "Readable controls", input: `{"a":"x\u0009"}`, output: `{\"a\":\"x\\t\"}`, opts: options{readableCtrl: true}},
+		{name: "Compact", input: `{ "a" : 1 }`, output: `{\"a\":1}`, opts: options{compact: true}},
+		{name: "JSON string output", input: `{"a":1}`, output: `"{\"a\":1}"`, opts: options{asJSONStr: true}},
+		{
+			name:     "Input is already escaped",
+			input:    `"{\"a\":1}"`,
+			output:   `\"{\\\"a\\\":1}\"`,
+			warnings: []string{"escaped a second time"},
+		},
+		{name: "JSON string holding a plain string", input: `"not json"`, output: `\"not json\"`},
+		{
+			name:     "Output that is not a string body",
+			input:    `{}`,
+			output:   `a"b`,
+			warnings: []string{"cannot be encoded again"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := tc.opts
+			opts.indent = "  "
+			var stderr bytes.Buffer
+			checkIdempotent(&stderr, []byte(tc.input), tc.output, &opts)
+
+			if len(tc.warnings) == 0 && stderr.Len() > 0 {
+				t.Errorf("expected no warnings but got:\n%s", stderr.String())
+			}
+			for _, warning := range tc.warnings {
+				if !strings.Contains(stderr.String(), warning) {
+					t.Errorf("expected a warning containing %q but got:\n%s", warning, stderr.String())
+				}
+			}
+		})
+	}
+}
+
+// TestCheckIdempotentFlag verifies the check leaves the output unchanged
+func TestCheckIdempotentFlag(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	cmd := exec.Command(binaryPath, "--check-idempotent", "--json", `"{\"a\":1}"`)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != `\"{\\\"a\\\":1}\"`+"\n" {
+		t.Errorf("expected the usual output but got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Warning: the input is a JSON string holding a JSON document") {
+		t.Errorf("expected a double-escaping warning but got %q", stderr.String())
+	}
+
+	cmd = exec.Command(binaryPath, "--check-idempotent", "--quiet", "--json", `"{\"a\":1}"`)
+	stderr.Reset()
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("expected --quiet to suppress warnings but got %q", stderr.String())
+	}
+
+	if err := exec.Command(binaryPath, "--check-idempotent", "--decode", "--json", `{}`).Run(); err == nil {
+		t.Errorf("expected --check-idempotent with --decode to fail")
+	}
+}
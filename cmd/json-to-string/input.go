@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// errMmapUnsupported is returned by mmapFile on platforms without mmap support,
+// and for files that cannot be mapped, such as pipes and devices
+var errMmapUnsupported = errors.New("memory mapping is not supported")
+
+// errFDUnsupported is returned by readFD on platforms without file descriptors
+var errFDUnsupported = errors.New("reading from a file descriptor is not supported on this platform")
+
+// readFile reads the contents of path, transparently decompressing gzip files
+// (detected by a .gz extension or the gzip magic bytes). When useMmap is set the
+// file is memory-mapped instead of copied onto the heap, falling back to
+// os.ReadFile if mapping isn't available. The returned release function must be
+// called once the data is no longer needed.
+func readFile(path string, useMmap bool) ([]byte, func(), error) {
+	data, release, err := readRawFile(path, useMmap)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !isGzip(data) && !strings.HasSuffix(path, ".gz") {
+		return data, release, nil
+	}
+
+	// The decompressed copy is independent of the mapped file, which can be released now
+	defer release()
+	decompressed, err := gunzip(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decompressing %s: %w", path, err)
+	}
+	return decompressed, func() {}, nil
+}
+
+// isGzip reports whether data starts with the gzip magic bytes
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// gunzip decompresses gzip data
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// readRawFile reads the contents of path as-is, optionally using mmap
+func readRawFile(path string, useMmap bool) ([]byte, func(), error) {
+	if useMmap {
+		data, unmap, err := mmapFile(path)
+		if err == nil {
+			return data, unmap, nil
+		}
+		if !errors.Is(err, errMmapUnsupported) {
+			return nil, nil, err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() {}, nil
+}
+
+// stdinHasInput reports whether stdin is connected to something that can supply
+// input: a pipe (including named pipes and process substitution), a socket, or a
+// redirected regular file. An interactive terminal or other character device,
+// such as /dev/null, is treated as no input. If stdin cannot be inspected at all
+// (for example because it was closed) there is no input either.
+func stdinHasInput() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+
+	mode := info.Mode()
+	switch {
+	case mode&os.ModeNamedPipe != 0, mode&os.ModeSocket != 0, mode.IsRegular():
+		return true
+	case mode&os.ModeCharDevice != 0:
+		return false
+	default:
+		// Block devices and other special files can still be read
+		return true
+	}
+}
+
+// readStdin reads all of stdin. With a positive timeout, it fails if nothing
+// arrives within the timeout instead of waiting forever on a pipe that is never
+// written to or closed, as some CI runners leave stdin. Once the first bytes
+// arrive, the rest is read without a time limit.
+func readStdin(timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		return io.ReadAll(os.Stdin)
+	}
+
+	type chunk struct {
+		data []byte
+		err  error
+	}
+	first := make(chan chunk, 1)
+	go func() {
+		buf := make([]byte, 32*1024)
+		n, err := os.Stdin.Read(buf)
+		first <- chunk{buf[:n], err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case c := <-first:
+		if c.err == io.EOF {
+			return c.data, nil
+		}
+		if c.err != nil {
+			return nil, c.err
+		}
+		rest, err := io.ReadAll(os.Stdin)
+		return append(c.data, rest...), err
+	case <-timer.C:
+		// The read is left blocked in its goroutine, which ends with the process
+		return nil, fmt.Errorf("no input arrived within %v (--stdin-timeout)", timeout)
+	}
+}
+
+// readJSONArg reads the input given with --json. Like curl, a value starting
+// with @ names a file to read instead, and @- reads stdin, which avoids argument
+// length limits and quoting for large documents. A value starting with @@ is
+// the literal text after the first @. Surrounding whitespace is ignored for
+// literal values, while files are read as they are, as with --file.
+func readJSONArg(value string, opts *options) ([]byte, func(), error) {
+	switch {
+	case strings.HasPrefix(value, "@@"):
+		value = value[1:]
+	case value == "@-":
+		if !stdinHasInput() {
+			return nil, nil, fmt.Errorf("--json @- requires input on stdin")
+		}
+		data, err := readStdin(opts.stdinTimeout)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading from stdin: %w", err)
+		}
+		return data, func() {}, nil
+	case strings.HasPrefix(value, "@"):
+		return readFile(value[1:], opts.useMmap)
+	}
+	// Arguments built with "$(cat file)" often end in a newline. Whitespace around
+	// the top-level value is insignificant, while whitespace inside strings is kept.
+	return []byte(strings.TrimSpace(value)), func() {}, nil
+}
+
+// readEnv reads input from the named environment variable. Surrounding
+// whitespace is ignored, as it is for --json.
+func readEnv(name string) ([]byte, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %s is empty", name)
+	}
+	return []byte(value), nil
+}
+
+// decodeHex decodes hex-encoded input for --hex, ignoring surrounding whitespace
+func decodeHex(input []byte) ([]byte, error) {
+	input = bytes.TrimSpace(input)
+	decoded := make([]byte, hex.DecodedLen(len(input)))
+	if _, err := hex.Decode(decoded, input); err != nil {
+		var invalid hex.InvalidByteError
+		switch {
+		case errors.As(err, &invalid):
+			return nil, fmt.Errorf("invalid hex input: unexpected character %q", rune(invalid))
+		case errors.Is(err, hex.ErrLength):
+			return nil, fmt.Errorf("invalid hex input: odd number of digits (%d)", len(input))
+		default:
+			return nil, fmt.Errorf("invalid hex input: %w", err)
+		}
+	}
+	return decoded, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// readInputFormat converts input in another format to JSON, as selected with
+// --from-querystring or --from-csv, or removes the comments of JSONC input
+// with --strip-comments. Other input is returned unchanged. header is the text
+// set aside before input by --preserve-header. The comments removed for
+// --comments-to are returned for writeComments.
+func readInputFormat(input, header []byte, opts *options) ([]byte, []jsonstr.Comment, error) {
+	switch {
+	case opts.fromQuery:
+		output, err := fromQueryString(input)
+		return output, nil, err
+	case opts.fromCSV:
+		output, err := fromCSV(input, opts)
+		return output, nil, err
+	case opts.stripComments:
+		return stripComments(input, header, opts)
+	}
+	return input, nil, nil
+}
+
+// stripComments removes the comments of JSONC input for --strip-comments. With
+// --comments-to they are also returned, located by the offset, line and column
+// at which they start in the whole input, header included.
+func stripComments(input, header []byte, opts *options) ([]byte, []jsonstr.Comment, error) {
+	if opts.commentsTo == "" {
+		stripped, err := jsonstr.StripComments(input)
+		return stripped, nil, err
+	}
+
+	stripped, comments, err := jsonstr.ExtractComments(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The header ends with a line break, so it does not change the columns
+	headerLines := bytes.Count(header, []byte("\n"))
+	for i := range comments {
+		comments[i].Offset += len(header)
+		comments[i].Line += headerLines
+	}
+	if comments == nil {
+		comments = []jsonstr.Comment{}
+	}
+	return stripped, comments, nil
+}
+
+// writeComments writes the comments removed by stripComments to the
+// --comments-to file as a JSON array. It is called only once the conversion
+// has succeeded, so a failed run leaves no sidecar behind.
+func writeComments(comments []jsonstr.Comment, opts *options) error {
+	if opts.commentsTo == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(comments, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling comments: %w", err)
+	}
+	if err := os.WriteFile(opts.commentsTo, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing comments: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultListenLimit is the default largest payload accepted by --listen
+const defaultListenLimit = 1 << 20
+
+// maxListenConns is the largest number of connections --listen handles at once.
+// Further connections wait in the listen backlog until one has finished.
+const maxListenConns = 64
+
+// parseListenAddr splits a --listen address of the form unix:/path or
+// tcp:host:port into the network and address passed to net.Listen
+func parseListenAddr(addr string) (network, address string, err error) {
+	network, address, ok := strings.Cut(addr, ":")
+	if !ok || address == "" || (network != "unix" && network != "tcp") {
+		return "", "", fmt.Errorf("invalid --listen address %q: must be unix:/path or tcp:host:port", addr)
+	}
+	return network, address, nil
+}
+
+// serveListen accepts connections on the --listen address until interrupted.
+// Each connection sends one payload, ended as described by readPayload, and
+// receives the converted result, or an error line, before it is closed.
+func serveListen(opts *options) error {
+	network, address, err := parseListenAddr(opts.listen)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("listening: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// Closing a Unix listener also removes its socket file
+		listener.Close()
+	}()
+
+	if !opts.quiet {
+		fmt.Fprintf(os.Stderr, "Listening on %s:%s\n", network, listener.Addr())
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	slots := make(chan struct{}, maxListenConns)
+	for {
+		slots <- struct{}{}
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("accepting a connection: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots }()
+			defer wg.Done()
+			handleConn(conn, opts)
+		}()
+	}
+}
+
+// handleConn converts the payload of a single connection and writes back the
+// result. Failures are reported to the client and, unless --quiet, on stderr.
+func handleConn(conn net.Conn, opts *options) {
+	defer closeConn(conn)
+	if err := conn.SetDeadline(time.Now().Add(opts.listenTimeout)); err != nil {
+		return
+	}
+
+	var output string
+	// Escaped strings never span lines, so only JSON to encode can continue past a newline
+	payload, err := readPayload(conn, opts.listenLimit, !opts.decode)
+	if err == nil {
+		output, err = convertInput(payload, opts)
+	}
+	if err != nil {
+		if !opts.quiet {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		}
+		fmt.Fprintf(conn, "Error %v\n", err)
+		return
+	}
+	writeResult(conn, output, opts)
+}
+
+// closeConn closes a connection after the response has been written. Closing a
+// TCP connection with unread data resets it, which can discard the response, so
+// the rest of the client's data is read first, bounded by the deadline.
+func closeConn(conn net.Conn) {
+	if c, ok := conn.(interface{ CloseWrite() error }); ok && c.CloseWrite() == nil {
+		_, _ = io.Copy(io.Discard, conn)
+	}
+	conn.Close()
+}
+
+// readPayload reads a payload ended by a newline or the end of the stream,
+// failing if it is longer than limit bytes. With multiline, a newline inside a
+// JSON object or array that has not been closed yet does not end the payload,
+// so pretty-printed JSON can be sent with or without closing the write side.
+func readPayload(r io.Reader, limit int, multiline bool) ([]byte, error) {
+	reader := bufio.NewReader(io.LimitReader(r, int64(limit)+1))
+	var payload []byte
+	var nesting jsonNesting
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("reading payload: %w", err)
+		}
+		payload = append(payload, line...)
+		nesting.scan(line)
+		if err != nil || !multiline || nesting.depth <= 0 {
+			break
+		}
+	}
+	payload = bytes.TrimRight(payload, "\r\n")
+	if len(payload) > limit {
+		return nil, fmt.Errorf("payload exceeds --listen-limit of %d bytes", limit)
+	}
+	return payload, nil
+}
+
+// jsonNesting tracks how deeply nested the JSON read so far is, to find where a
+// document that spans several lines ends
+type jsonNesting struct {
+	depth    int
+	inString bool
+	escaped  bool
+}
+
+// scan updates the nesting with the next part of the JSON text. Brackets inside
+// strings are ignored.
+func (n *jsonNesting) scan(data []byte) {
+	for _, c := range data {
+		switch {
+		case n.escaped:
+			n.escaped = false
+		case n.inString && c == '\\':
+			n.escaped = true
+		case c == '"':
+			n.inString = !n.inString
+		case n.inString:
+		case c == '{' || c == '[':
+			n.depth++
+		case c == '}' || c == ']':
+			n.depth--
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestParseListenAddr verifies the address forms accepted by --listen
+func TestParseListenAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		network string
+		address string
+		isError bool
+	}{
+		{addr: "unix:/tmp/json-to-string.sock", network: "unix", address: "/tmp/json-to-string.sock"},
+		{addr: "tcp:127.0.0.1:9000", network: "tcp", address: "127.0.0.1:9000"},
+		{addr: "tcp::9000", network: "tcp", address: ":9000"},
+		{addr: "udp:127.0.0.1:9000", isError: true},
+		{addr: "127.0.0.1:9000", isError: true},
+		{addr: "unix:", isError: true},
+		{addr: "/tmp/json-to-string.sock", isError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.addr, func(t *testing.T) {
+			network, address, err := parseListenAddr(tc.addr)
+			if tc.isError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if network != tc.network || address != tc.address {
+				t.Errorf("expected %s %s but got %s %s", tc.network, tc.address, network, address)
+			}
+		})
+	}
+}
+
+// TestReadPayload verifies how a payload is delimited and bounded
+func TestReadPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		limit     int
+		multiline bool
+		expected  string
+		isError   bool
+	}{
+		{name: "Ended by a newline", input: "{\"a\":1}\nignored", limit: 100, expected: `{"a":1}`},
+		{name: "Ended by a CRLF", input: "{\"a\":1}\r\n", limit: 100, expected: `{"a":1}`},
+		{name: "Ended by the end of the stream", input: `{"a":1}`, limit: 100, expected: `{"a":1}`},
+		{name: "Exactly the limit", input: "{\"a\":1}\n", limit: 7, expected: `{"a":1}`},
+		{name: "Over the limit", input: `{"a":12}`, limit: 7, isError: true},
+		{name: "Pretty-printed", input: "{\n  \"a\": [\n    1\n  ]\n}\nignored", limit: 100, multiline: true, expected: "{\n  \"a\": [\n    1\n  ]\n}"},
+		{name: "Brackets in strings", input: "{\"a\": \"}\\\"]\",\n\"b\": 1}\nignored", limit: 100, multiline: true, expected: "{\"a\": \"}\\\"]\",\n\"b\": 1}"},
+		{name: "Single line with multiline", input: "[1]\n[2]", limit: 100, multiline: true, expected: "[1]"},
+		{name: "Unfinished at the end of the stream", input: "{\n\"a\":", limit: 100, multiline: true, expected: "{\n\"a\":"},
+		{name: "Pretty-printed without multiline", input: "{\n\"a\": 1\n}", limit: 100, expected: "{"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := readPayload(strings.NewReader(tc.input), tc.limit, tc.multiline)
+			if tc.isError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(payload) != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, payload)
+			}
+		})
+	}
+}
+
+// TestListen verifies conversions served on a TCP socket
+func TestListen(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	cmd := exec.Command(binaryPath, "--listen", "tcp:127.0.0.1:0", "--listen-limit", "64")
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		t.Fatalf("failed to get stderr: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer func() {
+		_ = cmd.Process.Signal(os.Interrupt)
+		_ = cmd.Wait()
+	}()
+
+	// The first line on stderr announces the address, including the chosen port
+	reader := bufio.NewReader(stderr)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read the listening address: %v", err)
+	}
+	address := strings.TrimPrefix(strings.TrimSpace(line), "Listening on tcp:")
+	go func() { _, _ = io.Copy(io.Discard, reader) }()
+
+	tests := []struct {
+		name     string
+		payload  string
+		closeW   bool
+		expected string
+	}{
+		{name: "Payload ended by a newline", payload: "{\"a\":1}\n", expected: "{\\\"a\\\":1}\n"},
+		{name: "Payload ended by closing the write side", payload: `{"b":[1,2]}`, closeW: true, expected: "{\\\"b\\\":[1,2]}\n"},
+		{name: "Pretty-printed payload", payload: "{\n  \"a\": [\n    1\n  ]\n}\n", expected: "{\\n  \\\"a\\\": [\\n    1\\n  ]\\n}\n"},
+		{name: "Pretty-printed payload ended by closing the write side", payload: "{\n  \"a\": 1\n}", closeW: true, expected: "{\\n  \\\"a\\\": 1\\n}\n"},
+		{name: "Invalid JSON", payload: "{\"a\":\n", closeW: true, expected: "Error encoding JSON: invalid JSON: unexpected end of JSON input\n"},
+		{name: "Payload over the limit", payload: `{"a":"` + strings.Repeat("x", 64) + "\"}\n", expected: "Error payload exceeds --listen-limit of 64 bytes\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", address)
+			if err != nil {
+				t.Fatalf("failed to connect: %v", err)
+			}
+			defer conn.Close()
+			if _, err := conn.Write([]byte(tc.payload)); err != nil {
+				t.Fatalf("failed to send payload: %v", err)
+			}
+			if tc.closeW {
+				if err := conn.(*net.TCPConn).CloseWrite(); err != nil {
+					t.Fatalf("failed to close the write side: %v", err)
+				}
+			}
+			response, err := io.ReadAll(conn)
+			if err != nil {
+				t.Fatalf("failed to read response: %v", err)
+			}
+			if string(response) != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, response)
+			}
+		})
+	}
+}
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"html"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/eiladin/json-to-string/pkg/jsonstr"
 )
@@ -12,128 +19,1256 @@ import (
 // version is set during build
 var version = "dev"
 
-// printUsage prints a custom usage message with examples
-func printUsage() {
+// example is a usage example shown in the help output and the manual page
+type example struct {
+	description string
+	command     string
+}
+
+// encodeExamples lists the encoding examples in the order they are shown
+var encodeExamples = []example{
+	{description: "Encode JSON from a file", command: "json-to-string --file input.json"},
+	{description: "Encode JSON from a string argument", command: "json-to-string --json '{\"key\": \"value\"}'"},
+	{description: "Pass a large payload the way curl does, without quoting it", command: "json-to-string --json @payload.json"},
+	{description: "Encode JSON from an environment variable", command: "json-to-string --env PAYLOAD"},
+	{description: "Encode JSON from file descriptor 3, fed by process substitution", command: "json-to-string --fd 3 3< <(curl -s https://example.com/data.json)"},
+	{description: "Encode JSON from stdin (piping)", command: "echo '{\"key\": \"value\"}' | json-to-string"},
+	{description: "Start writing the output of a large piped input before it ends", command: "curl -s https://example.com/large.json | json-to-string --stream"},
+	{description: "Experiment interactively, converting each line as it is entered", command: "json-to-string --repl"},
+	{description: "Serve conversions to other services on a Unix socket", command: "json-to-string --listen unix:/run/json-to-string.sock"},
+	{description: "Fail instead of waiting forever when stdin stays silent", command: "json-to-string --stdin-timeout 5s"},
+	{description: "Encode JSON from file and remove whitespace from pretty-printed JSON", command: "json-to-string --compact --file input.json"},
+	{description: "Remove whitespace but keep key order and number formatting exactly", command: "json-to-string --strip-ws --file input.json"},
+	{description: "Pretty-print JSON with four-space indentation before encoding it", command: "json-to-string --pretty --indent '    ' --file input.json"},
+	{description: "Set a house indentation once instead of passing --indent every time", command: "JSON_TO_STRING_INDENT=4 json-to-string --pretty --file input.json"},
+	{description: "Encode without trailing newline (useful for piping)", command: "json-to-string --file input.json --raw"},
+	{description: "Encode each line of a newline-delimited JSON file separately", command: "json-to-string --ndjson --file events.ndjson"},
+	{description: "Encode each element of a JSON array on its own line", command: "json-to-string --explode --file items.json"},
+	{description: "Encode each document of an array, or of nested arrays, into an array of escaped strings", command: "json-to-string --each --as-array --file batches.json"},
+	{description: "Combine NDJSON log lines into a single array and encode it", command: "json-to-string --implode --file events.ndjson"},
+	{description: "Keep encoding lines as they are appended to a log (Ctrl-C to stop)", command: "json-to-string --ndjson --follow --file events.log"},
+	{description: "Encode only part of a document, selected with a JSON Pointer", command: "json-to-string --pointer /user/addresses/0 --file input.json"},
+	{description: "Override a value in a fixture before encoding it", command: "json-to-string --set '/user/name=\"Jane\"' --file fixture.json"},
+	{description: "Strip secrets from a fixture, ignoring ones that are not present", command: "json-to-string --remove /password --remove /token --ignore-missing --file fixture.json"},
+	{description: "Apply a JSON array of set and remove edits from a file", command: "json-to-string --edits fixture-edits.json --file fixture.json"},
+	{description: "Sort arrays that hold sets so the output does not depend on their order", command: "json-to-string --sort-arrays --file permissions.json"},
+	{description: "Write \\u00e9 style escapes in the input as UTF-8 characters", command: "json-to-string --expand-unicode --file input.json"},
+	{description: "Round long floating-point numbers to three significant digits", command: "json-to-string --float-precision 3 --file metrics.json"},
+	{description: "Drop repeated entries from lists, keeping the first of each", command: "json-to-string --dedupe-arrays --file tags.json"},
+	{description: "Preview a large list by keeping its first 3 entries", command: "json-to-string --max-array-length 3 --file users.json"},
+	{description: "Encode a commented configuration file, keeping its header line", command: "json-to-string --preserve-header --strip-comments --file settings.jsonc"},
+	{description: "Strip the comments of a configuration file, saving them to a sidecar file", command: "json-to-string --strip-comments --comments-to comments.json --file settings.jsonc"},
+	{description: "Reshape the JSON with jq before encoding it", command: "json-to-string --filter 'jq -c .data' --file response.json"},
+	{description: "Preview a verbose payload with string values cut to 40 characters", command: "json-to-string --max-string-length 40 --file payload.json"},
+	{description: "Trim stray spaces around string values before encoding", command: "json-to-string --trim-strings --file scraped.json"},
+	{description: "Sanitize a fixture by replacing text inside string values only", command: "json-to-string --replace prod.example.com=test.example.com --replace-regex '[0-9]{4}-[0-9]{4}=XXXX-XXXX' --file fixture.json"},
+	{description: "Lowercase string values and keys for case-insensitive comparisons", command: "json-to-string --case lower --case-keys --file users.json"},
+	{description: "Show control characters in string values as \\t, \\n and \\r instead of \\u00XX", command: "json-to-string --readable-controls --file log-line.json"},
+	{description: "Write non-ASCII bytes as \\xNN for a consumer that is not a JSON parser (not valid JSON escaping)", command: "json-to-string --byte-escape --file input.json"},
+	{description: "Warn if encoding the output again would do more than add one layer of escaping", command: "json-to-string --check-idempotent --file input.json"},
+	{description: "Redact values that look like API keys, tokens or private keys before encoding", command: "json-to-string --redact-secrets --file input.json"},
+	{description: "Compare the output size of the encode layouts", command: "json-to-string --compare-options --file input.json"},
+	{description: "Encode as a quoted JSON string value that can be embedded directly", command: "json-to-string --as-json-string --file input.json"},
+	{description: "Encode as a C string literal split into 80-character lines", command: "json-to-string --lang c --literal-width 80 --file input.json"},
+	{description: "Encode as a PostgreSQL string literal", command: "json-to-string --lang sql --sql-dialect postgres --compact --file input.json"},
+	{description: "Encode as a Java .properties value for a Spring config entry", command: "json-to-string --lang properties --compact --file config.json"},
+	{description: "Encode as a YAML string scalar, such as a ConfigMap value", command: "json-to-string --lang yaml --pretty --file config.json"},
+	{description: "Encode as a TOML string for a config value", command: "json-to-string --lang toml --compact --file config.json"},
+	{description: "Write the request parameters of a captured call as a URL query string", command: "json-to-string --querystring --pointer /params --file request.json"},
+	{description: "Encode the parameters of a URL query string as a JSON object", command: "json-to-string --from-querystring --json 'q=shoes&size=42&tag=new&tag=sale'"},
+	{description: "Encode a CSV table as an array of objects, with numbers and booleans as JSON values", command: "json-to-string --from-csv --csv-infer-types --file table.csv"},
+	{description: "Write an array of objects as a CSV table", command: "json-to-string --to-csv --file records.json > records.csv"},
+	{description: "Escape JSON for a data attribute in an HTML template", command: "json-to-string --html-attr --compact --file widget.json"},
+	{description: "Escape non-ASCII characters in keys for a consumer that only accepts ASCII keys", command: "json-to-string --ensure-ascii-keys --escape-nonascii-keys --file menu.json"},
+	{description: "Show a document and its escaped form together for documentation", command: "json-to-string --both --file example.json"},
+	{description: "List the JSON Pointer and value of every leaf for an audit", command: "json-to-string --pointers --file config.json"},
+	{description: "Turn strict JSON into friendlier JSON5 configuration", command: "json-to-string --to-json5 --file config.json > config.json5"},
+	{description: "Embed a JSON document as a string field of another document", command: "json-to-string --file payload.json --embed-into request.json --at /body"},
+	{description: "Check whether two JSON documents are semantically equal", command: "json-to-string --file a.json --equal b.json"},
+	{description: "Print the top-level type of the input (object, array, string, ...)", command: "json-to-string --type --file input.json"},
+	{description: "Check that a document survives encoding and decoding unchanged", command: "json-to-string --roundtrip --pretty --file input.json"},
+	{description: "Check that a fixture decodes back to exactly the same bytes", command: "json-to-string --strict-roundtrip --file fixture.json"},
+	{description: "Encode a Latin-1 file, writing the output as Latin-1 too", command: "json-to-string --input-charset latin1 --output-charset latin1 --file legacy.json"},
+	{description: "Fail unless the input is an object or an array, so a stray scalar cannot slip through", command: "json-to-string --expect object,array --file input.json"},
+	{description: "Fail in CI instead of escaping input that was already escaped", command: "json-to-string --reject-escaped --file payload.json"},
+	{description: "Fail on invalid UTF-8 instead of replacing it with U+FFFD", command: "json-to-string --strict-utf8 --file dump.json"},
+	{description: "Encode a gzip-compressed file (decompressed automatically)", command: "json-to-string --file fixture.json.gz"},
+	{description: "Stream a large document to a gzip-compressed file", command: "json-to-string --stream --gzip --file large.json --output large.escaped.gz"},
+	{description: "Escape only bytes 1024 to 2048 of a large file, to inspect a problem region", command: "json-to-string --range 1024:2048 --file large.json"},
+	{description: "Encode a large file by memory-mapping it instead of copying it", command: "json-to-string --mmap --file large.json"},
+	{description: "Encode a batch of files, four at a time (output keeps argument order)", command: "json-to-string --jobs 4 a.json b.json c.json"},
+	{description: "Build a regex that matches the escaped JSON literally, for test assertions", command: "json-to-string --regex-escape --json '{\"ids\":[1,2]}'"},
+	{description: "Append the output to a .env file as a shell-quoted assignment", command: "json-to-string --env-name CONFIG --quote single --file config.json >> .env"},
+	{description: "Export the output as a variable of the current shell", command: "eval \"$(json-to-string --export --env-name CONFIG --file config.json)\""},
+	{description: "Paste the pretty-printed form of an escaped payload into an issue", command: "json-to-string --decode --pretty --markdown --file payload.txt"},
+	{description: "Warn when the output is larger than 4KB and fail above 32KB", command: "json-to-string --warn-size 4096 --fail-size 32768 --file input.json"},
+	{description: "Right-align the output in an 80-character fixed-width field", command: "json-to-string --pad 80 --pad-left --file input.json"},
+	{description: "Show escaped string values while keeping the structure readable", command: "json-to-string --escape-values --file input.json"},
+	{description: "Print structural statistics about the input as JSON", command: "json-to-string --count-json --file input.json"},
+	{description: "Log a one-line digest of the conversion to stderr", command: "json-to-string --summary --file input.json > output.txt"},
+	{description: "Enable tab completion in the current bash session", command: "source <(json-to-string --completion bash)"},
+}
+
+// decodeExamples lists the decoding examples in the order they are shown
+var decodeExamples = []example{
+	{description: "Decode an escaped JSON string back to JSON", command: "json-to-string --decode --json '{\\\"key\\\":\\\"value\\\"}'"},
+	{description: "Decode and format the JSON output", command: "json-to-string --decode --pretty --file escaped.txt"},
+	{description: "Decode and fail if the escaped payload has duplicate keys", command: "json-to-string --decode --strict-keys --file escaped.txt"},
+	{description: "Decode and wrap long string values to fit an 80-column terminal", command: "json-to-string --decode --pretty --wrap 80 --file escaped.txt"},
+	{description: "Decode and line up the colons of each object", command: "json-to-string --decode --pretty --align --file escaped.txt"},
+	{description: "Decode, keeping objects and arrays shorter than 60 characters on one line", command: "json-to-string --decode --pretty --compact-threshold 60 --file escaped.txt"},
+	{description: "Decode, indenting only the first two levels of a deep document", command: "json-to-string --decode --pretty --pretty-depth 2 --file escaped.txt"},
+	{description: "Decode, pretty-printing string values that hold JSON", command: "json-to-string --decode --pretty --indent-json-strings --file escaped.txt"},
+	{description: "Decode a log of escaped strings, one per line, writing each as it arrives", command: "tail -f app.log | json-to-string decode --stream"},
+	{description: "Decode every escaped string of an array into an array of JSON values", command: "json-to-string --decode-elements --file escaped-array.json"},
+	{description: "Decode an escaped string that was stored hex-encoded", command: "json-to-string --decode --hex --file escaped.hex"},
+	{description: "Format JSON as a data: URI for embedding in a web page", command: "json-to-string format --compact --data-uri --file input.json"},
+	{description: "Decode to pure ASCII, escaping other characters as \\uXXXX", command: "json-to-string --decode --unicode escaped --file escaped.txt"},
+	{description: "Number the lines of decoded JSON to reference them in a review", command: "json-to-string --decode --pretty --line-numbers --file escaped.txt"},
+	{description: "Decode with Windows (CRLF) line endings", command: "json-to-string --decode --pretty --eol crlf --file escaped.txt"},
+	{description: "Chain encode and decode operations (pipe)", command: "echo '{\"key\":\"value\"}' | json-to-string --raw | json-to-string --decode --pretty"},
+}
+
+// printUsage prints a custom usage message with examples, or the usage of the
+// subcommand being run
+func printUsage(opts *options) {
+	if opts.command != "" {
+		printCommandUsage(opts)
+		return
+	}
+
 	fmt.Fprintf(os.Stderr, "json-to-string - Convert JSON to escaped string format and vice versa\n\n")
 	fmt.Fprintf(os.Stderr, "Version: %s\n\n", version)
 	fmt.Fprintf(os.Stderr, "Usage:\n")
-	fmt.Fprintf(os.Stderr, "  json-to-string [options]\n\n")
+	fmt.Fprintf(os.Stderr, "  json-to-string <command> [options] [file ...]\n")
+	fmt.Fprintf(os.Stderr, "  json-to-string [options] [file ...]   (deprecated, same as the encode command or decode with --decode)\n\n")
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'json-to-string <command> --help' for the options of a command.\n\n")
 	fmt.Fprintf(os.Stderr, "Options:\n")
-	flag.PrintDefaults()
+	printDefaults(opts.flags)
 
 	fmt.Fprintf(os.Stderr, "\nExamples:\n")
-
-	// Encoding examples
 	fmt.Fprintf(os.Stderr, "  Encoding (JSON to String):\n")
 	fmt.Fprintf(os.Stderr, "  -----------------------\n")
-	fmt.Fprintf(os.Stderr, "  # Encode JSON from a file:\n")
-	fmt.Fprintf(os.Stderr, "  json-to-string --file input.json\n\n")
+	for _, ex := range encodeExamples {
+		fmt.Fprintf(os.Stderr, "  # %s:\n  %s\n\n", ex.description, ex.command)
+	}
+	fmt.Fprintf(os.Stderr, "  Decoding (String to JSON):\n")
+	fmt.Fprintf(os.Stderr, "  -----------------------\n")
+	for i, ex := range decodeExamples {
+		if i > 0 {
+			fmt.Fprintf(os.Stderr, "\n")
+		}
+		fmt.Fprintf(os.Stderr, "  # %s:\n  %s\n", ex.description, ex.command)
+	}
+}
+
+// options holds the parsed command-line flags
+type options struct {
+	command            string
+	flags              *flag.FlagSet
+	files              []string
+	format             bool
+	validate           bool
+	inputFile          string
+	inputString        string
+	envVar             string
+	fd                 int
+	compact            bool
+	stripWS            bool
+	strictKeys         bool
+	rejectEscaped      bool
+	expect             string
+	decode             bool
+	pretty             bool
+	indent             string
+	wrap               int
+	align              bool
+	compactThreshold   int
+	prettyDepth        int
+	indentJSONStrings  bool
+	indentWidth        int
+	eol                string
+	unicode            string
+	hex                bool
+	explode            bool
+	each               bool
+	asArray            bool
+	explodePass        bool
+	implode            bool
+	decodeElements     bool
+	rawOutput          bool
+	stripFinalNL       bool
+	jobs               int
+	useMmap            bool
+	stdinTimeout       time.Duration
+	listen             string
+	listenLimit        int
+	listenTimeout      time.Duration
+	repl               bool
+	both               bool
+	htmlAttr           bool
+	asciiKeys          bool
+	escapeASCIIKeys    bool
+	bothJSON           bool
+	equalFile          string
+	roundtrip          bool
+	strictRoundtrip    bool
+	showType           bool
+	lang               string
+	queryString        bool
+	toCSV              bool
+	toJSON5            bool
+	bashArray          bool
+	stripComments      bool
+	commentsTo         string
+	preserveHeader     bool
+	fromQuery          bool
+	fromCSV            bool
+	csvInferTypes      bool
+	csvLenient         bool
+	litWidth           int
+	sqlDialect         string
+	propsASCII         bool
+	embedInto          string
+	embedAt            string
+	pointer            string
+	documentPointer    string
+	sets               stringList
+	removes            stringList
+	editsFile          string
+	ignoreMissing      bool
+	sortArrays         bool
+	dedupeArrays       bool
+	floatPrecision     int
+	expandUnicode      bool
+	detectSecrets      bool
+	redactSecrets      bool
+	lineNumbers        bool
+	pointers           bool
+	pointersJSON       bool
+	stream             bool
+	trimStrings        bool
+	trimKeys           bool
+	caseName           string
+	maxStringLen       int
+	maxArrayLen        int
+	noTruncationMarker bool
+	filter             string
+	caseKeys           bool
+	replaces           stringList
+	replaceRegexes     stringList
+	ndjson             bool
+	follow             bool
+	warnSize           int
+	failSize           int
+	quiet              bool
+	quote              string
+	regexEscape        bool
+	envName            string
+	export             bool
+	markdown           bool
+	count              bool
+	countJSON          bool
+	summary            bool
+	escapeVals         bool
+	inputCharset       string
+	strictUTF8         bool
+	onUnmappable       string
+	outputCharset      string
+	outputFile         string
+	dataURI            bool
+	dataURIPlain       bool
+	pad                int
+	byteRange          string
+	padLeft            bool
+	gzip               bool
+	checkIdem          bool
+	compareOpts        bool
+	repeat             int
+	readableCtrl       bool
+	byteEscape         bool
+	asJSONStr          bool
+	showVersion        bool
+	completion         string
+	man                bool
+	showHelp           bool
+}
+
+// encodeOptions returns the library options matching the command-line flags
+func (o *options) encodeOptions() jsonstr.EncodeOptions {
+	return jsonstr.EncodeOptions{
+		Compact:            o.compact,
+		StripWhitespace:    o.stripWS,
+		StrictKeys:         o.strictKeys,
+		ASCIIKeys:          o.asciiKeys,
+		EscapeNonASCIIKeys: o.escapeASCIIKeys,
+		Pretty:             o.pretty,
+		Indent:             o.indent,
+		Wrap:               o.wrap,
+		Align:              o.align,
+		CompactThreshold:   o.compactThreshold,
+		PrettyDepth:        o.prettyDepth,
+		IndentJSONStrings:  o.indentJSONStrings,
+	}
+}
 
-	fmt.Fprintf(os.Stderr, "  # Encode JSON from a string argument:\n")
-	fmt.Fprintf(os.Stderr, "  json-to-string --json '{\"key\": \"value\"}'\n\n")
+// convert runs the configured encode or decode operation on an input, treating
+// each line as a separate document in NDJSON mode and each array element as a
+// separate document with --explode, --each or --decode-elements. With --implode,
+// NDJSON lines are combined into a single array first.
+func convert(input []byte, opts *options) (string, error) {
+	if opts.validate {
+		return validateInput(input, opts)
+	}
+	if opts.ndjson {
+		return convertLines(input, opts)
+	}
+	if opts.explode {
+		return convertElements(input, opts)
+	}
+	if opts.implode {
+		return convertImploded(input, opts)
+	}
+	if opts.each {
+		return convertEach(input, opts)
+	}
+	if opts.decodeElements {
+		return decodeElements(input, opts)
+	}
+	return convertDocument(input, opts)
+}
 
-	fmt.Fprintf(os.Stderr, "  # Encode JSON from stdin (piping):\n")
-	fmt.Fprintf(os.Stderr, "  echo '{\"key\": \"value\"}' | json-to-string\n\n")
+// convertDocument runs the configured encode or decode operation on a single document
+func convertDocument(input []byte, opts *options) (string, error) {
+	if opts.format {
+		if err := checkExpected(input, opts); err != nil {
+			return "", fmt.Errorf("formatting JSON: %w", err)
+		}
+		return formatDocument(input, opts)
+	}
+	if opts.decode {
+		result, err := jsonstr.DecodeWithOptions(input, opts.encodeOptions())
+		if err != nil {
+			return "", fmt.Errorf("decoding JSON string: %w", err)
+		}
+		if err := checkExpected([]byte(result), opts); err != nil {
+			return "", fmt.Errorf("decoding JSON string: %w", err)
+		}
+		return finishDecoded(result, opts), nil
+	}
 
-	fmt.Fprintf(os.Stderr, "  # Encode JSON from file and remove whitespace from pretty-printed JSON:\n")
-	fmt.Fprintf(os.Stderr, "  json-to-string --compact --file input.json\n\n")
+	if opts.rejectEscaped && jsonstr.IsEscaped(input) {
+		return "", fmt.Errorf("encoding JSON: the input looks already escaped, so it would be escaped twice (use --decode to unescape it)")
+	}
+	if err := checkExpected(input, opts); err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
 
-	fmt.Fprintf(os.Stderr, "  # Encode without trailing newline (useful for piping):\n")
-	fmt.Fprintf(os.Stderr, "  json-to-string --file input.json --raw\n\n")
+	if opts.strictKeys {
+		// Check before transforms, as parsing collapses duplicate keys
+		if err := jsonstr.CheckDuplicateKeys(input); err != nil {
+			return "", fmt.Errorf("encoding JSON: %w", err)
+		}
+	}
 
-	// Decoding examples
-	fmt.Fprintf(os.Stderr, "  Decoding (String to JSON):\n")
-	fmt.Fprintf(os.Stderr, "  -----------------------\n")
-	fmt.Fprintf(os.Stderr, "  # Decode an escaped JSON string back to JSON:\n")
-	fmt.Fprintf(os.Stderr, "  json-to-string --decode --json '{\\\"key\\\":\\\"value\\\"}'\n\n")
+	input, err := transformInput(input, opts)
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+	if opts.detectSecrets {
+		if input, err = checkSecrets(os.Stderr, input, opts); err != nil {
+			return "", fmt.Errorf("encoding JSON: %w", err)
+		}
+	}
+
+	if opts.queryString {
+		result, err := toQueryString(input)
+		if err != nil {
+			return "", fmt.Errorf("converting to a query string: %w", err)
+		}
+		return result, nil
+	}
+
+	if opts.toCSV {
+		result, err := toCSV(input, opts)
+		if err != nil {
+			return "", fmt.Errorf("converting to CSV: %w", err)
+		}
+		return result, nil
+	}
+
+	if opts.pointers {
+		result, err := listPointers(input, opts)
+		if err != nil {
+			return "", fmt.Errorf("listing pointers: %w", err)
+		}
+		return result, nil
+	}
+
+	if opts.bashArray {
+		result, err := toBashArray(input, opts)
+		if err != nil {
+			return "", fmt.Errorf("converting to a bash array: %w", err)
+		}
+		return result, nil
+	}
+
+	if opts.toJSON5 {
+		result, err := jsonstr.EncodeJSON5(input, opts.indent)
+		if err != nil {
+			return "", fmt.Errorf("converting to JSON5: %w", err)
+		}
+		return convertNewlines(result, opts), nil
+	}
 
-	fmt.Fprintf(os.Stderr, "  # Decode and format the JSON output:\n")
-	fmt.Fprintf(os.Stderr, "  json-to-string --decode --pretty --file escaped.txt\n\n")
+	if opts.lang != "" {
+		return encodeLiteral(input, opts)
+	}
+
+	if opts.embedInto != "" {
+		return embedInput(input, opts)
+	}
 
-	fmt.Fprintf(os.Stderr, "  # Chain encode and decode operations (pipe):\n")
-	fmt.Fprintf(os.Stderr, "  echo '{\"key\":\"value\"}' | json-to-string --raw | json-to-string --decode --pretty\n")
+	if opts.escapeVals {
+		return escapeValues(input, opts)
+	}
+
+	encode := jsonstr.EncodeWithOptions
+	if opts.asJSONStr {
+		encode = jsonstr.EncodeJSONString
+	}
+
+	result, err := encode(input, opts.encodeOptions())
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+	if opts.readableCtrl {
+		result = jsonstr.ReadableControls(result)
+	}
+	if opts.byteEscape {
+		result = jsonstr.ByteEscape(result)
+	}
+	if opts.htmlAttr {
+		result = html.EscapeString(result)
+	}
+	if opts.both {
+		return bothOutput(input, result, opts)
+	}
+	return result, nil
+}
+
+// encodeLiteral encodes the input as a string literal for the language selected with --lang
+func encodeLiteral(input []byte, opts *options) (string, error) {
+	encodeOpts := opts.encodeOptions()
+	var result string
+	var err error
+	switch opts.lang {
+	case "c":
+		result, err = jsonstr.EncodeCLiteralWithOptions(input, opts.litWidth, encodeOpts)
+	case "sql":
+		result, err = jsonstr.EncodeSQLLiteralWithOptions(input, opts.sqlDialect, encodeOpts)
+	case "properties":
+		result, err = jsonstr.EncodePropertiesValueWithOptions(input, opts.propsASCII, encodeOpts)
+	case "yaml":
+		result, err = jsonstr.EncodeYAMLScalarWithOptions(input, encodeOpts)
+	case "toml":
+		result, err = jsonstr.EncodeTOMLStringWithOptions(input, encodeOpts)
+	default:
+		return "", fmt.Errorf("encoding JSON: unsupported --lang %q", opts.lang)
+	}
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+	return result, nil
+}
+
+// embedInput stores the input as an escaped string inside the --embed-into template
+func embedInput(input []byte, opts *options) (string, error) {
+	template, err := os.ReadFile(opts.embedInto)
+	if err != nil {
+		return "", fmt.Errorf("reading template: %w", err)
+	}
+
+	prepared, err := jsonstr.PrepareWithOptions(input, opts.encodeOptions())
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+
+	result, err := jsonstr.EmbedIn([]byte(prepared), template, opts.embedAt)
+	if err != nil {
+		return "", fmt.Errorf("embedding JSON: %w", err)
+	}
+	return string(result), nil
+}
+
+// escapeValues pretty-prints the input with each string value replaced by its escaped form
+func escapeValues(input []byte, opts *options) (string, error) {
+	data, err := jsonstr.Parse(input)
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+
+	result, err := json.MarshalIndent(jsonstr.EscapeValues(data), "", opts.indent)
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+	return string(result), nil
+}
+
+// indentEnv names the environment variable that sets the default indentation
+// for teams with a house style. --indent and --indent-width take precedence.
+const indentEnv = "JSON_TO_STRING_INDENT"
+
+// validIndent reports whether indent contains only spaces and tabs, the only
+// whitespace that keeps indented JSON valid
+func validIndent(indent string) bool {
+	return strings.Trim(indent, " \t") == ""
+}
+
+// envIndent returns the indentation set by JSON_TO_STRING_INDENT: a number of
+// spaces like --indent-width, or otherwise the indent string itself, which may
+// contain only spaces and tabs. ok is false when the variable is unset or empty.
+func envIndent() (indent string, ok bool, err error) {
+	value := os.Getenv(indentEnv)
+	if value == "" {
+		return "", false, nil
+	}
+	width, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		if !validIndent(value) {
+			return "", true, fmt.Errorf("invalid %s value %q: must be a number of spaces or contain only spaces and tabs", indentEnv, value)
+		}
+		return value, true, nil
+	}
+	if width < 1 || width > maxIndentWidth {
+		return "", true, fmt.Errorf("invalid %s value %d: must be between 1 and %d", indentEnv, width, maxIndentWidth)
+	}
+	return strings.Repeat(" ", width), true, nil
+}
+
+// resolveIndent applies JSON_TO_STRING_INDENT when neither --indent nor
+// --indent-width was given, so the precedence is flag > env > built-in default.
+// An empty --indent means the default, as it does in the library, so every
+// output that indents agrees.
+func resolveIndent(opts *options) {
+	if opts.indent == "" {
+		opts.indent = jsonstr.DefaultIndent
+	}
+	if flagPassed(opts.flags, "indent") || flagPassed(opts.flags, "indent-width") {
+		return
+	}
+	// An invalid value is reported when the options are validated
+	if indent, ok, err := envIndent(); ok && err == nil {
+		opts.indent = indent
+	}
+}
+
+// otherFormats returns the flags that were given to write another format
+// instead of an escaped string
+func (o *options) otherFormats() []string {
+	var flags []string
+	for _, format := range []struct {
+		set  bool
+		flag string
+	}{
+		{o.queryString, "--querystring"},
+		{o.toCSV, "--to-csv"},
+		{o.toJSON5, "--to-json5"},
+		{o.pointers, "--pointers"},
+		{o.bashArray, "--bash-array"},
+	} {
+		if format.set {
+			flags = append(flags, format.flag)
+		}
+	}
+	return flags
+}
+
+// validateOptions checks for flag combinations that cannot be used together
+func validateOptions(opts *options) error {
+	if len(opts.files) > 0 && (opts.inputFile != "" || opts.inputString != "" || opts.envVar != "" || opts.fd >= 0) {
+		return fmt.Errorf("batch files cannot be combined with --file, --json, --env or --fd")
+	}
+	if opts.fd < -1 {
+		return fmt.Errorf("invalid --fd value %d: must not be negative", opts.fd)
+	}
+	if opts.jobs < 1 {
+		return fmt.Errorf("invalid --jobs value %d: must be at least 1", opts.jobs)
+	}
+	if opts.compact && opts.pretty && !opts.decode {
+		return fmt.Errorf("--compact and --pretty cannot be combined when encoding")
+	}
+	if opts.stripWS && (opts.compact || opts.pretty || opts.decode) {
+		return fmt.Errorf("--strip-ws cannot be used with --compact, --pretty or --decode")
+	}
+	if opts.eol != "lf" && opts.eol != "crlf" {
+		return fmt.Errorf("invalid --eol value %q: must be lf or crlf", opts.eol)
+	}
+	if opts.hex && (opts.ndjson || opts.roundtrip) {
+		return fmt.Errorf("--hex cannot be used with --ndjson or --roundtrip")
+	}
+	if opts.dataURI && (opts.ndjson || opts.roundtrip || (opts.hex && !opts.decode)) {
+		return fmt.Errorf("--data-uri cannot be used with --ndjson, --roundtrip or --hex")
+	}
+	if opts.explode && (opts.decode || opts.ndjson || opts.roundtrip) {
+		return fmt.Errorf("--explode cannot be used with --decode, --ndjson or --roundtrip")
+	}
+	if opts.implode && (opts.ndjson || opts.explode || opts.roundtrip || opts.follow) {
+		return fmt.Errorf("--implode cannot be used with --ndjson, --explode, --roundtrip or --follow")
+	}
+	if opts.decodeElements && (opts.ndjson || opts.implode || opts.follow) {
+		return fmt.Errorf("--decode-elements cannot be used with --ndjson, --implode or --follow")
+	}
+	if opts.each && (opts.decode || opts.ndjson || opts.explode || opts.implode || opts.roundtrip) {
+		return fmt.Errorf("--each cannot be used with --decode, --ndjson, --explode, --implode or --roundtrip")
+	}
+	if opts.asArray && !opts.each {
+		return fmt.Errorf("--as-array requires --each")
+	}
+	if opts.explodePass && !opts.explode {
+		return fmt.Errorf("--explode-passthrough requires --explode")
+	}
+	if opts.unicode != "literal" && opts.unicode != "escaped" {
+		return fmt.Errorf("invalid --unicode value %q: must be literal or escaped", opts.unicode)
+	}
+	if opts.unicode == "escaped" && !opts.decode {
+		return fmt.Errorf("--unicode escaped requires --decode")
+	}
+	if opts.wrap < 0 {
+		return fmt.Errorf("invalid --wrap value %d: must not be negative", opts.wrap)
+	}
+	if opts.wrap > 0 && (!opts.decode || !opts.pretty) {
+		return fmt.Errorf("--wrap requires --decode and --pretty")
+	}
+	if opts.compactThreshold < 0 {
+		return fmt.Errorf("invalid --compact-threshold value %d: must not be negative", opts.compactThreshold)
+	}
+	if opts.compactThreshold > 0 && (!opts.decode || !opts.pretty) {
+		return fmt.Errorf("--compact-threshold requires --decode and --pretty")
+	}
+	if opts.flags != nil && flagPassed(opts.flags, "indent-width") {
+		if opts.indentWidth < 1 || opts.indentWidth > maxIndentWidth {
+			return fmt.Errorf("invalid --indent-width value %d: must be between 1 and %d", opts.indentWidth, maxIndentWidth)
+		}
+		if flagPassed(opts.flags, "indent") {
+			return fmt.Errorf("--indent-width cannot be used with --indent")
+		}
+	}
+	if opts.flags != nil && flagPassed(opts.flags, "indent") && !validIndent(opts.indent) {
+		return fmt.Errorf("invalid --indent value %q: must contain only spaces and tabs", opts.indent)
+	}
+	if opts.flags != nil && !flagPassed(opts.flags, "indent") && !flagPassed(opts.flags, "indent-width") {
+		if _, _, err := envIndent(); err != nil {
+			return err
+		}
+	}
+	if opts.prettyDepth < 0 {
+		return fmt.Errorf("invalid --pretty-depth value %d: must not be negative", opts.prettyDepth)
+	}
+	if opts.prettyDepth > 0 && !(opts.decode && opts.pretty) && !(opts.format && !opts.compact) {
+		return fmt.Errorf("--pretty-depth requires --decode --pretty, or the format command without --compact")
+	}
+	if opts.lineNumbers && !(opts.decode && opts.pretty) && !(opts.format && !opts.compact) {
+		return fmt.Errorf("--line-numbers requires --decode --pretty, or the format command without --compact")
+	}
+	if opts.indentJSONStrings && !opts.decode && !opts.format {
+		return fmt.Errorf("--indent-json-strings requires --decode or the format command")
+	}
+	if opts.lineNumbers && opts.ndjson {
+		return fmt.Errorf("--line-numbers cannot be used with --ndjson")
+	}
+	if opts.align && (!opts.decode || !opts.pretty) {
+		return fmt.Errorf("--align requires --decode and --pretty")
+	}
+	if opts.wrap > 0 && (opts.count || opts.countJSON) {
+		return fmt.Errorf("--wrap cannot be used with --count or --count-json")
+	}
+	if opts.lang != "" && opts.decode {
+		return fmt.Errorf("--lang cannot be used with --decode")
+	}
+	if opts.quote != "" && opts.quote != "single" && opts.quote != "double" {
+		return fmt.Errorf("invalid --quote value %q: must be single or double", opts.quote)
+	}
+	if opts.envName != "" && !envNamePattern.MatchString(opts.envName) {
+		return fmt.Errorf("invalid --env-name %q: must be a valid environment variable name", opts.envName)
+	}
+	if opts.export && (opts.envName == "" || opts.quote != "") {
+		return fmt.Errorf("--export requires --env-name and cannot be used with --quote, as it always single-quotes the value")
+	}
+	if (opts.quote != "" || opts.envName != "" || opts.export) && (opts.ndjson || opts.explode || (opts.each && !opts.asArray)) {
+		// These write one record per line, and a single assignment or quote
+		// around them all is not valid shell
+		return fmt.Errorf("--quote, --env-name and --export cannot be used with --ndjson, --explode or --each without --as-array")
+	}
+	if opts.bashArray && (opts.quote != "" || opts.export) {
+		return fmt.Errorf("--bash-array cannot be used with --quote or --export, as its elements are already quoted (use --env-name to assign the array)")
+	}
+	if opts.pad < 0 {
+		return fmt.Errorf("invalid --pad value %d: must not be negative", opts.pad)
+	}
+	if opts.padLeft && opts.pad == 0 {
+		return fmt.Errorf("--pad-left requires --pad")
+	}
+	if opts.pad > 0 && (opts.ndjson || opts.explode || opts.each || opts.markdown) {
+		// These write several records, or a code block rather than a record
+		return fmt.Errorf("--pad cannot be used with --ndjson, --explode, --each or --markdown")
+	}
+	if opts.warnSize < 0 || opts.failSize < 0 {
+		return fmt.Errorf("--warn-size and --fail-size must not be negative")
+	}
+	if (opts.count || opts.countJSON) && (len(opts.files) > 0 || opts.ndjson) {
+		return fmt.Errorf("--count and --count-json cannot be used with batch files or --ndjson")
+	}
+	if opts.follow && (!opts.ndjson || opts.inputFile == "") {
+		return fmt.Errorf("--follow requires --ndjson and --file")
+	}
+	if opts.markdown && opts.follow {
+		return fmt.Errorf("--markdown cannot be used with --follow")
+	}
+	if opts.stripFinalNL && (opts.rawOutput || opts.follow) {
+		return fmt.Errorf("--strip-final-newline cannot be used with --raw or --follow")
+	}
+	if _, ok := caseFuncs[opts.caseName]; opts.caseName != "" && !ok {
+		return fmt.Errorf("invalid --case value %q: must be lower or upper", opts.caseName)
+	}
+	if opts.maxStringLen < 0 {
+		return fmt.Errorf("invalid --max-string-length value %d: must not be negative", opts.maxStringLen)
+	}
+	if opts.maxArrayLen < 0 {
+		return fmt.Errorf("invalid --max-array-length value %d: must not be negative", opts.maxArrayLen)
+	}
+	if opts.noTruncationMarker && opts.maxStringLen == 0 && opts.maxArrayLen == 0 {
+		return fmt.Errorf("--no-truncation-marker requires --max-string-length or --max-array-length")
+	}
+	if opts.caseKeys && opts.caseName == "" {
+		return fmt.Errorf("--case-keys requires --case")
+	}
+	if _, _, err := stringMappers(opts); err != nil {
+		return err
+	}
+	if opts.floatPrecision < 0 || opts.floatPrecision > jsonstr.MaxFloatPrecision {
+		return fmt.Errorf("invalid --float-precision value %d: must be between 1 and %d", opts.floatPrecision, jsonstr.MaxFloatPrecision)
+	}
+	if opts.decode && opts.hasTransforms() {
+		return fmt.Errorf("--pointer, --set, --remove, --edits, --sort-arrays, --dedupe-arrays, --max-array-length, --float-precision, --expand-unicode, --trim-strings, --trim-keys, --case, --replace, --replace-regex, --max-string-length and --filter cannot be used with --decode")
+	}
+	if opts.embedInto != "" && (opts.decode || opts.lang != "") {
+		return fmt.Errorf("--embed-into cannot be used with --decode or --lang")
+	}
+	if opts.escapeVals && (opts.decode || opts.lang != "" || opts.embedInto != "") {
+		return fmt.Errorf("--escape-values cannot be used with --decode, --lang or --embed-into")
+	}
+	if opts.roundtrip && (len(opts.files) > 0 || opts.decode || opts.ndjson || opts.equalFile != "" ||
+		opts.lang != "" || opts.embedInto != "" || opts.escapeVals || opts.hasTransforms() || opts.redactSecrets) {
+		return fmt.Errorf("--roundtrip cannot be used with batch files, --decode, --ndjson, --equal, --lang, --embed-into, --escape-values, --pointer, --set, --remove, --edits, --sort-arrays, --dedupe-arrays, --max-array-length, --float-precision, --expand-unicode, --trim-strings, --trim-keys, --case, --replace, --replace-regex, --max-string-length, --filter or --redact-secrets")
+	}
+	if opts.detectSecrets && opts.decode {
+		return fmt.Errorf("--detect-secrets and --redact-secrets cannot be used with --decode")
+	}
+	if opts.readableCtrl && (opts.decode || opts.lang != "" || opts.embedInto != "" || opts.escapeVals) {
+		return fmt.Errorf("--readable-controls cannot be used with --decode, --lang, --embed-into or --escape-values")
+	}
+	if opts.byteEscape && (opts.decode || opts.lang != "" || opts.embedInto != "" || opts.escapeVals || opts.asJSONStr ||
+		len(opts.otherFormats()) > 0 || opts.checkIdem || opts.roundtrip) {
+		return fmt.Errorf("--byte-escape cannot be used with --decode, --lang, --embed-into, --escape-values, --as-json-string, --querystring, --to-csv, --to-json5, --pointers, --bash-array, --check-idempotent or --roundtrip")
+	}
+	if opts.listen != "" {
+		if _, _, err := parseListenAddr(opts.listen); err != nil {
+			return err
+		}
+		if len(opts.files) > 0 || opts.inputFile != "" || opts.inputString != "" || opts.envVar != "" || opts.fd >= 0 ||
+			opts.follow || opts.repeat > 0 || opts.equalFile != "" || opts.roundtrip || opts.showType || opts.compareOpts ||
+			opts.count || opts.countJSON || opts.summary {
+			return fmt.Errorf("--listen cannot be used with batch files, --file, --json, --env, --fd, --follow, --repeat, --equal, --roundtrip, --type, --compare-options, --count, --count-json or --summary")
+		}
+	}
+	if opts.gzip && opts.outputFile == "" {
+		return fmt.Errorf("--gzip requires --output")
+	}
+	if opts.outputFile != "" && (len(opts.files) > 0 || opts.follow || opts.listen != "" || opts.repl || opts.equalFile != "" ||
+		opts.roundtrip || opts.showType || opts.countJSON || opts.compareOpts) {
+		return fmt.Errorf("--output cannot be used with batch files, --follow, --listen, --repl, --equal, --roundtrip, --type, --count-json or --compare-options")
+	}
+	if opts.stream && opts.flags != nil {
+		if err := validateStream(opts); err != nil {
+			return err
+		}
+	}
+	if opts.byteRange != "" && opts.flags != nil {
+		if err := validateRange(opts); err != nil {
+			return err
+		}
+	}
+	if opts.repl && (len(opts.files) > 0 || opts.inputFile != "" || opts.inputString != "" || opts.envVar != "" || opts.fd >= 0 ||
+		opts.follow || opts.listen != "" || opts.ndjson || opts.repeat > 0 || opts.equalFile != "" || opts.roundtrip || opts.showType ||
+		opts.compareOpts || opts.count || opts.countJSON || opts.summary) {
+		return fmt.Errorf("--repl cannot be used with batch files, --file, --json, --env, --fd, --follow, --listen, --ndjson, --repeat, --equal, --roundtrip, --type, --compare-options, --count, --count-json or --summary")
+	}
+	if opts.listenLimit < 1 {
+		return fmt.Errorf("invalid --listen-limit value %d: must be positive", opts.listenLimit)
+	}
+	if opts.listenTimeout <= 0 {
+		return fmt.Errorf("invalid --listen-timeout value %v: must be positive", opts.listenTimeout)
+	}
+	if opts.stdinTimeout < 0 {
+		return fmt.Errorf("invalid --stdin-timeout value %v: must not be negative", opts.stdinTimeout)
+	}
+	if opts.rejectEscaped && opts.decode {
+		return fmt.Errorf("--reject-escaped cannot be used with --decode")
+	}
+	if err := validateCharsets(opts); err != nil {
+		return err
+	}
+	if enc, _ := lookupCharset(opts.inputCharset); enc != nil && opts.follow {
+		return fmt.Errorf("--input-charset cannot be used with --follow")
+	}
+	if opts.strictUTF8 && opts.follow {
+		return fmt.Errorf("--strict-utf8 cannot be used with --follow")
+	}
+	if opts.checkIdem && (len(opts.files) > 0 || opts.decode || opts.ndjson || opts.explode || opts.each ||
+		opts.lang != "" || opts.embedInto != "" || opts.escapeVals) {
+		return fmt.Errorf("--check-idempotent cannot be used with batch files, --decode, --ndjson, --explode, --each, --lang, --embed-into or --escape-values")
+	}
+	if opts.compareOpts && (len(opts.files) > 0 || opts.decode || opts.ndjson || opts.explode || opts.implode || opts.each ||
+		opts.equalFile != "" || opts.roundtrip || opts.showType || opts.count || opts.countJSON) {
+		return fmt.Errorf("--compare-options cannot be used with batch files, --decode, --ndjson, --explode, --implode, --each, --equal, --roundtrip, --type, --count or --count-json")
+	}
+	if opts.fromQuery && (opts.decode || opts.ndjson || opts.implode || opts.follow) {
+		return fmt.Errorf("--from-querystring cannot be used with --decode, --ndjson, --implode or --follow")
+	}
+	if opts.fromCSV && (opts.fromQuery || opts.decode || opts.ndjson || opts.implode || opts.follow) {
+		return fmt.Errorf("--from-csv cannot be used with --from-querystring, --decode, --ndjson, --implode or --follow")
+	}
+	if opts.stripComments && (opts.fromQuery || opts.fromCSV || opts.decode || opts.follow) {
+		return fmt.Errorf("--strip-comments cannot be used with --from-querystring, --from-csv, --decode or --follow")
+	}
+	if opts.commentsTo != "" && !opts.stripComments {
+		return fmt.Errorf("--comments-to requires --strip-comments")
+	}
+	if opts.commentsTo != "" && (len(opts.files) > 0 || opts.listen != "" || opts.repl || opts.repeat > 0) {
+		// Each input or run would overwrite the comments of the previous one
+		return fmt.Errorf("--comments-to cannot be used with batch files, --listen, --repl or --repeat")
+	}
+	if opts.preserveHeader && (opts.decode || opts.follow) {
+		return fmt.Errorf("--preserve-header cannot be used with --decode or --follow")
+	}
+	if (opts.csvInferTypes || opts.csvLenient) && !opts.fromCSV {
+		return fmt.Errorf("--csv-infer-types and --csv-lenient require --from-csv")
+	}
+	if opts.summary && (len(opts.files) > 0 || opts.follow || opts.equalFile != "" || opts.roundtrip || opts.showType ||
+		opts.countJSON || opts.compareOpts || opts.repeat > 0) {
+		return fmt.Errorf("--summary cannot be used with batch files, --follow, --equal, --roundtrip, --type, --count-json, --compare-options or --repeat")
+	}
+	if opts.expect != "" {
+		if _, err := parseExpect(opts.expect); err != nil {
+			return err
+		}
+	}
+	if opts.repeat < 0 {
+		return fmt.Errorf("invalid --repeat value %d: must not be negative", opts.repeat)
+	}
+	if opts.repeat > 0 && (len(opts.files) > 0 || opts.follow || opts.equalFile != "" || opts.roundtrip || opts.showType ||
+		opts.count || opts.countJSON || opts.checkIdem || opts.compareOpts) {
+		return fmt.Errorf("--repeat cannot be used with batch files, --follow, --equal, --roundtrip, --type, --count, --count-json, --check-idempotent or --compare-options")
+	}
+	formats := opts.otherFormats()
+	if len(formats) > 1 {
+		return fmt.Errorf("%s cannot be used together", strings.Join(formats, " and "))
+	}
+	if len(formats) > 0 && (opts.decode || opts.lang != "" || opts.embedInto != "" || opts.escapeVals || opts.asJSONStr ||
+		opts.readableCtrl || opts.checkIdem || opts.compareOpts) {
+		return fmt.Errorf("%s cannot be used with --decode, --lang, --embed-into, --escape-values, --as-json-string, --readable-controls, --check-idempotent or --compare-options", formats[0])
+	}
+	if opts.htmlAttr && (opts.decode || opts.lang != "" || opts.embedInto != "" || opts.escapeVals || len(formats) > 0) {
+		return fmt.Errorf("--html-attr cannot be used with --decode, --lang, --embed-into, --escape-values, --querystring, --to-csv, --to-json5, --pointers or --bash-array")
+	}
+	if opts.asciiKeys && (opts.decode || opts.escapeVals || len(formats) > 0) {
+		return fmt.Errorf("--ensure-ascii-keys cannot be used with --decode, --escape-values, --querystring, --to-csv, --to-json5, --pointers or --bash-array")
+	}
+	if opts.escapeASCIIKeys && !opts.asciiKeys {
+		return fmt.Errorf("--escape-nonascii-keys requires --ensure-ascii-keys")
+	}
+	if opts.both && (opts.decode || opts.lang != "" || opts.embedInto != "" || opts.escapeVals || len(formats) > 0 ||
+		opts.checkIdem || opts.compareOpts || opts.roundtrip) {
+		return fmt.Errorf("--both cannot be used with --decode, --lang, --embed-into, --escape-values, --querystring, --to-csv, --to-json5, --pointers, --bash-array, --check-idempotent, --compare-options or --roundtrip")
+	}
+	if opts.asJSONStr && (opts.decode || opts.lang != "" || opts.embedInto != "" || opts.escapeVals) {
+		return fmt.Errorf("--as-json-string cannot be used with --decode, --lang, --embed-into or --escape-values")
+	}
+	if opts.showType && (len(opts.files) > 0 || opts.decode || opts.ndjson) {
+		return fmt.Errorf("--type cannot be used with batch files, --decode or --ndjson")
+	}
+	if opts.ignoreMissing && len(opts.removes) == 0 && opts.editsFile == "" {
+		return fmt.Errorf("--ignore-missing requires --remove or --edits")
+	}
+	if opts.embedAt != "" && opts.embedInto == "" {
+		return fmt.Errorf("--at requires --embed-into")
+	}
+	return nil
+}
+
+// errNotEqual is returned by checkEqual when the documents differ. The outcome
+// has already been printed, so the command exits non-zero without a message.
+var errNotEqual = errors.New("documents are not equal")
+
+// checkEqual compares the input with the --equal file, printing the outcome
+// and returning errNotEqual when the documents differ
+func checkEqual(input []byte, opts *options) error {
+	other, err := os.ReadFile(opts.equalFile)
+	if err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+
+	equal, err := jsonstr.Equal(input, other)
+	if err != nil {
+		return fmt.Errorf("comparing JSON: %w", err)
+	}
+
+	writeResult(os.Stdout, fmt.Sprint(equal), opts)
+	if !equal {
+		return errNotEqual
+	}
+	return nil
+}
+
+// checkRoundtrip encodes the input and decodes the result again, printing the
+// decoded JSON and failing when it is not equal to the input. With
+// --strict-roundtrip the result is decoded verbatim and must match the input
+// byte for byte.
+func checkRoundtrip(input []byte, opts *options) error {
+	encoded, err := jsonstr.EncodeWithOptions(input, opts.encodeOptions())
+	if err != nil {
+		return fmt.Errorf("encoding JSON: %w", err)
+	}
+
+	if opts.strictRoundtrip {
+		decoded, err := jsonstr.DecodeVerbatim([]byte(encoded))
+		if err != nil {
+			return fmt.Errorf("roundtrip failed: decoding %s: %w", encoded, err)
+		}
+		writeResult(os.Stdout, decoded, opts)
+		if offset := firstDifference(input, []byte(decoded)); offset >= 0 {
+			return fmt.Errorf("roundtrip failed: decoded JSON differs from the input at byte %d: %s", offset, roundtripBreakers(input, opts))
+		}
+		return nil
+	}
+
+	decoded, err := jsonstr.DecodeWithOptions([]byte(encoded), opts.encodeOptions())
+	if err != nil {
+		return fmt.Errorf("roundtrip failed: decoding %s: %w", encoded, err)
+	}
+
+	equal, err := jsonstr.Equal(input, []byte(decoded))
+	if err != nil {
+		return fmt.Errorf("comparing JSON: %w", err)
+	}
+
+	writeResult(os.Stdout, decoded, opts)
+	if !equal {
+		return fmt.Errorf("roundtrip failed: decoded JSON differs from the input")
+	}
+	return nil
+}
+
+// firstDifference returns the offset of the first byte at which a and b differ,
+// or -1 if they are identical
+func firstDifference(a, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	if len(a) != len(b) {
+		return min(len(a), len(b))
+	}
+	return -1
+}
+
+// roundtripBreakers explains which of the options given keep the input from
+// decoding back byte for byte for --strict-roundtrip
+func roundtripBreakers(input []byte, opts *options) string {
+	var reasons []string
+	if opts.compact {
+		reasons = append(reasons, "--compact re-marshals the JSON, sorting keys and rewriting numbers and whitespace")
+	}
+	if opts.pretty {
+		reasons = append(reasons, "--pretty re-marshals and re-indents the JSON, sorting keys and rewriting numbers")
+	}
+	if opts.stripWS {
+		reasons = append(reasons, "--strip-ws removes whitespace")
+	}
+	if opts.escapeASCIIKeys {
+		reasons = append(reasons, "--escape-nonascii-keys rewrites non-ASCII characters in keys as \\uXXXX escapes")
+	}
+	if len(reasons) > 0 {
+		return strings.Join(reasons, "; ")
+	}
+	if !utf8.Valid(input) {
+		return "the input is not valid UTF-8, and encoding replaces invalid bytes with U+FFFD"
+	}
+	return "encoding changed the input"
+}
+
+// printType prints the top-level JSON type of the input, after applying --pointer
+func printType(input []byte, opts *options) error {
+	input, err := transformInput(input, opts)
+	if err != nil {
+		return fmt.Errorf("detecting type: %w", err)
+	}
+
+	typ, err := jsonstr.TopLevelType(input)
+	if err != nil {
+		return fmt.Errorf("detecting type: %w", err)
+	}
+	writeResult(os.Stdout, typ, opts)
+	return nil
+}
+
+// printStats prints structural statistics for a JSON document, either as a
+// summary line on stderr (--count) or as JSON on stdout (--count-json)
+func printStats(document []byte, opts *options) error {
+	data, err := jsonstr.Parse(document)
+	if err != nil {
+		return fmt.Errorf("counting JSON: %w", err)
+	}
+
+	stats := jsonstr.Stats(data)
+	if !opts.countJSON {
+		fmt.Fprintf(os.Stderr, "Nodes: %d, objects: %d, arrays: %d, keys: %d, elements: %d, max depth: %d\n",
+			stats.Nodes, stats.Objects, stats.Arrays, stats.Keys, stats.Elements, stats.MaxDepth)
+		return nil
+	}
+
+	statsJSON, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("counting JSON: %w", err)
+	}
+	writeResult(os.Stdout, string(statsJSON), opts)
+	return nil
+}
+
+// fail prints an error message to stderr and exits with a non-zero status
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format, args...)
+	os.Exit(1)
 }
 
 func main() {
-	var inputFile string
-	var inputString string
-	var compact bool
-	var decode bool
-	var pretty bool
-	var rawOutput bool
-	var showVersion bool
-	var showHelp bool
-
-	flag.StringVar(&inputFile, "file", "", "Input JSON file path")
-	flag.StringVar(&inputString, "json", "", "JSON string input")
-	flag.BoolVar(&compact, "compact", false, "Remove newlines and extra spaces from pretty-printed JSON")
-	flag.BoolVar(&decode, "decode", false, "Decode an escaped JSON string back to JSON")
-	flag.BoolVar(&pretty, "pretty", false, "Format the decoded JSON output with indentation (only used with --decode)")
-	flag.BoolVar(&rawOutput, "raw", false, "Output without trailing newline (useful for piping)")
-	flag.BoolVar(&showVersion, "version", false, "Show version information")
-	flag.BoolVar(&showHelp, "help", false, "Show help with examples")
-
-	// Override the default usage function
-	flag.Usage = printUsage
-
-	flag.Parse()
-
-	if showHelp {
-		printUsage()
+	opts := parseArgs(os.Args[1:])
+
+	if opts.showHelp {
+		printUsage(opts)
 		os.Exit(0)
 	}
 
-	if showVersion {
+	if opts.showVersion {
 		fmt.Println(version)
 		os.Exit(0)
 	}
 
+	if opts.completion != "" {
+		if err := writeCompletion(os.Stdout, opts.completion); err != nil {
+			fail("Error: %v\n", err)
+		}
+		os.Exit(0)
+	}
+
+	if opts.man {
+		writeManPage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if err := validateOptions(opts); err != nil {
+		fail("Error: %v\n", err)
+	}
+
+	// Positional arguments are treated as a batch of input files
+	if len(opts.files) > 0 {
+		if errs := processBatch(os.Stdout, opts.files, opts); len(errs) > 0 {
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.follow {
+		if err := followFile(opts.inputFile, os.Stdout, opts); err != nil {
+			fail("Error %v\n", err)
+		}
+		return
+	}
+
+	if opts.listen != "" {
+		if err := serveListen(opts); err != nil {
+			fail("Error %v\n", err)
+		}
+		return
+	}
+
+	if opts.stream {
+		w, closeOutput, err := openOutput(opts)
+		if err != nil {
+			fail("Error %v\n", err)
+		}
+		stream := encodeStream
+		if opts.decode {
+			stream = decodeStream
+		}
+		if opts.gzip {
+			// The final line ending is compressed too, so the file holds what stdout would show
+			err = jsonstr.WriteGzip(w, func(gz io.Writer) error { return stream(gz, opts) })
+		} else {
+			err = stream(w, opts)
+		}
+		if closeErr := closeOutput(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fail("Error %v\n", err)
+		}
+		return
+	}
+
+	if opts.repl {
+		// Prompt only at a terminal, so piped sessions print just the results
+		if err := runREPL(os.Stdin, os.Stdout, opts, !stdinHasInput()); err != nil {
+			fail("Error %v\n", err)
+		}
+		return
+	}
+
 	var input []byte
 	var err error
+	release := func() {}
 
 	switch {
-	case inputFile != "":
-		input, err = os.ReadFile(inputFile)
+	case opts.inputFile != "":
+		input, release, err = readFile(opts.inputFile, opts.useMmap)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-			os.Exit(1)
+			fail("Error reading file: %v\n", err)
+		}
+	case opts.inputString != "":
+		input, release, err = readJSONArg(opts.inputString, opts)
+		if err != nil {
+			fail("Error reading input: %v\n", err)
+		}
+	case opts.envVar != "":
+		input, err = readEnv(opts.envVar)
+		if err != nil {
+			fail("Error reading environment: %v\n", err)
+		}
+	case opts.fd >= 0:
+		input, err = readFD(opts.fd)
+		if err != nil {
+			fail("Error reading input: %v\n", err)
 		}
-	case inputString != "":
-		input = []byte(inputString)
 	default:
 		// Read from stdin if no file or string provided
-		stat, _ := os.Stdin.Stat()
-		if (stat.Mode() & os.ModeCharDevice) == 0 {
-			input, err = io.ReadAll(os.Stdin)
+		if stdinHasInput() {
+			input, err = readStdin(opts.stdinTimeout)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading from stdin: %v\n", err)
-				os.Exit(1)
+				fail("Error reading from stdin: %v\n", err)
 			}
 		} else {
-			fmt.Fprintln(os.Stderr, "No input provided. Use --file, --json or pipe data to stdin.")
-			printUsage()
+			fmt.Fprintln(os.Stderr, "No input provided. Use --file, --json, --env, --fd or pipe data to stdin.")
+			printUsage(opts)
 			os.Exit(1)
 		}
 	}
 
-	var result string
-	if decode {
-		result, err = jsonstr.Decode(input, pretty)
+	err = processInput(input, opts)
+	// The input may be memory-mapped, so it is released only once nothing uses it
+	release()
+	if errors.Is(err, errNotEqual) {
+		os.Exit(1)
+	}
+	if err != nil {
+		fail("Error %v\n", err)
+	}
+}
+
+// processInput runs the conversion or check selected by opts on the input and
+// writes the output. Nothing it writes or keeps refers to the input afterwards,
+// so a memory-mapped input can be released once it returns.
+func processInput(input []byte, opts *options) error {
+	if opts.byteRange != "" {
+		output, err := escapeRange(input, opts)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error decoding JSON string: %v\n", err)
-			os.Exit(1)
+			return err
 		}
-	} else {
-		result, err = jsonstr.Encode(input, compact)
+		return writeOutput(output, opts)
+	}
+
+	if opts.repeat > 0 {
+		result, err := repeatConversion(os.Stderr, input, opts)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
-			os.Exit(1)
+			return err
+		}
+		return writeOutput(result, opts)
+	}
+
+	input, header, comments, err := prepareInput(input, opts)
+	if err != nil {
+		return fmt.Errorf("reading input: %w", err)
+	}
+	if err := convertPrepared(input, header, opts); err != nil {
+		return err
+	}
+	// The removed comments are only saved once the conversion has succeeded
+	return writeComments(comments, opts)
+}
+
+// convertPrepared runs the conversion or check selected by opts on the JSON
+// returned by prepareInput and writes the output
+func convertPrepared(input, header []byte, opts *options) error {
+	if opts.equalFile != "" {
+		return checkEqual(input, opts)
+	}
+	if opts.roundtrip {
+		return checkRoundtrip(input, opts)
+	}
+	if opts.showType {
+		return printType(input, opts)
+	}
+	if opts.compareOpts {
+		return compareOptions(os.Stderr, input, opts)
+	}
+
+	result, err := convert(input, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.count || opts.countJSON {
+		// Statistics describe the JSON document: the input when encoding, the result when decoding
+		document := input
+		if opts.decode {
+			document = []byte(result)
+		}
+		if err := printStats(document, opts); err != nil {
+			return err
+		}
+		if opts.countJSON {
+			return nil
 		}
 	}
+	if opts.checkIdem && !opts.quiet {
+		checkIdempotent(os.Stderr, input, result, opts)
+	}
 
-	if rawOutput {
-		fmt.Print(result)
-	} else {
-		fmt.Println(result)
+	output, err := finishOutput(result, opts)
+	if err != nil {
+		return err
+	}
+	output = attachHeader(header, output)
+	if opts.summary {
+		writeSummary(os.Stderr, input, result, output, opts)
 	}
+	return writeOutput(output, opts)
 }
@@ -0,0 +1,372 @@
+//go:build unix
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// TestStdinSources verifies input is read from named pipes and redirected files
+func TestStdinSources(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+	dir := t.TempDir()
+
+	t.Run("Named pipe", func(t *testing.T) {
+		fifoPath := filepath.Join(dir, "input.fifo")
+		if err := syscall.Mkfifo(fifoPath, 0o600); err != nil {
+			t.Fatalf("Failed to create FIFO: %v", err)
+		}
+
+		// Opening either end of a FIFO blocks until the other end is opened
+		writeErr := make(chan error, 1)
+		go func() {
+			writer, err := os.OpenFile(fifoPath, os.O_WRONLY, 0)
+			if err != nil {
+				writeErr <- err
+				return
+			}
+			_, err = writer.WriteString(`{"source":"fifo"}`)
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+			writeErr <- err
+		}()
+
+		reader, err := os.Open(fifoPath)
+		if err != nil {
+			t.Fatalf("Failed to open FIFO: %v", err)
+		}
+		defer reader.Close()
+
+		cmd := exec.Command(binaryPath)
+		cmd.Stdin = reader
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("unexpected error: %v, stderr: %s", err, stderr.String())
+		}
+		if err := <-writeErr; err != nil {
+			t.Fatalf("Failed to write to FIFO: %v", err)
+		}
+		if strings.TrimSpace(stdout.String()) != `{\"source\":\"fifo\"}` {
+			t.Errorf("unexpected output %q", stdout.String())
+		}
+	})
+
+	t.Run("Redirected regular file", func(t *testing.T) {
+		path := filepath.Join(dir, "input.json")
+		if err := os.WriteFile(path, []byte(`{"source":"file"}`), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open file: %v", err)
+		}
+		defer file.Close()
+
+		cmd := exec.Command(binaryPath)
+		cmd.Stdin = file
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("unexpected error: %v, stderr: %s", err, stderr.String())
+		}
+		if strings.TrimSpace(stdout.String()) != `{\"source\":\"file\"}` {
+			t.Errorf("unexpected output %q", stdout.String())
+		}
+	})
+
+	t.Run("Character device", func(t *testing.T) {
+		devNull, err := os.Open(os.DevNull)
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", os.DevNull, err)
+		}
+		defer devNull.Close()
+
+		cmd := exec.Command(binaryPath)
+		cmd.Stdin = devNull
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err == nil {
+			t.Errorf("expected error when stdin is a character device")
+		}
+		if !strings.Contains(stderr.String(), "No input provided") {
+			t.Errorf("expected 'No input provided' in stderr but got: %s", stderr.String())
+		}
+	})
+}
+
+// TestShellQuoting verifies that quoted assignments and export lines are read back
+// unchanged by the shell
+func TestShellQuoting(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+	input := `{"text":"it's \"quoted\" $HOME ` + "`cmd`" + ` \\ done"}`
+
+	encoded, err := exec.Command(binaryPath, "--raw", "--json", input).Output()
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	for name, flags := range map[string][]string{
+		"single": {"--quote", "single"},
+		"double": {"--quote", "double"},
+		"export": {"--export"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			args := append([]string{"--raw", "--env-name", "CONFIG", "--json", input}, flags...)
+			assignment, err := exec.Command(binaryPath, args...).Output()
+			if err != nil {
+				t.Fatalf("command failed: %v", err)
+			}
+
+			shell := exec.Command("sh", "-c", string(assignment)+`; printf '%s' "$CONFIG"`)
+			value, err := shell.Output()
+			if err != nil {
+				t.Fatalf("shell failed to evaluate %s: %v", assignment, err)
+			}
+			if string(value) != string(encoded) {
+				t.Errorf("expected shell to read back %s but got %s", encoded, value)
+			}
+		})
+	}
+}
+
+// TestFDInput verifies --fd reads from an inherited descriptor and reports unusable ones
+func TestFDInput(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+	dir := t.TempDir()
+
+	t.Run("Readable descriptor", func(t *testing.T) {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("Failed to create pipe: %v", err)
+		}
+		defer reader.Close()
+		go func() {
+			writer.WriteString(`{"source":"fd"}`)
+			writer.Close()
+		}()
+
+		cmd := exec.Command(binaryPath, "--fd", "3")
+		// ExtraFiles[0] becomes descriptor 3 in the child
+		cmd.ExtraFiles = []*os.File{reader}
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("command failed: %v", err)
+		}
+		if expected := `{\"source\":\"fd\"}`; strings.TrimSpace(string(output)) != expected {
+			t.Errorf("expected %s but got %s", expected, output)
+		}
+	})
+
+	t.Run("Descriptor not open", func(t *testing.T) {
+		cmd := exec.Command(binaryPath, "--fd", "9")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err == nil {
+			t.Fatalf("expected error but got none")
+		}
+		if !strings.Contains(stderr.String(), "file descriptor 9 is not open") {
+			t.Errorf("expected a not open error but got: %s", stderr.String())
+		}
+	})
+
+	t.Run("Write-only descriptor", func(t *testing.T) {
+		file, err := os.OpenFile(filepath.Join(dir, "write-only.json"), os.O_WRONLY|os.O_CREATE, 0o600)
+		if err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		defer file.Close()
+
+		cmd := exec.Command(binaryPath, "--fd", "3")
+		cmd.ExtraFiles = []*os.File{file}
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err == nil {
+			t.Fatalf("expected error but got none")
+		}
+		if !strings.Contains(stderr.String(), "not open for reading") {
+			t.Errorf("expected a not readable error but got: %s", stderr.String())
+		}
+	})
+}
+
+// TestFilter verifies --filter pipes the JSON through a shell command
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		input       string
+		expected    string
+		expectError string
+	}{
+		{name: "Output is returned without surrounding whitespace", command: "sed 's/1/2/'; echo", input: `{"a":1}`, expected: `{"a":2}`},
+		{name: "Shell pipes", command: "cat | tr a b", input: `["a"]`, expected: `["b"]`},
+		{name: "Command failure", command: "echo broken >&2; exit 3", input: `{}`, expectError: "failed with exit status 3: broken"},
+		{name: "Output that is not JSON", command: "echo hello", input: `{}`, expectError: "did not write a single JSON document"},
+		{name: "Several documents", command: "echo '1 2'", input: `{}`, expectError: "did not write a single JSON document"},
+		{name: "No output", command: "cat >/dev/null", input: `{}`, expectError: "wrote no output"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := runFilter([]byte(tc.input), tc.command)
+			if tc.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+					t.Errorf("expected error containing %q but got %v", tc.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+
+	t.Run("Filtered output is encoded after the other options", func(t *testing.T) {
+		binaryPath := buildTestBinary(t)
+		cmd := exec.Command(binaryPath, "--remove", "/b", "--filter", "sed 's/x/y/'", "--json", `{"b":1,"a":"x"}`)
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("command failed: %v", err)
+		}
+		if expected := `{\"a\":\"y\"}`; strings.TrimSpace(string(output)) != expected {
+			t.Errorf("expected %s but got %s", expected, output)
+		}
+	})
+}
+
+// TestBashArray verifies bash reads the --bash-array output back as the escaped elements
+func TestBashArray(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	binaryPath := buildTestBinary(t)
+
+	output, err := exec.Command(binaryPath, "--bash-array", "--json", `["a b","it's",{"k":"$HOME \"x\""},[1, 2]]`).Output()
+	if err != nil {
+		t.Fatalf("command failed: %v", err)
+	}
+	script := "items=" + strings.TrimSpace(string(output)) + `; printf '%s\n' "${items[@]}"`
+	result, err := exec.Command(bash, "-c", script).Output()
+	if err != nil {
+		t.Fatalf("bash failed: %v", err)
+	}
+	expected := `\"a b\"` + "\n" + `\"it's\"` + "\n" + `{\"k\":\"$HOME \\\"x\\\"\"}` + "\n" + `[1, 2]` + "\n"
+	if string(result) != expected {
+		t.Errorf("expected %q but got %q", expected, result)
+	}
+}
+
+// TestMmapInput verifies that a memory-mapped --file can be used with the options
+// that read the input after it has been converted, and that files which cannot
+// be mapped are read normally
+func TestMmapInput(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "input.json")
+	if err := os.WriteFile(inputFile, []byte(`{"a": 1, "b": [1, 2]}`), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	headerFile := filepath.Join(dir, "header.json")
+	if err := os.WriteFile(headerFile, []byte("// generated\n{\"a\":1}"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	otherFile := filepath.Join(dir, "other.json")
+	if err := os.WriteFile(otherFile, []byte(`{"a":1}`), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		file     string
+		stdin    string
+		expected string
+		stderr   string
+		wantErr  bool
+	}{
+		{
+			name:     "Count and idempotence check",
+			args:     []string{"--count", "--check-idempotent"},
+			expected: `{\"a\": 1, \"b\": [1, 2]}`,
+			stderr:   "Nodes: 5",
+		},
+		{
+			name:     "Summary",
+			args:     []string{"--summary"},
+			expected: `{\"a\": 1, \"b\": [1, 2]}`,
+			stderr:   "encoded object, 2 keys",
+		},
+		{
+			name:     "Preserved header",
+			args:     []string{"--preserve-header"},
+			file:     headerFile,
+			expected: "// generated\n{\\\"a\\\":1}",
+		},
+		{
+			name:     "Equal",
+			args:     []string{"--equal", inputFile},
+			expected: "true",
+		},
+		{
+			name:     "Not equal",
+			args:     []string{"--equal", otherFile},
+			expected: "false",
+			wantErr:  true,
+		},
+		{
+			name:     "Roundtrip",
+			args:     []string{"--roundtrip"},
+			expected: `{"a":1,"b":[1,2]}`,
+		},
+		{
+			name:     "Pipe",
+			args:     []string{"--file", "/dev/stdin"},
+			stdin:    `{"source":"pipe"}`,
+			expected: `{\"source\":\"pipe\"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			args := append([]string{"--mmap"}, tc.args...)
+			if tc.file == "" {
+				tc.file = inputFile
+			}
+			if tc.stdin == "" {
+				args = append(args, "--file", tc.file)
+			}
+			cmd := exec.Command(binaryPath, args...)
+			cmd.Stdin = strings.NewReader(tc.stdin)
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			err := cmd.Run()
+			if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() != 1 {
+				t.Fatalf("command crashed with exit code %d: %s", exitErr.ExitCode(), stderr.String())
+			}
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("expected error %v but got %v: %s", tc.wantErr, err, stderr.String())
+			}
+			if output := strings.TrimSpace(stdout.String()); output != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, output)
+			}
+			if !strings.Contains(stderr.String(), tc.stderr) {
+				t.Errorf("expected %q on stderr but got %q", tc.stderr, stderr.String())
+			}
+		})
+	}
+}
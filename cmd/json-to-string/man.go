@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// roffEscaper escapes text for roff. Hyphens are escaped so option names render
+// as minus signs and can be searched for and copied.
+var roffEscaper = strings.NewReplacer(`\`, `\e`, `-`, `\-`)
+
+// roffText escapes a line of text for roff, protecting a leading control character
+func roffText(s string) string {
+	s = roffEscaper.Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// manDefaults describes defaults that depend on the machine the page is generated
+// on, so the page is the same wherever it is built
+var manDefaults = map[string]string{
+	"jobs": "the number of CPUs",
+}
+
+// writeManOption writes the manual page entry of a flag
+func writeManOption(w io.Writer, f *flag.Flag) {
+	valueName, usage := flag.UnquoteUsage(f)
+	fmt.Fprintf(w, ".TP\n")
+	if valueName == "" {
+		fmt.Fprintf(w, ".B %s\n", roffText("--"+f.Name))
+	} else {
+		fmt.Fprintf(w, ".BI \"%s \" %s\n", roffText("--"+f.Name), valueName)
+	}
+	if def, ok := manDefaults[f.Name]; ok {
+		usage += fmt.Sprintf(" (default %s)", def)
+	} else if f.DefValue != "" && f.DefValue != "0" && f.DefValue != "false" {
+		usage += fmt.Sprintf(" (default %q)", f.DefValue)
+	}
+	fmt.Fprintf(w, "%s\n", roffText(usage))
+}
+
+// writeManExamples writes a list of examples as indented literal blocks
+func writeManExamples(w io.Writer, examples []example) {
+	for _, ex := range examples {
+		fmt.Fprintf(w, ".PP\n%s:\n", roffText(ex.description))
+		fmt.Fprintf(w, ".RS\n.nf\n%s\n.fi\n.RE\n", roffText(ex.command))
+	}
+}
+
+// writeManPage writes a roff manual page for section 1, built from the flag
+// definitions and the usage examples so it stays in sync with --help
+func writeManPage(w io.Writer) {
+	fmt.Fprintf(w, ".TH JSON\\-TO\\-STRING 1 \"\" \"json-to-string %s\" \"User Commands\"\n", roffText(version))
+	fmt.Fprintf(w, ".SH NAME\n")
+	fmt.Fprintf(w, "json\\-to\\-string \\- convert JSON to escaped string format and vice versa\n")
+
+	fmt.Fprintf(w, ".SH SYNOPSIS\n")
+	fmt.Fprintf(w, ".B json\\-to\\-string\n.I command\n[\\fIoptions\\fR] [\\fIfile\\fR ...]\n.br\n")
+	fmt.Fprintf(w, ".B json\\-to\\-string\n[\\fIoptions\\fR] [\\fIfile\\fR ...]\n")
+
+	fmt.Fprintf(w, ".SH DESCRIPTION\n")
+	fmt.Fprintf(w, "%s\n", roffText("json-to-string escapes a JSON document so it can be embedded as a string value, "+
+		"and decodes escaped strings back to JSON. Input is read from the first of --file, --json, --env, "+
+		"--fd and stdin that is given. With several file arguments each file is converted on its own."))
+	fmt.Fprintf(w, ".PP\n")
+	fmt.Fprintf(w, "%s\n", roffText("Without a command every option is accepted and the input is encoded, "+
+		"or decoded with --decode. This form is deprecated in favour of the commands below."))
+
+	fmt.Fprintf(w, ".SH COMMANDS\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s.\n", cmd.name, roffText(cmd.summary))
+		var names []string
+		for _, f := range commandFlags(cmd.name) {
+			names = append(names, "--"+f.name)
+		}
+		fmt.Fprintf(w, "Options: %s\n", roffText(strings.Join(names, ", ")))
+	}
+
+	fmt.Fprintf(w, ".SH OPTIONS\n")
+	newFlagSet("", &options{}).VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			writeManOption(w, f)
+		}
+	})
+
+	fmt.Fprintf(w, ".SH EXAMPLES\n")
+	fmt.Fprintf(w, ".SS Encoding\n")
+	writeManExamples(w, encodeExamples)
+	fmt.Fprintf(w, ".SS Decoding\n")
+	writeManExamples(w, decodeExamples)
+
+	fmt.Fprintf(w, ".SH EXIT STATUS\n")
+	fmt.Fprintf(w, "%s\n", roffText("0 on success, 1 if the input cannot be read or converted, "+
+		"or if a check such as --equal, --roundtrip or --fail-size does not pass."))
+
+	fmt.Fprintf(w, ".SH SEE ALSO\n")
+	fmt.Fprintf(w, ".UR https://github.com/eiladin/json-to-string\n.UE\n")
+}
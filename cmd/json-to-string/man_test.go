@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestWriteManPage verifies the manual page documents every visible flag and example
+func TestWriteManPage(t *testing.T) {
+	var buf bytes.Buffer
+	writeManPage(&buf)
+	page := buf.String()
+
+	for _, section := range []string{".TH JSON\\-TO\\-STRING 1", ".SH NAME", ".SH SYNOPSIS", ".SH COMMANDS", ".SH OPTIONS", ".SH EXAMPLES"} {
+		if !strings.Contains(page, section+"\n") && !strings.Contains(page, section+" ") {
+			t.Errorf("expected %q in the manual page", section)
+		}
+	}
+	for _, cmd := range commands {
+		if !strings.Contains(page, ".B "+cmd.name+"\n") {
+			t.Errorf("expected command %q in the manual page", cmd.name)
+		}
+	}
+	newFlagSet("", &options{}).VisitAll(func(f *flag.Flag) {
+		entry := roffText("--" + f.Name)
+		found := strings.Contains(page, ".B "+entry+"\n") || strings.Contains(page, ".BI \""+entry+" \"")
+		if found == hiddenFlags[f.Name] {
+			t.Errorf("expected flag %q documented=%v", f.Name, !hiddenFlags[f.Name])
+		}
+	})
+	for _, ex := range append(encodeExamples, decodeExamples...) {
+		if !strings.Contains(page, "\n"+roffText(ex.command)+"\n") {
+			t.Errorf("expected example %q in the manual page", ex.command)
+		}
+	}
+	if !strings.Contains(page, "(default the number of CPUs)") {
+		t.Errorf("expected a machine-independent default for --jobs")
+	}
+}
+
+// TestRoffText verifies text is escaped for roff
+func TestRoffText(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "plain text", expected: "plain text"},
+		{input: "--file", expected: `\-\-file`},
+		{input: `{\"key\"}`, expected: `{\e"key\e"}`},
+		{input: ".env file", expected: `\&.env file`},
+		{input: "'quoted'", expected: `\&'quoted'`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			if got := roffText(tc.input); got != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestManFlag verifies --man prints the page and stays out of the help output
+func TestManFlag(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	out, err := exec.Command(binaryPath, "--man").Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(string(out), ".TH ") {
+		t.Errorf("expected a roff manual page but got:\n%s", out)
+	}
+
+	cmd := exec.Command(binaryPath, "--help")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(stderr.String(), "-man") {
+		t.Errorf("did not expect the hidden --man flag in the help output")
+	}
+	if !strings.Contains(stderr.String(), "-completion") {
+		t.Errorf("expected visible flags in the help output")
+	}
+}
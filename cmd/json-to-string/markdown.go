@@ -0,0 +1,51 @@
+package main
+
+import "strings"
+
+// markdownFence wraps result in a fenced Markdown code block for --markdown. The
+// fence is one backtick longer than the longest run of backticks in result, and
+// at least three, so the content can never close it early.
+func markdownFence(result string, opts *options) string {
+	longest, run := 0, 0
+	for i := 0; i < len(result); i++ {
+		if result[i] != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+
+	nl := opts.newline()
+	return fence + fenceLanguage(opts) + nl + strings.TrimRight(result, "\r\n") + nl + fence
+}
+
+// fenceLanguage returns the info string of the --markdown code block, which
+// selects the syntax highlighting of the output
+func fenceLanguage(opts *options) string {
+	switch {
+	case opts.envName != "" || opts.quote != "":
+		return "sh"
+	case opts.hex && !opts.decode, opts.regexEscape, opts.dataURI:
+		return "text"
+	case opts.both:
+		if opts.bothJSON {
+			return "json"
+		}
+		return "text"
+	case opts.decode || opts.format || opts.asJSONStr || opts.asArray || opts.embedInto != "" || opts.escapeVals:
+		return "json"
+	case opts.toCSV:
+		return "csv"
+	case opts.toJSON5:
+		return "json5"
+	case opts.pointersJSON:
+		return "json"
+	case opts.lang != "":
+		return opts.lang
+	default:
+		// A bare escaped string or query string is not valid JSON on its own
+		return "text"
+	}
+}
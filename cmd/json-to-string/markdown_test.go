@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestMarkdownFence verifies the fence length and info string of --markdown
+func TestMarkdownFence(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		opts     options
+		expected string
+	}{
+		{
+			name:     "Escaped string",
+			result:   `{\"a\":1}`,
+			expected: "```text\n{\\\"a\\\":1}\n```",
+		},
+		{
+			name:     "Decoded JSON",
+			result:   "{\n  \"a\": 1\n}\n",
+			opts:     options{decode: true},
+			expected: "```json\n{\n  \"a\": 1\n}\n```",
+		},
+		{
+			name:     "Triple backticks lengthen the fence",
+			result:   "{\"doc\":\"```go\\n```\"}",
+			opts:     options{format: true},
+			expected: "````json\n{\"doc\":\"```go\\n```\"}\n````",
+		},
+		{
+			name:     "Longest run of backticks",
+			result:   "a ` b ````` c ``",
+			expected: "``````text\na ` b ````` c ``\n``````",
+		},
+		{
+			name:     "Language literal",
+			result:   `'{}'`,
+			opts:     options{lang: "sql"},
+			expected: "```sql\n'{}'\n```",
+		},
+		{
+			name:     "Shell assignment",
+			result:   `CONFIG='{}'`,
+			opts:     options{envName: "CONFIG", quote: "single"},
+			expected: "```sh\nCONFIG='{}'\n```",
+		},
+		{
+			name:     "CRLF line endings",
+			result:   "{}",
+			opts:     options{decode: true, eol: "crlf"},
+			expected: "```json\r\n{}\r\n```",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := markdownFence(tc.result, &tc.opts)
+			if result != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+// TestMarkdownFlag verifies --markdown with encode and the format command
+func TestMarkdownFlag(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	output, err := exec.Command(binaryPath, "--markdown", "--json", `{"doc":"`+"```"+`"}`).Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "````text\n{\\\"doc\\\":\\\"```\\\"}\n````\n"
+	if string(output) != expected {
+		t.Errorf("expected %q but got %q", expected, output)
+	}
+
+	output, err = exec.Command(binaryPath, "format", "--markdown", "--json", `{"a": 1}`).Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(string(output), "```json\n") || !strings.HasSuffix(string(output), "\n```\n") {
+		t.Errorf("expected a json code block but got %q", output)
+	}
+}
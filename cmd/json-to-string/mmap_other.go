@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+// mmapFile is not available on this platform; callers fall back to os.ReadFile
+func mmapFile(path string) ([]byte, func(), error) {
+	return nil, nil, errMmapUnsupported
+}
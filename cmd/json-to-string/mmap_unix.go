@@ -0,0 +1,43 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mmapFile maps the file at path read-only into memory
+func mmapFile(path string) ([]byte, func(), error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The mapping stays valid after the descriptor is closed
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		// Pipes and devices such as /dev/stdin are read with os.ReadFile instead
+		return nil, nil, fmt.Errorf("%s is not a regular file: %w", path, errMmapUnsupported)
+	}
+
+	size := info.Size()
+	if size == 0 {
+		// Zero-length mappings are rejected by the kernel
+		return []byte{}, func() {}, nil
+	}
+	if int64(int(size)) != size {
+		return nil, nil, fmt.Errorf("%s is too large to map", path)
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mapping %s: %w", path, err)
+	}
+	return data, func() { _ = syscall.Munmap(data) }, nil
+}
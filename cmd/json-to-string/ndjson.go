@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// followPollInterval is how often --follow checks the file for new data
+const followPollInterval = 200 * time.Millisecond
+
+// convertLines converts each non-blank line of input as a separate document and
+// joins the results with newlines. The first failing line is reported by number.
+func convertLines(input []byte, opts *options) (string, error) {
+	var results []string
+	for i, line := range bytes.Split(input, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		result, err := convertDocument(line, opts)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", i+1, err)
+		}
+		results = append(results, result)
+	}
+	return joinLines(results, opts.newline()), nil
+}
+
+// convertElements converts each element of a top-level JSON array as a separate
+// document for --explode, joining the results with newlines. Elements keep their
+// original bytes. Other input is an error unless --explode-passthrough is set, in
+// which case it is converted as a single document.
+func convertElements(input []byte, opts *options) (string, error) {
+	// Select the array first, so --pointer is not applied to each element again
+	input, err := transformInput(input, opts)
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+	elementOpts := opts.withoutTransforms()
+	elementOpts.documentPointer += opts.pointer
+
+	typ, err := jsonstr.TopLevelType(input)
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+	if typ != "array" {
+		if !opts.explodePass {
+			return "", fmt.Errorf("--explode requires a JSON array, got %s", typ)
+		}
+		return convertDocument(input, elementOpts)
+	}
+
+	var elements []json.RawMessage
+	if err := json.Unmarshal(input, &elements); err != nil {
+		return "", fmt.Errorf("encoding JSON: invalid JSON: %w", err)
+	}
+
+	results := make([]string, len(elements))
+	for i, element := range elements {
+		documentOpts := *elementOpts
+		documentOpts.documentPointer += fmt.Sprintf("/%d", i)
+		result, err := convertDocument(element, &documentOpts)
+		if err != nil {
+			return "", fmt.Errorf("element %d: %w", i, err)
+		}
+		results[i] = result
+	}
+	return joinLines(results, opts.newline()), nil
+}
+
+// convertEach converts each document in a top-level JSON array for --each. Elements
+// that are arrays themselves are groups of documents, converted in the same way at
+// any depth. The results are printed one per line, or with --as-array as a JSON
+// array of the same shape holding the escaped strings. Documents keep their
+// original bytes.
+func convertEach(input []byte, opts *options) (string, error) {
+	input, err := transformInput(input, opts)
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+
+	typ, err := jsonstr.TopLevelType(input)
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
+	}
+	if typ != "array" {
+		return "", fmt.Errorf("--each requires a JSON array, got %s", typ)
+	}
+
+	var results []string
+	elementOpts := opts.withoutTransforms()
+	elementOpts.documentPointer += opts.pointer
+	tree, err := convertEachElement(input, elementOpts, "", &results)
+	if err != nil {
+		return "", err
+	}
+	if !opts.asArray {
+		return joinLines(results, opts.newline()), nil
+	}
+
+	array, err := json.Marshal(tree)
+	if err != nil {
+		return "", fmt.Errorf("combining results: %w", err)
+	}
+	return string(array), nil
+}
+
+// convertEachElement converts a document, or each document in an array, appending
+// the results to results in order and returning them in the shape of the input.
+// location is the path of element indexes used in error messages.
+func convertEachElement(input []byte, opts *options, location string, results *[]string) (interface{}, error) {
+	typ, err := jsonstr.TopLevelType(input)
+	if err != nil {
+		return nil, fmt.Errorf("element %s: encoding JSON: %w", location, err)
+	}
+	if typ != "array" {
+		documentOpts := *opts
+		documentOpts.documentPointer += location
+		result, err := convertDocument(input, &documentOpts)
+		if err != nil {
+			return nil, fmt.Errorf("element %s: %w", location, err)
+		}
+		*results = append(*results, result)
+		return result, nil
+	}
+
+	var elements []json.RawMessage
+	if err := json.Unmarshal(input, &elements); err != nil {
+		return nil, fmt.Errorf("encoding JSON: invalid JSON: %w", err)
+	}
+	group := make([]interface{}, len(elements))
+	for i, element := range elements {
+		group[i], err = convertEachElement(element, opts, fmt.Sprintf("%s/%d", location, i), results)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return group, nil
+}
+
+// convertImploded combines the non-blank lines of NDJSON input into a single
+// array for --implode and converts that array. When decoding, each line is an
+// escaped JSON string and the array of unescaped values is printed as JSON.
+// Every record keeps its original bytes, so number precision is preserved.
+func convertImploded(input []byte, opts *options) (string, error) {
+	records := []json.RawMessage{}
+	for i, line := range bytes.Split(input, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		record, err := implodeRecord(line, opts)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", i+1, err)
+		}
+		records = append(records, record)
+	}
+
+	array, err := json.Marshal(records)
+	if err != nil {
+		return "", fmt.Errorf("combining records: %w", err)
+	}
+	if !opts.decode {
+		return convertDocument(array, opts)
+	}
+
+	if !opts.pretty {
+		return finishDecoded(string(array), opts), nil
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, array, "", opts.indent); err != nil {
+		return "", fmt.Errorf("formatting JSON: %w", err)
+	}
+	return finishDecoded(b.String(), opts), nil
+}
+
+// decodeElements decodes each element of a JSON array of escaped JSON strings
+// for --decode-elements, the inverse of --each --as-array on a flat array, and
+// returns the array of decoded values. The first failing element is reported by
+// its index.
+func decodeElements(input []byte, opts *options) (string, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(input, &elements); err != nil {
+		typ, typErr := jsonstr.TopLevelType(input)
+		if typErr != nil {
+			return "", fmt.Errorf("decoding elements: %w", typErr)
+		}
+		return "", fmt.Errorf("--decode-elements requires a JSON array of strings, got %s", typ)
+	}
+
+	// Each value is decoded compactly and the array is formatted as a whole
+	elementOpts := opts.encodeOptions()
+	elementOpts.Pretty = false
+	values := make([]json.RawMessage, len(elements))
+	for i, element := range elements {
+		var escaped string
+		if err := json.Unmarshal(element, &escaped); err != nil {
+			typ, _ := jsonstr.TopLevelType(element)
+			return "", fmt.Errorf("element %d: expected an escaped JSON string, got %s", i, typ)
+		}
+		decoded, err := jsonstr.DecodeWithOptions([]byte(escaped), elementOpts)
+		if err != nil {
+			return "", fmt.Errorf("element %d: decoding JSON string: %w", i, err)
+		}
+		if err := checkExpected([]byte(decoded), opts); err != nil {
+			return "", fmt.Errorf("element %d: decoding JSON string: %w", i, err)
+		}
+		values[i] = json.RawMessage(decoded)
+	}
+
+	array, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("combining values: %w", err)
+	}
+	if !opts.pretty {
+		return finishDecoded(string(array), opts), nil
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, array, "", opts.indent); err != nil {
+		return "", fmt.Errorf("formatting JSON: %w", err)
+	}
+	return finishDecoded(b.String(), opts), nil
+}
+
+// implodeRecord validates a single --implode line, unescaping it first when decoding
+func implodeRecord(line []byte, opts *options) (json.RawMessage, error) {
+	if opts.decode {
+		var unescaped string
+		quoted := append(append([]byte{'"'}, line...), '"')
+		if err := json.Unmarshal(quoted, &unescaped); err != nil {
+			return nil, fmt.Errorf("decoding JSON string: invalid JSON string: %w", err)
+		}
+		line = []byte(unescaped)
+	}
+
+	var record json.RawMessage
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if opts.strictKeys {
+		if err := jsonstr.CheckDuplicateKeys(record); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+// joinLines joins converted lines with the given line ending
+func joinLines(lines []string, eol string) string {
+	var b bytes.Buffer
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString(eol)
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// followFile converts lines from path as they are appended, in the manner of
+// tail -f. Processing starts at the beginning of the file. The output options,
+// such as --regex-escape and --fail-size, apply to each record. If the file is
+// truncated it is read again from the start, and if it is replaced (for example
+// by log rotation) the new file is opened. Invalid lines are reported on stderr
+// without stopping. The function returns once an interrupt or termination
+// signal is received, after flushing any buffered output.
+func followFile(path string, w io.Writer, opts *options) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+	defer func() { file.Close() }()
+
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	var pending []byte
+	lineNum := 0
+
+	for {
+		chunk, err := reader.ReadBytes('\n')
+		offset += int64(len(chunk))
+		pending = append(pending, chunk...)
+
+		if err == nil {
+			// A complete line is available
+			lineNum++
+			if line := bytes.TrimSpace(pending); len(line) > 0 {
+				result, convErr := convertDocument(line, opts)
+				if convErr == nil {
+					// Each record is a complete output, so the output options
+					// apply to it as they would to a single document
+					result, convErr = finishOutput(result, opts)
+				}
+				if convErr != nil {
+					fmt.Fprintf(os.Stderr, "Error line %d: %v\n", lineNum, convErr)
+				} else {
+					// Every record keeps its own line so the stream stays line-delimited
+					fmt.Fprint(out, result+opts.newline())
+				}
+			}
+			pending = pending[:0]
+			continue
+		}
+		if err != io.EOF {
+			return fmt.Errorf("reading file: %w", err)
+		}
+
+		// Reached the current end of the file; flush and wait for more data
+		if err := out.Flush(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(followPollInterval):
+		}
+
+		current, statErr := file.Stat()
+		if statErr != nil {
+			return fmt.Errorf("reading file: %w", statErr)
+		}
+		if current.Size() < offset {
+			// The file was truncated, start again from the beginning
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("reading file: %w", err)
+			}
+			reader.Reset(file)
+			offset, pending, lineNum = 0, pending[:0], 0
+			continue
+		}
+
+		if replaced, statErr := os.Stat(path); statErr == nil && !os.SameFile(current, replaced) && current.Size() == offset {
+			// The file was rotated and the old one fully read, switch to the new file
+			reopened, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			file.Close()
+			file = reopened
+			reader.Reset(file)
+			offset, pending, lineNum = 0, pending[:0], 0
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// envNamePattern matches valid environment variable names for --env-name
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// writeResult prints a result followed by exactly one line ending. Line endings
+// at the end of the result are dropped first, so the output never ends with a
+// blank line, and an empty result prints nothing. With --strip-final-newline no
+// line ending is added, while --raw prints the result exactly as it is.
+func writeResult(w io.Writer, result string, opts *options) {
+	if opts.rawOutput {
+		fmt.Fprint(w, result)
+		return
+	}
+	result = strings.TrimRight(result, "\r\n")
+	if result == "" || opts.stripFinalNL {
+		fmt.Fprint(w, result)
+		return
+	}
+	fmt.Fprint(w, result+opts.newline())
+}
+
+// openOutput creates the --output file, or returns stdout when there is none,
+// with the function that closes it
+func openOutput(opts *options) (io.Writer, func() error, error) {
+	if opts.outputFile == "" {
+		return os.Stdout, func() error { return nil }, nil
+	}
+	f, err := os.Create(opts.outputFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("writing output: %w", err)
+	}
+	return f, f.Close, nil
+}
+
+// writeOutput writes the final output with writeResult to stdout or the --output
+// file, compressed with --gzip. The file is only created once the conversion has
+// succeeded, so a failed conversion leaves an existing file as it was.
+func writeOutput(output string, opts *options) error {
+	w, closeOutput, err := openOutput(opts)
+	if err != nil {
+		return err
+	}
+	write := func(w io.Writer) error {
+		writeResult(w, output, opts)
+		return nil
+	}
+	if opts.gzip {
+		err = jsonstr.WriteGzip(w, write)
+	} else {
+		err = write(w)
+	}
+	if closeErr := closeOutput(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
+
+// newline returns the line ending selected with --eol
+func (o *options) newline() string {
+	if o.eol == "crlf" {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// finishDecoded applies the decode-only output options to decoded JSON
+func finishDecoded(decoded string, opts *options) string {
+	if opts.unicode == "escaped" {
+		decoded = jsonstr.EscapeNonASCII(decoded)
+	}
+	if opts.lineNumbers {
+		decoded = numberLines(decoded)
+	}
+	return convertNewlines(decoded, opts)
+}
+
+// numberLines prefixes each line of formatted JSON with its line number for
+// --line-numbers, right-aligned in a gutter as wide as the largest number
+func numberLines(formatted string) string {
+	lines := strings.Split(formatted, "\n")
+	width := len(strconv.Itoa(len(lines)))
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%*d | %s", width, i+1, line)
+	}
+	return b.String()
+}
+
+// convertNewlines replaces the structural newlines of formatted JSON with the
+// line ending selected with --eol. Newlines inside string values are always
+// escaped as \n, so only the layout is affected.
+func convertNewlines(formatted string, opts *options) string {
+	if opts.eol != "crlf" {
+		return formatted
+	}
+	return strings.ReplaceAll(formatted, "\n", "\r\n")
+}
+
+// finishOutput applies output-stage options to a converted result
+func finishOutput(result string, opts *options) (string, error) {
+	if opts.regexEscape {
+		// Before transcoding, as multibyte charsets such as Shift_JIS can have
+		// bytes that look like regex metacharacters inside a character
+		result = regexp.QuoteMeta(result)
+	}
+
+	result, err := encodeCharset(result, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.hex && !opts.decode {
+		result = hex.EncodeToString([]byte(result))
+	}
+	if opts.dataURI {
+		result = dataURI(result, opts)
+	}
+
+	switch opts.quote {
+	case "single":
+		result = singleQuote(result)
+	case "double":
+		result = doubleQuote(result)
+	}
+
+	switch {
+	case opts.export:
+		result = "export " + opts.envName + "=" + singleQuote(result)
+	case opts.envName != "":
+		result = opts.envName + "=" + result
+	}
+
+	if opts.pad > 0 {
+		if result, err = padOutput(result, opts); err != nil {
+			return "", err
+		}
+	}
+
+	if opts.markdown {
+		result = markdownFence(result, opts)
+	}
+
+	if err := checkSize(result, opts); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// checkSize enforces the --warn-size and --fail-size thresholds on the final output
+func checkSize(result string, opts *options) error {
+	size := len(result)
+	if opts.failSize > 0 && size > opts.failSize {
+		return fmt.Errorf("output is %d bytes, exceeding --fail-size of %d bytes", size, opts.failSize)
+	}
+	if opts.warnSize > 0 && size > opts.warnSize && !opts.quiet {
+		fmt.Fprintf(os.Stderr, "Warning: output is %d bytes, exceeding --warn-size of %d bytes\n", size, opts.warnSize)
+	}
+	return nil
+}
+
+// singleQuote wraps s in single quotes for POSIX shells. Nothing is special
+// inside single quotes, so an embedded quote closes the string, adds an
+// escaped quote and reopens it.
+func singleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// doubleQuote wraps s in double quotes for POSIX shells, escaping the
+// characters that remain special inside them
+func doubleQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\', '"', '$', '`':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// padOutput pads the output with spaces to exactly --pad characters for
+// fixed-width records, after it with --pad or before it with --pad-left. Line
+// endings at the end of the output are not part of the record and are dropped.
+func padOutput(result string, opts *options) (string, error) {
+	result = strings.TrimRight(result, "\r\n")
+	length := outputLength(result, opts)
+	if length > opts.pad {
+		return "", fmt.Errorf("output is %d characters, exceeding --pad of %d characters", length, opts.pad)
+	}
+	padding := strings.Repeat(" ", opts.pad-length)
+	if opts.padLeft {
+		return padding + result, nil
+	}
+	return result + padding, nil
+}
+
+// outputLength returns the number of characters in the output: Unicode
+// characters in UTF-8 output, and bytes in another --output-charset, as the
+// fixed-width systems using one count them
+func outputLength(result string, opts *options) int {
+	if enc, _ := lookupCharset(opts.outputCharset); enc != nil {
+		return len(result)
+	}
+	return utf8.RuneCountInString(result)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+)
+
+// TestPadOutput verifies that --pad pads the output to exactly the given width
+func TestPadOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      string
+		opts        options
+		expected    string
+		expectError bool
+	}{
+		{name: "Shorter output", result: `{\"a\":1}`, opts: options{pad: 12}, expected: `{\"a\":1}   `},
+		{name: "Exact length", result: `{\"a\":1}`, opts: options{pad: 9}, expected: `{\"a\":1}`},
+		{name: "Over length", result: `{\"a\":1}`, opts: options{pad: 8}, expectError: true},
+		{name: "Left padding", result: `{\"a\":1}`, opts: options{pad: 11, padLeft: true}, expected: `  {\"a\":1}`},
+		{name: "Characters rather than bytes", result: `\"日本\"`, opts: options{pad: 7}, expected: `\"日本\" `},
+		{name: "Bytes in another charset", result: "\"\xe9\"", opts: options{pad: 4, outputCharset: "latin1"}, expected: "\"\xe9\" "},
+		{name: "Trailing line endings are dropped", result: "{\n  \"a\": 1\n}\n", opts: options{pad: 13}, expected: "{\n  \"a\": 1\n} "},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := padOutput(tc.result, &tc.opts)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+// TestPadFlag verifies the padded output of the binary, which the trimming of
+// TestCLI would hide
+func TestPadFlag(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	output, err := exec.Command(binaryPath, "--pad", "12", "--json", `{"a":1}`).Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `{\"a\":1}   ` + "\n"; string(output) != expected {
+		t.Errorf("expected %q but got %q", expected, output)
+	}
+
+	cmd := exec.Command(binaryPath, "--pad", "5", "--json", `{"a":1}`)
+	output, err = cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected an error for output longer than --pad but got %q", output)
+	}
+	if expected := "Error output is 9 characters, exceeding --pad of 5 characters\n"; string(output) != expected {
+		t.Errorf("expected %q but got %q", expected, output)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// listPointers writes the leaves of a JSON document for --pointers, one
+// "<pointer>\t<value>" line each with the value as compact JSON. With
+// --pointers-json they are the members of an indented JSON object instead,
+// in the same order.
+func listPointers(input []byte, opts *options) (string, error) {
+	data, err := jsonstr.Parse(input)
+	if err != nil {
+		return "", err
+	}
+
+	leaves := jsonstr.Leaves(data)
+	lines := make([]string, len(leaves))
+	for i, leaf := range leaves {
+		value, err := marshalUnescaped(leaf.Value)
+		if err != nil {
+			return "", err
+		}
+		if !opts.pointersJSON {
+			lines[i] = leaf.Pointer + "\t" + string(value)
+			continue
+		}
+		pointer, err := marshalUnescaped(leaf.Pointer)
+		if err != nil {
+			return "", err
+		}
+		lines[i] = opts.indent + string(pointer) + ": " + string(value)
+	}
+
+	if !opts.pointersJSON {
+		return convertNewlines(strings.Join(lines, "\n"), opts), nil
+	}
+	if len(lines) == 0 {
+		return "{}", nil
+	}
+	return convertNewlines("{\n"+strings.Join(lines, ",\n")+"\n}", opts), nil
+}
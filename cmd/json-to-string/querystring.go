@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// queryKeySeparator joins the keys of nested objects in query strings
+const queryKeySeparator = "."
+
+// toQueryString writes a JSON object as a URL query string for --querystring.
+// Nested keys are joined with dots and arrays become repeated keys, so
+// {"a":{"b":1},"c":[1,2]} becomes a.b=1&c=1&c=2. Keys are sorted, and keys and
+// values are percent-encoded by net/url.
+func toQueryString(input []byte) (string, error) {
+	data, err := jsonstr.Parse(input)
+	if err != nil {
+		return "", err
+	}
+	flat, err := jsonstr.Flatten(data, queryKeySeparator)
+	if err != nil {
+		return "", err
+	}
+
+	values := url.Values{}
+	for key, value := range flat {
+		if array, ok := value.([]interface{}); ok {
+			for _, element := range array {
+				values.Add(key, queryValue(element))
+			}
+			continue
+		}
+		values.Add(key, queryValue(value))
+	}
+	return values.Encode(), nil
+}
+
+// queryValue returns the query string form of a scalar. Strings are written
+// without quotes and null as an empty value.
+func queryValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// fromQueryString reads a URL query string as a JSON object for
+// --from-querystring, the inverse of --querystring. Dotted keys become nested
+// objects and repeated keys become arrays, so a.b=1&c=1&c=2 becomes
+// {"a":{"b":"1"},"c":["1","2"]}. Values are always strings. A leading '?' and
+// surrounding whitespace are ignored.
+func fromQueryString(input []byte) ([]byte, error) {
+	query := strings.TrimPrefix(strings.TrimSpace(string(input)), "?")
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query string: %w", err)
+	}
+
+	flat := make(map[string]interface{}, len(values))
+	for key, list := range values {
+		if len(list) == 1 {
+			flat[key] = list[0]
+			continue
+		}
+		array := make([]interface{}, len(list))
+		for i, value := range list {
+			array[i] = value
+		}
+		flat[key] = array
+	}
+
+	object, err := jsonstr.Unflatten(flat, queryKeySeparator)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
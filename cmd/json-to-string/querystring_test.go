@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestToQueryString verifies the flattening and escaping rules of --querystring
+func TestToQueryString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		errorMsg string
+	}{
+		{name: "Flat object", input: `{"b":2,"a":"x"}`, expected: "a=x&b=2"},
+		{name: "Nested keys", input: `{"filter":{"status":"open","owner":{"id":7}}}`, expected: "filter.owner.id=7&filter.status=open"},
+		{name: "Arrays become repeated keys", input: `{"id":[3,1,2]}`, expected: "id=3&id=1&id=2"},
+		{name: "Empty array and object are omitted", input: `{"a":[],"b":{},"c":1}`, expected: "c=1"},
+		{name: "Scalars", input: `{"t":true,"f":false,"n":null,"x":1.50}`, expected: "f=false&n=&t=true&x=1.50"},
+		{name: "Percent-encoding", input: `{"q":"a b&c=d/é","k y":"+"}`, expected: "k+y=%2B&q=a+b%26c%3Dd%2F%C3%A9"},
+		{name: "Empty object", input: `{}`, expected: ""},
+		{name: "Top-level array", input: `[1,2]`, errorMsg: "top-level value must be an object"},
+		{name: "Top-level string", input: `"a=1"`, errorMsg: "top-level value must be an object"},
+		{name: "Array of objects", input: `{"a":[{"b":1}]}`, errorMsg: "cannot flatten /a/0: arrays can only hold scalars"},
+		{name: "Invalid JSON", input: `{"a":`, errorMsg: "invalid JSON"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := toQueryString([]byte(tc.input))
+			if tc.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+					t.Errorf("expected error containing %q but got %v", tc.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+// TestFromQueryString verifies how --from-querystring builds a JSON object
+func TestFromQueryString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		errorMsg string
+	}{
+		{name: "Single values", input: "b=2&a=x", expected: `{"a":"x","b":"2"}`},
+		{name: "Repeated keys become arrays", input: "id=3&id=1&id=2", expected: `{"id":["3","1","2"]}`},
+		{name: "Dotted keys", input: "filter.status=open&filter.owner.id=7", expected: `{"filter":{"owner":{"id":"7"},"status":"open"}}`},
+		{name: "Percent-encoded values", input: "q=a+b%26c%3Dd%2F%C3%A9&k%20y=%2B", expected: `{"k y":"+","q":"a b\u0026c=d/é"}`},
+		{name: "Leading question mark and whitespace", input: " ?a=1\n", expected: `{"a":"1"}`},
+		{name: "Key without a value", input: "flag&a=", expected: `{"a":"","flag":""}`},
+		{name: "Empty input", input: "", expected: `{}`},
+		{name: "Invalid escape", input: "a=%zz", errorMsg: "invalid query string"},
+		{name: "Value and parent", input: "a=1&a.b=2", errorMsg: `key "a" is both a value and the parent`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := fromQueryString([]byte(tc.input))
+			if tc.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+					t.Errorf("expected error containing %q but got %v", tc.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+
+	// Reading a query string back gives the same query string
+	query := "a.b=1&c=x+y&c=%2F"
+	document, err := fromQueryString([]byte(query))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := toQueryString(document)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != query {
+		t.Errorf("expected %q after a roundtrip but got %q", query, result)
+	}
+}
+
+// TestQueryStringFlag verifies --querystring and --from-querystring on the command line
+func TestQueryStringFlag(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	output, err := exec.Command(binaryPath, "--querystring", "--pointer", "/params", "--json", `{"params":{"q":"x y","page":2}}`).Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(output) != "page=2&q=x+y\n" {
+		t.Errorf("expected a query string but got %q", output)
+	}
+
+	output, err = exec.Command(binaryPath, "encode", "--from-querystring", "--json", "tag=a&tag=b%20c").Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(output) != `{\"tag\":[\"a\",\"b c\"]}`+"\n" {
+		t.Errorf("expected the query string encoded as JSON but got %q", output)
+	}
+
+	if err := exec.Command(binaryPath, "--from-querystring", "--decode", "--json", "a=1").Run(); err == nil {
+		t.Errorf("expected --from-querystring with --decode to fail")
+	}
+	if err := exec.Command(binaryPath, "--querystring", "--lang", "c", "--json", `{}`).Run(); err == nil {
+		t.Errorf("expected --querystring with --lang to fail")
+	}
+}
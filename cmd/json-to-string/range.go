@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// rangeFlags lists the flags that --range can be combined with. The bytes of
+// the range are escaped as raw text, so only the input and output options
+// apply, and none of the options that parse, check or reformat JSON.
+var rangeFlags = map[string]bool{
+	"range":               true,
+	"file":                true,
+	"json":                true,
+	"env":                 true,
+	"fd":                  true,
+	"mmap":                true,
+	"stdin-timeout":       true,
+	"as-json-string":      true,
+	"raw":                 true,
+	"strip-final-newline": true,
+	"eol":                 true,
+	"output-charset":      true,
+	"on-unmappable":       true,
+	"regex-escape":        true,
+	"hex":                 true,
+	"data-uri":            true,
+	"data-uri-plain":      true,
+	"quote":               true,
+	"env-name":            true,
+	"export":              true,
+	"markdown":            true,
+	"pad":                 true,
+	"pad-left":            true,
+	"warn-size":           true,
+	"fail-size":           true,
+	"output":              true,
+	"gzip":                true,
+	"quiet":               true,
+}
+
+// validateRange checks the syntax of --range and that only the flags in
+// rangeFlags are given with it
+func validateRange(opts *options) error {
+	if len(opts.files) > 0 {
+		return fmt.Errorf("--range cannot be used with batch files")
+	}
+	if _, _, err := parseRange(opts.byteRange); err != nil {
+		return err
+	}
+	return checkAllowedFlags(opts.flags, "--range", rangeFlags)
+}
+
+// parseRange parses a --range value of the form <start>:<end>. Either bound may
+// be left out, for the start or the end of the input, which end is then -1.
+func parseRange(value string) (start, end int, err error) {
+	first, last, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid --range %q: expected <start>:<end>", value)
+	}
+	start, end = 0, -1
+	if first != "" {
+		if start, err = strconv.Atoi(first); err != nil || start < 0 {
+			return 0, 0, fmt.Errorf("invalid --range start %q: must be a non-negative byte offset", first)
+		}
+	}
+	if last != "" {
+		if end, err = strconv.Atoi(last); err != nil || end < 0 {
+			return 0, 0, fmt.Errorf("invalid --range end %q: must be a non-negative byte offset", last)
+		}
+		if end < start {
+			return 0, 0, fmt.Errorf("invalid --range %q: the end is before the start", value)
+		}
+	}
+	return start, end, nil
+}
+
+// escapeRange escapes the bytes [start:end) of the input as raw text for
+// --range, without checking that they are JSON, and applies the output options.
+// A range that splits a multibyte UTF-8 character is an error rather than
+// leaving half of it to be written as U+FFFD.
+func escapeRange(input []byte, opts *options) (string, error) {
+	start, end, err := parseRange(opts.byteRange)
+	if err != nil {
+		return "", err
+	}
+	if end < 0 {
+		end = len(input)
+	}
+	if end > len(input) {
+		return "", fmt.Errorf("--range %s is out of bounds for an input of %d bytes", opts.byteRange, len(input))
+	}
+	if start > end {
+		return "", fmt.Errorf("--range %s starts after the end of an input of %d bytes", opts.byteRange, len(input))
+	}
+
+	// Only offsets that keep the range non-empty are suggested
+	if first, size, ok := splitCharacter(input, start); ok {
+		suggestion := strconv.Itoa(first)
+		if first+size < end {
+			suggestion += " or " + strconv.Itoa(first+size)
+		}
+		return "", fmt.Errorf("--range %s starts inside the %d-byte UTF-8 character that starts at byte %d, use a start of %s instead",
+			opts.byteRange, size, first, suggestion)
+	}
+	if first, size, ok := splitCharacter(input, end); ok {
+		suggestion := strconv.Itoa(first + size)
+		if first > start {
+			suggestion = strconv.Itoa(first) + " or " + suggestion
+		}
+		return "", fmt.Errorf("--range %s ends inside the %d-byte UTF-8 character that starts at byte %d, use an end of %s instead",
+			opts.byteRange, size, first, suggestion)
+	}
+
+	escaped := jsonstr.EscapeRaw(input[start:end])
+	if opts.asJSONStr {
+		escaped = `"` + escaped + `"`
+	}
+	return finishOutput(escaped, opts)
+}
+
+// splitCharacter reports whether offset falls inside a valid multibyte UTF-8
+// character of input, returning the offset and size of that character. Bytes
+// that are not valid UTF-8 are not characters, so they are never split.
+func splitCharacter(input []byte, offset int) (first, size int, ok bool) {
+	for i := offset - 1; i >= 0 && i > offset-utf8.UTFMax; i-- {
+		if utf8.RuneStart(input[i]) {
+			_, size = utf8.DecodeRune(input[i:])
+			return i, size, i+size > offset
+		}
+	}
+	return 0, 0, false
+}
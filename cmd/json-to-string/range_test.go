@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestEscapeRange verifies the bytes escaped by --range and the errors for
+// ranges outside the input
+func TestEscapeRange(t *testing.T) {
+	input := []byte(`{"a": [1, "é"]}`)
+
+	tests := []struct {
+		name      string
+		byteRange string
+		expected  string
+		err       string
+	}{
+		{name: "Middle of the input", byteRange: "6:14", expected: `[1, \"é\"`},
+		{name: "From the start", byteRange: ":4", expected: `{\"a\"`},
+		{name: "To the end", byteRange: "13:", expected: `\"]}`},
+		{name: "Whole input", byteRange: ":", expected: `{\"a\": [1, \"é\"]}`},
+		{name: "Empty range", byteRange: "3:3", expected: ``},
+		{name: "End splits a character", byteRange: "10:12", err: "--range 10:12 ends inside the 2-byte UTF-8 character that starts at byte 11, use an end of 11 or 13 instead"},
+		{name: "End splits the only character", byteRange: "11:12", err: "--range 11:12 ends inside the 2-byte UTF-8 character that starts at byte 11, use an end of 13 instead"},
+		{name: "Start splits a character", byteRange: "12:", err: "--range 12: starts inside the 2-byte UTF-8 character that starts at byte 11, use a start of 11 or 13 instead"},
+		{name: "Start splits the last character", byteRange: "12:13", err: "--range 12:13 starts inside the 2-byte UTF-8 character that starts at byte 11, use a start of 11 instead"},
+		{name: "Range around a character", byteRange: "11:13", expected: "é"},
+		{name: "End out of bounds", byteRange: "2:17", err: "--range 2:17 is out of bounds for an input of 16 bytes"},
+		{name: "Start out of bounds", byteRange: "20:", err: "--range 20: starts after the end of an input of 16 bytes"},
+		{name: "Missing separator", byteRange: "5", err: `invalid --range "5": expected <start>:<end>`},
+		{name: "Negative start", byteRange: "-1:4", err: `invalid --range start "-1": must be a non-negative byte offset`},
+		{name: "End before the start", byteRange: "4:2", err: `invalid --range "4:2": the end is before the start`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := escapeRange(input, &options{byteRange: tc.byteRange})
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Errorf("expected error %q but got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+// TestEscapeRangeInvalidUTF8 verifies that bytes of the input that are not
+// valid UTF-8 are escaped as U+FFFD rather than reported as a split character
+func TestEscapeRangeInvalidUTF8(t *testing.T) {
+	input := []byte("\"a\xc3\x28\xe2\x82\"")
+
+	tests := []struct {
+		name      string
+		byteRange string
+		expected  string
+	}{
+		{name: "Invalid lead byte", byteRange: "2:4", expected: "\ufffd("},
+		{name: "Truncated sequence", byteRange: "4:6", expected: "\ufffd\ufffd"},
+		{name: "After a truncated sequence", byteRange: "5:", expected: "\ufffd\\\""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := escapeRange(input, &options{byteRange: tc.byteRange})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+// TestRangeFlagOutOfBounds verifies the error printed for a range past the end
+// of the input
+func TestRangeFlagOutOfBounds(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	output, err := exec.Command(binaryPath, "--range", "2:50", "--json", `{"a": 1}`).CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected an error but got %q", output)
+	}
+	if !strings.Contains(string(output), "--range 2:50 is out of bounds for an input of 8 bytes") {
+		t.Errorf("expected an out-of-bounds error but got %q", output)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// repeatConversion runs the conversion opts.repeat times for the hidden --repeat
+// flag and writes the timings to w. Each run starts from the raw input: files
+// are read again every time, while other input, which can only be read once, is
+// reused. Only the conversion is timed and repeated. The runs before the last
+// are quiet, so warnings such as those of --detect-secrets are written once, and
+// the output options, including the --warn-size and --fail-size checks, are
+// applied once to the result of the last run, which is returned for printing.
+func repeatConversion(w io.Writer, input []byte, opts *options) (string, error) {
+	quiet := *opts
+	quiet.quiet = true
+
+	var result string
+	var header []byte
+	start := time.Now()
+	for i := 0; i < opts.repeat; i++ {
+		runOpts := &quiet
+		if i == opts.repeat-1 {
+			runOpts = opts
+		}
+		var err error
+		if result, header, err = repeatRun(input, runOpts); err != nil {
+			return "", err
+		}
+	}
+	total := time.Since(start)
+
+	fmt.Fprintf(w, "Runs: %d, total: %v, per run: %v\n", opts.repeat, total, total/time.Duration(opts.repeat))
+
+	output, err := finishOutput(result, opts)
+	if err != nil {
+		return "", err
+	}
+	return attachHeader(header, output), nil
+}
+
+// repeatRun converts the input once for repeatConversion, reading the input
+// file again when there is one, and returns the result before the output
+// options together with the header set aside by --preserve-header
+func repeatRun(input []byte, opts *options) (string, []byte, error) {
+	if opts.inputFile != "" {
+		data, release, err := readFile(opts.inputFile, opts.useMmap)
+		if err != nil {
+			return "", nil, fmt.Errorf("reading file: %w", err)
+		}
+		defer release()
+		input = data
+	}
+
+	// --comments-to is rejected with --repeat, so there are no comments to keep
+	prepared, header, _, err := prepareInput(input, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	result, err := convert(prepared, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	return result, header, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// replHelp lists the commands understood by --repl
+const replHelp = `Enter JSON to encode it, or escaped JSON after :decode. Commands:
+  :decode   toggle decoding instead of encoding
+  :compact  toggle removing whitespace (turns off :pretty)
+  :pretty   toggle pretty-printing (turns off :compact)
+  :options  show the current settings
+  :help     show this help
+  :quit     exit (as does end of input)`
+
+// maxREPLLine is the longest line --repl accepts
+const maxREPLLine = 16 << 20
+
+// runREPL reads lines from in and writes each one converted to out, keeping the
+// settings changed by commands between lines. A prompt is shown before each
+// line when prompt is set, as it is for an interactive terminal.
+func runREPL(in io.Reader, out io.Writer, opts *options, prompt bool) error {
+	state := *opts
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxREPLLine)
+
+	for {
+		if prompt {
+			fmt.Fprint(out, replPrompt(&state))
+		}
+		if !scanner.Scan() {
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case line == ":quit" || line == ":q" || line == ":exit":
+			return nil
+		case strings.HasPrefix(line, ":"):
+			replCommand(out, line, &state)
+			continue
+		}
+
+		result, err := convertInput([]byte(line), &state)
+		if err != nil {
+			fmt.Fprintf(out, "Error %v\n", err)
+			continue
+		}
+		fmt.Fprintln(out, result)
+	}
+	if prompt {
+		fmt.Fprintln(out)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading input: %w", err)
+	}
+	return nil
+}
+
+// replPrompt returns the prompt for the next line, which shows the direction
+func replPrompt(state *options) string {
+	if state.decode {
+		return "decode> "
+	}
+	return "encode> "
+}
+
+// replCommand runs a REPL command. A toggle that leads to an invalid
+// combination of options is undone and the reason reported.
+func replCommand(out io.Writer, line string, state *options) {
+	previous := *state
+	switch line {
+	case ":decode":
+		state.decode = !state.decode
+	case ":compact":
+		state.compact = !state.compact
+		state.pretty = false
+	case ":pretty":
+		state.pretty = !state.pretty
+		state.compact = false
+	case ":options":
+	case ":help":
+		fmt.Fprintln(out, replHelp)
+		return
+	default:
+		fmt.Fprintf(out, "Unknown command %s (type :help for the list)\n", line)
+		return
+	}
+
+	if err := validateOptions(state); err != nil {
+		*state = previous
+		fmt.Fprintf(out, "Error %v\n", err)
+		return
+	}
+	fmt.Fprintf(out, "decode: %s, compact: %s, pretty: %s\n", onOff(state.decode), onOff(state.compact), onOff(state.pretty))
+}
+
+// onOff describes a boolean setting
+func onOff(value bool) string {
+	if value {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestREPL verifies the lines and commands of --repl sessions
+func TestREPL(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		prompt   bool
+		expected string
+	}{
+		{
+			name:     "Each line is encoded",
+			args:     []string{"--repl"},
+			input:    "{\"a\": 1}\n\n[true]\n",
+			expected: "{\\\"a\\\": 1}\n[true]\n",
+		},
+		{
+			name:  "Toggles are kept between lines",
+			args:  []string{"--repl"},
+			input: "{\"a\": [1]}\n:compact\n{\"a\": [1]}\n:compact\n:decode\n{\\\"a\\\":1}\n",
+			expected: "{\\\"a\\\": [1]}\n" +
+				"decode: off, compact: on, pretty: off\n" +
+				"{\\\"a\\\":[1]}\n" +
+				"decode: off, compact: off, pretty: off\n" +
+				"decode: on, compact: off, pretty: off\n" +
+				"{\"a\":1}\n",
+		},
+		{
+			name:     "Pretty turns off compact",
+			args:     []string{"--repl", "--compact"},
+			input:    ":pretty\n{\"a\":1}\n",
+			expected: "decode: off, compact: off, pretty: on\n{\\n  \\\"a\\\": 1\\n}\n",
+		},
+		{
+			name:     "Decode subcommand starts decoding",
+			args:     []string{"decode", "--repl"},
+			input:    "{\\\"a\\\":1}\n",
+			expected: "{\"a\":1}\n",
+		},
+		{
+			name:     "Errors do not end the session",
+			args:     []string{"--repl"},
+			input:    "{\"a\":\n:bogus\n[]\n",
+			expected: "Error encoding JSON: invalid JSON: unexpected end of JSON input\nUnknown command :bogus (type :help for the list)\n[]\n",
+		},
+		{
+			name:     "Invalid toggles are undone",
+			args:     []string{"--repl", "--strip-ws"},
+			input:    ":compact\n{\"a\": 1}\n",
+			expected: "Error --strip-ws cannot be used with --compact, --pretty or --decode\n{\\\"a\\\":1}\n",
+		},
+		{
+			name:     "Quit ignores the remaining lines",
+			args:     []string{"--repl"},
+			input:    "[1]\n:quit\n[2]\n",
+			expected: "[1]\n",
+		},
+		{
+			name:     "Prompts show the direction",
+			args:     []string{"--repl"},
+			input:    "[1]\n:decode\n",
+			prompt:   true,
+			expected: "encode> [1]\nencode> decode: on, compact: off, pretty: off\ndecode> \n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := parseArgs(tc.args)
+			if err := validateOptions(opts); err != nil {
+				t.Fatalf("invalid options: %v", err)
+			}
+			var out bytes.Buffer
+			if err := runREPL(strings.NewReader(tc.input), &out, opts, tc.prompt); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, out.String())
+			}
+		})
+	}
+}
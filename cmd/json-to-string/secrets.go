@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// checkSecrets writes a warning to w for each string value of the input that
+// looks like a credential, for --detect-secrets. With --redact-secrets those
+// parts of the values are replaced and the input is marshaled again as by the
+// transforms, otherwise it is returned unchanged. The warnings locate values in
+// the whole input, so they include the --pointer and the index of an --explode
+// or --each element.
+func checkSecrets(w io.Writer, input []byte, opts *options) ([]byte, error) {
+	data, err := jsonstr.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	secrets := jsonstr.DetectSecrets(data)
+	if !opts.quiet {
+		for _, secret := range secrets {
+			location := opts.documentPointer + opts.pointer + secret.Pointer
+			if location == "" {
+				location = "the top level"
+			}
+			action := "use --redact-secrets to remove it"
+			if opts.redactSecrets {
+				action = "redacted"
+			}
+			fmt.Fprintf(w, "Warning: possible %s at %s (%s)\n", secret.Kind, location, action)
+		}
+	}
+	if len(secrets) == 0 || !opts.redactSecrets {
+		return input, nil
+	}
+	return marshalTransformed(jsonstr.RedactSecrets(data), opts)
+}
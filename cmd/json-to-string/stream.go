@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// streamFlags lists the flags that --stream can be combined with. Streaming
+// escapes the input text as it is read, so any option that needs the whole
+// document, or changes its text, cannot be used.
+var streamFlags = map[string]bool{
+	"stream":              true,
+	"file":                true,
+	"raw":                 true,
+	"strip-final-newline": true,
+	"eol":                 true,
+	"quiet":               true,
+	"output":              true,
+	"gzip":                true,
+}
+
+// decodeStreamFlags lists the flags that --stream can be combined with when
+// decoding. Each line is decoded on its own, so the options that format a decoded
+// document can be used, while those that need the whole input cannot.
+var decodeStreamFlags = map[string]bool{
+	"stream":              true,
+	"decode":              true,
+	"file":                true,
+	"pretty":              true,
+	"indent":              true,
+	"indent-width":        true,
+	"wrap":                true,
+	"align":               true,
+	"compact-threshold":   true,
+	"pretty-depth":        true,
+	"indent-json-strings": true,
+	"strict-keys":         true,
+	"expect":              true,
+	"unicode":             true,
+	"eol":                 true,
+	"quiet":               true,
+	"output":              true,
+	"gzip":                true,
+}
+
+// validateStream checks that only the flags in streamFlags are given with --stream
+func validateStream(opts *options) error {
+	if len(opts.files) > 0 {
+		return fmt.Errorf("--stream cannot be used with batch files")
+	}
+	if opts.outputFile != "" && opts.inputFile != "" && filepath.Clean(opts.outputFile) == filepath.Clean(opts.inputFile) {
+		// The output file is truncated before the input has been read
+		return fmt.Errorf("--stream cannot write its --output to the --file it is reading")
+	}
+	if opts.decode {
+		return checkAllowedFlags(opts.flags, "--stream with --decode", decodeStreamFlags)
+	}
+	return checkAllowedFlags(opts.flags, "--stream", streamFlags)
+}
+
+// openStream opens --file or stdin for --stream, with the function that closes it
+func openStream(opts *options) (*bufio.Reader, func(), error) {
+	var r io.Reader = os.Stdin
+	closeInput := func() {}
+	if opts.inputFile != "" {
+		f, err := os.Open(opts.inputFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading file: %w", err)
+		}
+		r = f
+		closeInput = func() { f.Close() }
+	} else if !stdinHasInput() {
+		return nil, nil, fmt.Errorf("--stream requires --file or input on stdin")
+	}
+
+	// Like readFile, decompress gzip input detected by its magic bytes or extension
+	buffered := bufio.NewReader(r)
+	if magic, _ := buffered.Peek(2); isGzip(magic) || strings.HasSuffix(opts.inputFile, ".gz") {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			closeInput()
+			return nil, nil, fmt.Errorf("decompressing %s: %w", opts.inputFile, err)
+		}
+		closeFile := closeInput
+		closeInput = func() {
+			gz.Close()
+			closeFile()
+		}
+		buffered = bufio.NewReader(gz)
+	}
+	return buffered, closeInput, nil
+}
+
+// encodeStream escapes --file or stdin to w while it is being read, for --stream
+func encodeStream(w io.Writer, opts *options) error {
+	r, closeInput, err := openStream(opts)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	if err := jsonstr.EncodeStream(w, r); err != nil {
+		return fmt.Errorf("encoding JSON: %w", err)
+	}
+	if !opts.rawOutput && !opts.stripFinalNL {
+		fmt.Fprint(w, opts.newline())
+	}
+	return nil
+}
+
+// decodeStream decodes each line of --file or stdin as a separate escaped string
+// for --stream with --decode, writing each result to w as soon as its line has
+// been read. Blank lines are skipped, and the first line that fails to decode is
+// reported by number after the results of the lines before it.
+func decodeStream(w io.Writer, opts *options) error {
+	r, closeInput, err := openStream(opts)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	for number := 1; ; number++ {
+		line, readErr := r.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return fmt.Errorf("reading input: %w", readErr)
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			result, err := convertDocument(line, opts)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", number, err)
+			}
+			if _, err := io.WriteString(w, strings.TrimRight(result, "\r\n")+opts.newline()); err != nil {
+				return fmt.Errorf("writing output: %w", err)
+			}
+		}
+		if readErr != nil {
+			return nil
+		}
+	}
+}
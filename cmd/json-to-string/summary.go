@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// writeSummary writes the one-line --summary digest of a conversion to w, such as
+// "encoded object, 3 keys, 128→152 bytes, +24 escapes". input is the JSON or
+// escaped text that was converted, converted the result before the output-stage
+// options and output the final output.
+func writeSummary(w io.Writer, input []byte, converted, output string, opts *options) {
+	verb, document := "encoded", input
+	switch {
+	case opts.format:
+		verb = "formatted"
+	case opts.decode:
+		verb, document = "decoded", []byte(converted)
+	}
+
+	parts := []string{verb}
+	if data, err := jsonstr.Parse(document); err == nil {
+		// Several NDJSON documents do not parse as one and are not described
+		description, _ := jsonstr.TopLevelType(document)
+		switch v := data.(type) {
+		case map[string]interface{}:
+			description += ", " + plural(len(v), "key")
+		case []interface{}:
+			description += ", " + plural(len(v), "element")
+		}
+		parts[0] += " " + description
+	}
+	parts = append(parts, fmt.Sprintf("%d→%d bytes", len(input), len(output)))
+
+	switch {
+	case opts.decode:
+		parts = append(parts, fmt.Sprintf("-%s", plural(countEscapes(string(input)), "escape")))
+	case !opts.format && opts.lang == "" && opts.embedInto == "" && !opts.escapeVals && !opts.queryString && !opts.toCSV && !opts.toJSON5 && !opts.pointers && !opts.both:
+		parts = append(parts, fmt.Sprintf("+%s", plural(countEscapes(converted), "escape")))
+	}
+
+	fmt.Fprintln(w, strings.Join(parts, ", "))
+}
+
+// plural writes a count followed by noun, adding an s unless the count is one
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// countEscapes returns the number of backslash escape sequences in escaped text
+func countEscapes(escaped string) int {
+	count := 0
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] == '\\' {
+			count++
+			// The escaped character cannot start another escape
+			i++
+		}
+	}
+	return count
+}
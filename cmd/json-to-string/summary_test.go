@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestWriteSummary verifies the --summary digest for each kind of conversion
+func TestWriteSummary(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		converted string
+		output    string
+		opts      options
+		expected  string
+	}{
+		{
+			name:      "Encoded object",
+			input:     `{"a":"x\ny","b":[1]}`,
+			converted: `{\"a\":\"x\\ny\",\"b\":[1]}`,
+			output:    `{\"a\":\"x\\ny\",\"b\":[1]}`,
+			expected:  "encoded object, 2 keys, 20→27 bytes, +7 escapes\n",
+		},
+		{
+			name:      "Decoded array",
+			input:     `[\"a\"]`,
+			converted: `["a"]`,
+			output:    `["a"]`,
+			opts:      options{decode: true},
+			expected:  "decoded array, 1 element, 7→5 bytes, -2 escapes\n",
+		},
+		{
+			name:      "Formatted scalar",
+			input:     `true`,
+			converted: `true`,
+			output:    `true`,
+			opts:      options{format: true},
+			expected:  "formatted boolean, 4→4 bytes\n",
+		},
+		{
+			name:      "Output options count towards the size",
+			input:     `1`,
+			converted: `1`,
+			output:    `X=1`,
+			opts:      options{envName: "X"},
+			expected:  "encoded number, 1→3 bytes, +0 escapes\n",
+		},
+		{
+			name:      "Language literals have no escape count",
+			input:     `{}`,
+			converted: `"{}"`,
+			output:    `"{}"`,
+			opts:      options{lang: "c"},
+			expected:  "encoded object, 0 keys, 2→4 bytes\n",
+		},
+		{
+			name:      "Several NDJSON documents",
+			input:     "{}\n{}",
+			converted: "{}\n{}",
+			output:    "{}\n{}",
+			opts:      options{ndjson: true},
+			expected:  "encoded, 5→5 bytes, +0 escapes\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			writeSummary(&stderr, []byte(tc.input), tc.converted, tc.output, &tc.opts)
+			if stderr.String() != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, stderr.String())
+			}
+		})
+	}
+}
+
+// TestSummaryFlag verifies the digest goes to stderr and leaves stdout unchanged
+func TestSummaryFlag(t *testing.T) {
+	binaryPath := buildTestBinary(t)
+
+	cmd := exec.Command(binaryPath, "--summary", "--json", `{"a":1}`)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != `{\"a\":1}`+"\n" {
+		t.Errorf("expected the encoded output on stdout but got %q", stdout.String())
+	}
+	if !strings.HasPrefix(stderr.String(), "encoded object, 1 key, ") {
+		t.Errorf("expected a summary on stderr but got %q", stderr.String())
+	}
+
+	if err := exec.Command(binaryPath, "--summary", "--count-json", "--json", `{}`).Run(); err == nil {
+		t.Errorf("expected --summary with --count-json to fail")
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/eiladin/json-to-string/pkg/jsonstr"
+)
+
+// hasTransforms reports whether any option requires the input to be parsed
+// and re-marshaled before encoding
+func (o *options) hasTransforms() bool {
+	return o.pointer != "" || len(o.sets) > 0 || o.editsFile != "" || len(o.removes) > 0 || o.sortArrays || o.dedupeArrays || o.floatPrecision > 0 ||
+		o.maxArrayLen > 0 || o.expandUnicode || o.filter != "" || o.hasStringOptions()
+}
+
+// hasStringOptions reports whether any option changes the string values or keys
+func (o *options) hasStringOptions() bool {
+	return len(o.replaces) > 0 || len(o.replaceRegexes) > 0 || o.trimStrings || o.trimKeys || o.caseName != "" ||
+		o.maxStringLen > 0
+}
+
+// withoutTransforms returns a copy of the options with the structural options
+// cleared, for converting parts of an input that was already transformed
+func (o *options) withoutTransforms() *options {
+	copied := *o
+	copied.pointer = ""
+	copied.sets = nil
+	copied.removes = nil
+	copied.editsFile = ""
+	copied.sortArrays = false
+	copied.dedupeArrays = false
+	copied.floatPrecision = 0
+	copied.expandUnicode = false
+	copied.trimStrings = false
+	copied.trimKeys = false
+	copied.caseName = ""
+	copied.caseKeys = false
+	copied.replaces = nil
+	copied.replaceRegexes = nil
+	copied.maxStringLen = 0
+	copied.maxArrayLen = 0
+	copied.noTruncationMarker = false
+	copied.filter = ""
+	return &copied
+}
+
+// applySet sets the value of a --set <pointer>=<JSON value> assignment in data
+func applySet(data interface{}, assignment string) error {
+	pointer, raw, ok := strings.Cut(assignment, "=")
+	if !ok {
+		return fmt.Errorf("invalid --set %q: expected <pointer>=<JSON value>", assignment)
+	}
+	value, err := jsonstr.Parse([]byte(raw))
+	if err != nil {
+		return fmt.Errorf("invalid --set value for %s: %w", pointer, err)
+	}
+	if err := jsonstr.SetPointer(data, pointer, value); err != nil {
+		return fmt.Errorf("--set %s: %w", pointer, err)
+	}
+	return nil
+}
+
+// caseFuncs maps the --case values to the functions that change the case of a string
+var caseFuncs = map[string]func(string) string{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// transformInput applies structural options to the parsed input before encoding.
+// When no such option is set the input is returned unchanged so that its original
+// formatting is preserved. Otherwise the result is compact JSON with sorted keys.
+func transformInput(input []byte, opts *options) ([]byte, error) {
+	if !opts.hasTransforms() {
+		return input, nil
+	}
+
+	data, err := jsonstr.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.editsFile != "" {
+		if data, err = applyEdits(data, opts.editsFile, opts.ignoreMissing); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, assignment := range opts.sets {
+		if err := applySet(data, assignment); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pointer := range opts.removes {
+		removed, err := jsonstr.RemovePointer(data, pointer)
+		if errors.Is(err, jsonstr.ErrNoValue) && opts.ignoreMissing {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("--remove %s: %w", pointer, err)
+		}
+		data = removed
+	}
+
+	if opts.pointer != "" {
+		data, err = jsonstr.ResolvePointer(data, opts.pointer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.hasStringOptions() {
+		if data, err = mapStrings(data, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.floatPrecision > 0 {
+		data = jsonstr.RoundFloats(data, opts.floatPrecision)
+	}
+	if opts.sortArrays {
+		jsonstr.SortArrays(data)
+	}
+	if opts.dedupeArrays {
+		data = jsonstr.DedupeArrays(data)
+	}
+	if opts.maxArrayLen > 0 {
+		data = jsonstr.TruncateArrays(data, opts.maxArrayLen, !opts.noTruncationMarker)
+	}
+
+	result, err := marshalTransformed(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The filter command sees the document after every other option
+	if opts.filter != "" {
+		return runFilter(result, opts.filter)
+	}
+	return result, nil
+}
+
+// marshalTransformed marshals the transformed input, as compact JSON with sorted
+// keys, keeping the characters of --expand-unicode
+func marshalTransformed(data interface{}, opts *options) ([]byte, error) {
+	if opts.expandUnicode {
+		return marshalUnescaped(data)
+	}
+	result, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling JSON: %w", err)
+	}
+	return result, nil
+}
+
+// marshalUnescaped marshals data for --expand-unicode. Unlike json.Marshal it
+// writes <, > and & as they are, so a \u003c in the input also becomes a literal
+// character. Control characters, U+2028 and U+2029 are still escaped.
+func marshalUnescaped(data interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return nil, fmt.Errorf("error marshaling JSON: %w", err)
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// mapStrings applies the string options to the string values and object keys of
+// data: --replace and --replace-regex, then --trim-strings and --trim-keys, then
+// --case with --case-keys, then --max-string-length
+func mapStrings(data interface{}, opts *options) (interface{}, error) {
+	values, keys, err := stringMappers(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = jsonstr.MapStrings(data, values, keys)
+	if err != nil {
+		return nil, fmt.Errorf("mapping keys: %w", err)
+	}
+	return data, nil
+}
+
+// stringMappers returns the functions mapStrings applies to string values and to
+// object keys, either of which is nil when those strings are left unchanged
+func stringMappers(opts *options) (values, keys func(string) string, err error) {
+	var valueSteps, keySteps []func(string) string
+
+	for _, replacement := range opts.replaces {
+		old, replacement, err := parseReplacement("--replace", replacement)
+		if err != nil {
+			return nil, nil, err
+		}
+		valueSteps = append(valueSteps, func(s string) string { return strings.ReplaceAll(s, old, replacement) })
+	}
+	for _, replacement := range opts.replaceRegexes {
+		pattern, replacement, err := parseReplacement("--replace-regex", replacement)
+		if err != nil {
+			return nil, nil, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --replace-regex pattern %q: %w", pattern, err)
+		}
+		valueSteps = append(valueSteps, func(s string) string { return re.ReplaceAllString(s, replacement) })
+	}
+
+	if opts.trimStrings {
+		valueSteps = append(valueSteps, strings.TrimSpace)
+	}
+	if opts.trimKeys {
+		keySteps = append(keySteps, strings.TrimSpace)
+	}
+	if changeCase := caseFuncs[opts.caseName]; changeCase != nil {
+		valueSteps = append(valueSteps, changeCase)
+		if opts.caseKeys {
+			keySteps = append(keySteps, changeCase)
+		}
+	}
+	if opts.maxStringLen > 0 {
+		ellipsis := "…"
+		if opts.noTruncationMarker {
+			ellipsis = ""
+		}
+		valueSteps = append(valueSteps, func(s string) string { return truncateRunes(s, opts.maxStringLen, ellipsis) })
+	}
+	return chain(valueSteps), chain(keySteps), nil
+}
+
+// truncateRunes shortens s to its first max runes followed by ellipsis when it
+// has more than max runes. Counting runes rather than bytes never splits a
+// multibyte character.
+func truncateRunes(s string, max int, ellipsis string) string {
+	count := 0
+	for i := range s {
+		if count == max {
+			return s[:i] + ellipsis
+		}
+		count++
+	}
+	return s
+}
+
+// parseReplacement splits an <old>=<new> replacement given with flag
+func parseReplacement(flag, value string) (old, replacement string, err error) {
+	old, replacement, ok := strings.Cut(value, "=")
+	if !ok || old == "" {
+		return "", "", fmt.Errorf("invalid %s %q: expected <old>=<new> with a non-empty <old>", flag, value)
+	}
+	return old, replacement, nil
+}
+
+// chain returns a function applying steps in order, or nil when there are none
+func chain(steps []func(string) string) func(string) string {
+	if len(steps) == 0 {
+		return nil
+	}
+	return func(s string) string {
+		for _, step := range steps {
+			s = step(s)
+		}
+		return s
+	}
+}
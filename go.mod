@@ -1,3 +1,9 @@
 module github.com/eiladin/json-to-string
 
 go 1.24.3
+
+require (
+	github.com/BurntSushi/toml v1.5.0
+	golang.org/x/text v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,28 @@
+package jsonstr
+
+import "strings"
+
+// ByteEscape rewrites every byte above 0x7F in the output of Encode as a \xNN
+// escape of that byte, so each UTF-8 character becomes one escape per byte, such
+// as \xc3\xa9 for é. This is not JSON escaping, which has no \x escapes and writes
+// characters as \uXXXX, and the result cannot be decoded as a JSON string. It is
+// meant for consumers that require this form. Every backslash in the output of
+// Encode starts a JSON escape, so the \x escapes cannot be confused with the
+// content.
+func ByteEscape(escaped string) string {
+	const hexDigits = "0123456789abcdef"
+
+	var b strings.Builder
+	b.Grow(len(escaped))
+	for i := 0; i < len(escaped); i++ {
+		c := escaped[i]
+		if c < 0x80 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString(`\x`)
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&0x0f])
+	}
+	return b.String()
+}
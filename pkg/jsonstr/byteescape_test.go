@@ -0,0 +1,36 @@
+package jsonstr
+
+import "testing"
+
+func TestByteEscape(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "ASCII is unchanged", input: `{\"a\":\"b\\nc\"}`, expected: `{\"a\":\"b\\nc\"}`},
+		{name: "Two-byte character", input: `{\"name\":\"café\"}`, expected: `{\"name\":\"caf\xc3\xa9\"}`},
+		{name: "Three-byte characters", input: `日本`, expected: `\xe6\x97\xa5\xe6\x9c\xac`},
+		{name: "Four-byte character", input: `😀`, expected: `\xf0\x9f\x98\x80`},
+		{name: "Invalid UTF-8 byte", input: "a\xffb", expected: `a\xffb`},
+		{name: "Empty", input: ``, expected: ``},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := ByteEscape(tc.input)
+			if result != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+
+	// The escapes are built from the encoded output, byte for byte
+	encoded, err := Encode([]byte(`{"city":"Zürich"}`), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := ByteEscape(encoded); result != `{\"city\":\"Z\xc3\xbcrich\"}` {
+		t.Errorf("unexpected result %s", result)
+	}
+}
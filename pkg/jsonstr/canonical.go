@@ -0,0 +1,158 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SortArrays sorts the elements of every array in a parsed JSON document, as
+// returned by Parse, by their canonical form, so that arrays holding the same
+// elements in a different order become identical. Nested arrays are sorted
+// first, so the order does not depend on them either. Arrays are sorted in place.
+//
+// The canonical form is compact JSON with sorted object keys in which numbers
+// are written as plain decimals, so numbers that are equal, such as 1.5, 1.50 and
+// 15e-1, are written the same way. Numbers that would need more than 64 zeros
+// as plain decimals, such as 1e100, are written as a normalized mantissa with
+// an exponent instead, 1e100. Elements are ordered by comparing these forms
+// as text: strings come before numbers, then arrays, false, null, true and
+// objects, and numbers compare digit by digit, so 1.5 comes before 2 and 10
+// comes before 9.
+func SortArrays(data interface{}) {
+	canonical(data, true)
+}
+
+// DedupeArrays removes the elements of every array in a parsed JSON document, as
+// returned by Parse, whose canonical form, as described for SortArrays, equals
+// that of an earlier element, so {"a":1,"b":2} and {"b":2,"a":1.0} are duplicates.
+// The first occurrence of each element is kept, in its place. Nested arrays are
+// deduplicated first, but their order still matters, so [1,2] and [2,1] are kept.
+// Arrays are changed in place, and the document is returned because a top-level
+// array may become shorter.
+func DedupeArrays(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = DedupeArrays(value)
+		}
+	case []interface{}:
+		seen := make(map[string]bool, len(v))
+		kept := v[:0]
+		for _, element := range v {
+			element = DedupeArrays(element)
+			key := canonical(element, false)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			kept = append(kept, element)
+		}
+		return kept
+	}
+	return data
+}
+
+// canonical returns the canonical form of value, first sorting the arrays in it
+// when sortArrays is set
+func canonical(value interface{}, sortArrays bool) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var b strings.Builder
+		b.WriteByte('{')
+		for i, key := range sortedKeys(v) {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(`"` + escapeString(key) + `":`)
+			b.WriteString(canonical(v[key], sortArrays))
+		}
+		b.WriteByte('}')
+		return b.String()
+	case []interface{}:
+		keys := make([]string, len(v))
+		for i, element := range v {
+			keys[i] = canonical(element, sortArrays)
+		}
+		if sortArrays {
+			// Elements with the same canonical form keep their order
+			sort.Stable(&byCanonical{elements: v, keys: keys})
+		}
+		return "[" + strings.Join(keys, ",") + "]"
+	case json.Number:
+		return canonicalNumber(string(v))
+	case float64:
+		return canonicalNumber(strconv.FormatFloat(v, 'g', -1, 64))
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			// Strings, booleans and null always marshal
+			return ""
+		}
+		return string(encoded)
+	}
+}
+
+// maxCanonicalZeros is the number of zeros up to which canonicalNumber writes
+// a plain decimal, so that huge exponents cannot exhaust memory
+const maxCanonicalZeros = 64
+
+// canonicalNumber writes a JSON number so that equal numbers are written the same
+// way: as a plain decimal without an exponent, leading zeros, trailing zeros in
+// the fraction or a sign on zero, so 1.50e1 is written as 15 and -0.0 as 0.
+// Numbers that would need more than maxCanonicalZeros zeros are written as a
+// mantissa with one digit before the point and an exponent, so 1.50e100 is
+// written as 1.5e100. The exponent may be arbitrarily large, so it is a big.Int.
+func canonicalNumber(number string) string {
+	mantissa, exponent := number, new(big.Int)
+	if i := strings.IndexAny(number, "eE"); i >= 0 {
+		if _, ok := exponent.SetString(strings.TrimPrefix(number[i+1:], "+"), 10); !ok {
+			return number
+		}
+		mantissa = number[:i]
+	}
+	sign := ""
+	if rest, ok := strings.CutPrefix(mantissa, "-"); ok {
+		sign, mantissa = "-", rest
+	}
+
+	// The number is 0.digits times ten to the power of point
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	digits := strings.TrimLeft(whole+fraction, "0")
+	if digits == "" {
+		return "0"
+	}
+	point := exponent.Add(exponent, big.NewInt(int64(len(digits)-len(fraction))))
+	digits = strings.TrimRight(digits, "0")
+
+	if !point.IsInt64() || point.Int64() < -maxCanonicalZeros || point.Int64() > int64(len(digits)+maxCanonicalZeros) {
+		exponent := point.Sub(point, big.NewInt(1)).String()
+		if len(digits) == 1 {
+			return sign + digits + "e" + exponent
+		}
+		return sign + digits[:1] + "." + digits[1:] + "e" + exponent
+	}
+	switch n := int(point.Int64()); {
+	case n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	case n >= len(digits):
+		return sign + digits + strings.Repeat("0", n-len(digits))
+	default:
+		return sign + digits[:n] + "." + digits[n:]
+	}
+}
+
+// byCanonical sorts array elements together with their canonical forms
+type byCanonical struct {
+	elements []interface{}
+	keys     []string
+}
+
+func (s *byCanonical) Len() int           { return len(s.elements) }
+func (s *byCanonical) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s *byCanonical) Swap(i, j int) {
+	s.elements[i], s.elements[j] = s.elements[j], s.elements[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
@@ -0,0 +1,154 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSortArrays(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Strings", input: `["b","c","a"]`, expected: `["a","b","c"]`},
+		{name: "Numbers compare as text", input: `[10,9,1]`, expected: `[1,10,9]`},
+		{name: "Equal numbers keep their order", input: `[2,1.0,1,1e0]`, expected: `[1.0,1,1e0,2]`},
+		{name: "Non-integer numbers", input: `[0.1,2,1.5,0.5]`, expected: `[0.1,0.5,1.5,2]`},
+		{name: "Non-integer before a larger integer", input: `[3,1.5]`, expected: `[1.5,3]`},
+		{name: "Exponents", input: `[2e-1,15e-1,1.2e1,0.05]`, expected: `[0.05,2e-1,15e-1,1.2e1]`},
+		{name: "Huge exponents", input: `[1e9999999999,2,1e-99999999999999999]`, expected: `[1e-99999999999999999,1e9999999999,2]`},
+		{
+			name:     "Mixed scalar types",
+			input:    `[{"a":1},true,null,false,[1],2,"x",-1]`,
+			expected: `["x",-1,2,[1],false,null,true,{"a":1}]`,
+		},
+		{
+			name:     "Objects compare by their sorted keys",
+			input:    `[{"name":"b","id":1},{"id":2,"name":"a"},{"id":1,"name":"a"}]`,
+			expected: `[{"id":1,"name":"a"},{"id":1,"name":"b"},{"id":2,"name":"a"}]`,
+		},
+		{
+			name:     "Nested arrays are sorted first",
+			input:    `[[3,1],[2,1],[1,2,0]]`,
+			expected: `[[0,1,2],[1,2],[1,3]]`,
+		},
+		{
+			name:     "Arrays inside objects",
+			input:    `{"tags":["z","a"],"items":[{"ids":[2,1]},{"ids":[1]}]}`,
+			expected: `{"items":[{"ids":[1,2]},{"ids":[1]}],"tags":["a","z"]}`,
+		},
+		{name: "Scalar document", input: `"x"`, expected: `"x"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Parse([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			SortArrays(data)
+			result, err := json.Marshal(data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+
+	// Documents that differ only in the order of array elements become identical
+	first, _ := Parse([]byte(`{"roles":[{"name":"admin","scopes":["write","read"]},{"name":"dev","scopes":["read"]}]}`))
+	second, _ := Parse([]byte(`{"roles":[{"scopes":["read"],"name":"dev"},{"scopes":["read","write"],"name":"admin"}]}`))
+	SortArrays(first)
+	SortArrays(second)
+	a, _ := json.Marshal(first)
+	b, _ := json.Marshal(second)
+	if string(a) != string(b) {
+		t.Errorf("expected sorted documents to match but got %s and %s", a, b)
+	}
+}
+
+func TestDedupeArrays(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Scalars keep their first occurrence", input: `["b","a","b",1,"a",1]`, expected: `["b","a",1]`},
+		{name: "Equal numbers", input: `[1,1.0,1e0,2]`, expected: `[1,2]`},
+		{name: "Huge exponents", input: `[1e9999999999,10e9999999998,2,1e-99999999999999999,0.1e-99999999999999998]`, expected: `[1e9999999999,2,1e-99999999999999999]`},
+		{
+			name:     "Objects with different key order",
+			input:    `[{"id":1,"name":"a"},{"name":"a","id":1},{"id":1.0,"name":"a"},{"id":2,"name":"a"}]`,
+			expected: `[{"id":1,"name":"a"},{"id":2,"name":"a"}]`,
+		},
+		{name: "Array order matters", input: `[[1,2],[2,1],[1,2]]`, expected: `[[1,2],[2,1]]`},
+		{name: "Nested arrays are deduplicated first", input: `[[1,1,2],[1,2]]`, expected: `[[1,2]]`},
+		{
+			name:     "Arrays inside objects",
+			input:    `{"tags":["a","a"],"items":[{"ids":[1,1]},{"ids":[1]}]}`,
+			expected: `{"items":[{"ids":[1]}],"tags":["a"]}`,
+		},
+		{name: "Scalar document", input: `"x"`, expected: `"x"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Parse([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, err := json.Marshal(DedupeArrays(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestCanonicalNumber(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "0", expected: "0"},
+		{input: "-0.0", expected: "0"},
+		{input: "42", expected: "42"},
+		{input: "-42", expected: "-42"},
+		{input: "1.50", expected: "1.5"},
+		{input: "15e-1", expected: "1.5"},
+		{input: "0.015E2", expected: "1.5"},
+		{input: "1.50e1", expected: "15"},
+		{input: "1e3", expected: "1000"},
+		{input: "1E+3", expected: "1000"},
+		{input: "5e-3", expected: "0.005"},
+		{input: "-0.25", expected: "-0.25"},
+		{input: "100.010", expected: "100.01"},
+		{input: "0.00", expected: "0"},
+		{input: "0e5", expected: "0"},
+		{input: "1e64", expected: "1" + strings.Repeat("0", 64)},
+		{input: "1e65", expected: "1e65"},
+		{input: "1.50e100", expected: "1.5e100"},
+		{input: "150e98", expected: "1.5e100"},
+		{input: "-25e-100", expected: "-2.5e-99"},
+		{input: "1e-64", expected: "0." + strings.Repeat("0", 63) + "1"},
+		{input: "1e-66", expected: "1e-66"},
+		{input: "1e9999999999", expected: "1e9999999999"},
+		{input: "1e-99999999999999999", expected: "1e-99999999999999999"},
+		{input: "10e99999999999999999999", expected: "1e100000000000000000000"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			if result := canonicalNumber(tc.input); result != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+}
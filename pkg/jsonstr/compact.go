@@ -0,0 +1,96 @@
+package jsonstr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// jsonSpace holds the characters JSON allows around tokens
+const jsonSpace = " \t\r\n"
+
+// marshaledForm returns input without surrounding whitespace when it is already
+// exactly what re-marshaling it with Compact would produce: no whitespace
+// between tokens, object keys in sorted order without duplicates, and strings
+// and numbers written the way encoding/json writes them. Such input can be
+// escaped as it is, which saves building and marshaling the parsed document.
+// It reports false for anything else, including invalid JSON, which is left to
+// the usual checks.
+func marshaledForm(input []byte) ([]byte, bool) {
+	input = bytes.Trim(input, jsonSpace)
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+
+	// container tracks an open object or array. In an object, the next string
+	// is a key when expectKey is set, and lastKey is the key before it.
+	type container struct {
+		object    bool
+		expectKey bool
+		hasKey    bool
+		lastKey   string
+	}
+	var stack []container
+	var previous int64
+	done := false
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return input, done
+		}
+		if err != nil || done {
+			return nil, false
+		}
+		offset := decoder.InputOffset()
+		raw := input[previous:offset]
+		previous = offset
+		if len(raw) > 1 && (raw[0] == ',' || raw[0] == ':') {
+			raw = raw[1:]
+		}
+
+		var expected []byte
+		completed := true
+		switch v := token.(type) {
+		case json.Delim:
+			expected = []byte(v.String())
+			switch v {
+			case '{', '[':
+				stack = append(stack, container{object: v == '{', expectKey: v == '{'})
+				completed = false
+			default:
+				stack = stack[:len(stack)-1]
+			}
+		case string:
+			if n := len(stack); n > 0 && stack[n-1].object && stack[n-1].expectKey {
+				if stack[n-1].hasKey && v <= stack[n-1].lastKey {
+					return nil, false
+				}
+				stack[n-1].hasKey, stack[n-1].lastKey = true, v
+			}
+			expected, err = json.Marshal(v)
+		case json.Number:
+			f, parseErr := strconv.ParseFloat(string(v), 64)
+			if parseErr != nil {
+				return nil, false
+			}
+			expected, err = json.Marshal(f)
+		default:
+			expected, err = json.Marshal(v)
+		}
+		if err != nil || !bytes.Equal(raw, expected) {
+			return nil, false
+		}
+
+		// A key or a complete value switches an object between keys and values
+		if !completed {
+			continue
+		}
+		if n := len(stack); n == 0 {
+			done = true
+		} else if stack[n-1].object {
+			stack[n-1].expectKey = !stack[n-1].expectKey
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMarshaledForm(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{name: "Compact object with sorted keys", input: `{"a":1,"b":[true,false,null],"c":{"d":"x","e":{}}}`, ok: true},
+		{name: "Nested arrays and objects", input: `[[],[{"a":[1,[2]]},"s"],{"a":{"b":[]},"c":3}]`, ok: true},
+		{name: "Surrounding whitespace", input: " {\"a\":1}\n", ok: true},
+		{name: "Scalars", input: `"text"`, ok: true},
+		{name: "Numbers in encoding/json form", input: `[0,-1,1.5,1e+21,1e-7,0.000001,-0]`, ok: true},
+		{name: "Whitespace between tokens", input: `{"a": 1}`, ok: false},
+		{name: "Whitespace before a comma", input: `[1 ,2]`, ok: false},
+		{name: "Unsorted keys", input: `{"b":1,"a":2}`, ok: false},
+		{name: "Unsorted keys in a nested object", input: `[{"a":{"d":1,"c":2}}]`, ok: false},
+		{name: "Duplicate keys", input: `{"a":1,"a":2}`, ok: false},
+		{name: "Keys compared after the object ends", input: `{"a":{"z":1},"b":{"a":1}}`, ok: true},
+		{name: "Trailing zeros", input: `[1.50]`, ok: false},
+		{name: "Exponent that encoding/json writes in full", input: `[1e3]`, ok: false},
+		{name: "Escaped character", input: `["\u00e9"]`, ok: false},
+		{name: "Escaped slash", input: `["a\/b"]`, ok: false},
+		{name: "HTML characters", input: `["<b>"]`, ok: false},
+		{name: "Invalid JSON", input: `{"a":}`, ok: false},
+		{name: "Several documents", input: `{}{}`, ok: false},
+		{name: "Empty input", input: ``, ok: false},
+		{name: "Number too large", input: `[1e999]`, ok: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, ok := marshaledForm([]byte(tc.input))
+			if ok != tc.ok {
+				t.Fatalf("expected %v but got %v", tc.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			// The result must be what the re-marshal it replaces produces
+			var data interface{}
+			if err := json.Unmarshal([]byte(tc.input), &data); err != nil {
+				t.Fatalf("failed to parse input: %v", err)
+			}
+			expected, err := json.Marshal(data)
+			if err != nil {
+				t.Fatalf("failed to marshal input: %v", err)
+			}
+			if string(result) != string(expected) {
+				t.Errorf("expected %s but got %s", expected, result)
+			}
+		})
+	}
+}
+
+func TestEncodeCompactInputUnchanged(t *testing.T) {
+	input := `{"id":"a-1","n":[1,2.5,-3],"nested":{"ok":true,"tags":["x","y"]}}`
+	result, err := Encode([]byte(input), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := strings.ReplaceAll(input, `"`, `\"`)
+	if result != expected {
+		t.Errorf("expected %q but got %q", expected, result)
+	}
+}
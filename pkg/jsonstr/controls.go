@@ -0,0 +1,91 @@
+package jsonstr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// shortEscapes maps the control characters that JSON has a short escape for to
+// the letter of that escape
+var shortEscapes = map[rune]byte{
+	'\b': 'b',
+	'\t': 't',
+	'\n': 'n',
+	'\f': 'f',
+	'\r': 'r',
+}
+
+// ReadableControls rewrites the \u00XX escapes of control characters in the output
+// of Encode to the short escapes JSON has for them (\b, \t, \n, \f and \r) and
+// writes the others in lowercase \u00xx form, so escaped log lines are easier to
+// read. The result decodes to the same JSON values as the input.
+func ReadableControls(escaped string) string {
+	var b strings.Builder
+	b.Grow(len(escaped))
+
+	for i := 0; i < len(escaped); {
+		if escaped[i] != '\\' {
+			b.WriteByte(escaped[i])
+			i++
+			continue
+		}
+		if !strings.HasPrefix(escaped[i:], `\\`) {
+			// An escape added by encoding, such as \" or \n for a newline in the JSON
+			size := escapeSize(escaped[i:])
+			b.WriteString(escaped[i : i+size])
+			i += size
+			continue
+		}
+
+		// \\ is a backslash in the JSON text, which starts an escape sequence there
+		i += 2
+		if code, ok := controlEscape(escaped[i:]); ok {
+			if short, ok := shortEscapes[code]; ok {
+				b.WriteString(`\\`)
+				b.WriteByte(short)
+			} else {
+				b.WriteString(`\\u` + strings.ToLower(escaped[i+1:i+5]))
+			}
+			i += 5
+			continue
+		}
+
+		// Copy the escaped character whole, so an escaped backslash in the JSON
+		// text is not mistaken for the start of another escape sequence
+		b.WriteString(`\\`)
+		if i < len(escaped) {
+			size := 1
+			if escaped[i] == '\\' {
+				size = escapeSize(escaped[i:])
+			}
+			b.WriteString(escaped[i : i+size])
+			i += size
+		}
+	}
+	return b.String()
+}
+
+// escapeSize returns the length of the escape sequence at the start of s
+func escapeSize(s string) int {
+	switch {
+	case len(s) >= 6 && s[1] == 'u':
+		return 6
+	case len(s) >= 2:
+		return 2
+	default:
+		return len(s)
+	}
+}
+
+// controlEscape reports whether s starts with the uXXXX part of a \uXXXX escape
+// of a control character, and returns that character
+func controlEscape(s string) (rune, bool) {
+	if len(s) < 5 || s[0] != 'u' {
+		return 0, false
+	}
+	code, err := strconv.ParseUint(s[1:5], 16, 16)
+	if err != nil || code >= 0x20 {
+		return 0, false
+	}
+	return rune(code), true
+}
@@ -0,0 +1,50 @@
+package jsonstr
+
+import (
+	"testing"
+)
+
+func TestReadableControls(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Tab", input: `{"a":"x\u0009y"}`, expected: `{\"a\":\"x\\ty\"}`},
+		{name: "Newline", input: `{"a":"x\u000Ay"}`, expected: `{\"a\":\"x\\ny\"}`},
+		{name: "Carriage return", input: `{"a":"x\u000dy"}`, expected: `{\"a\":\"x\\ry\"}`},
+		{name: "Backspace and form feed", input: `["\u0008\u000c"]`, expected: `[\"\\b\\f\"]`},
+		{name: "Bell keeps a lowercase unicode escape", input: `["\u0007\u001B"]`, expected: `[\"\\u0007\\u001b\"]`},
+		{name: "Other unicode escapes are unchanged", input: `["\u00e9\u0020"]`, expected: `[\"\\u00e9\\u0020\"]`},
+		{name: "Escaped backslash before u0009", input: `["\\u0009"]`, expected: `[\"\\\\u0009\"]`},
+		{name: "Escaped quote", input: `["say \"hi\"\u0009"]`, expected: `[\"say \\\"hi\\\"\\t\"]`},
+		{name: "Whitespace between tokens", input: "{\n\t\"a\": 1\n}", expected: `{\n\t\"a\": 1\n}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := Encode([]byte(tc.input), false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result := ReadableControls(encoded)
+			if result != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+
+			// The readable form must decode to the same JSON values
+			decoded, err := Decode([]byte(result), false)
+			if err != nil {
+				t.Fatalf("readable output does not decode: %v", err)
+			}
+			original, err := Decode([]byte(encoded), false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decoded != original {
+				t.Errorf("expected readable output to decode to %s but got %s", original, decoded)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Equal reports whether two JSON documents are semantically equal.
+// Object key order and insignificant whitespace are ignored, and numbers
+// are compared by value so that 1, 1.0 and 1e0 are considered equal.
+func Equal(a, b []byte) (bool, error) {
+	left, err := parse(a)
+	if err != nil {
+		return false, fmt.Errorf("invalid JSON in first document: %w", err)
+	}
+	right, err := parse(b)
+	if err != nil {
+		return false, fmt.Errorf("invalid JSON in second document: %w", err)
+	}
+	return equalValues(left, right), nil
+}
+
+// equalValues recursively compares two parsed JSON values
+func equalValues(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !equalValues(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equalValues(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		return equalNumbers(av, bv)
+	default:
+		// Strings, booleans and null compare directly
+		return a == b
+	}
+}
+
+// equalNumbers compares two JSON numbers exactly, regardless of representation
+func equalNumbers(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	ar, ok := new(big.Rat).SetString(string(a))
+	if !ok {
+		return false
+	}
+	br, ok := new(big.Rat).SetString(string(b))
+	if !ok {
+		return false
+	}
+	return ar.Cmp(br) == 0
+}
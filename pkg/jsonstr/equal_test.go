@@ -0,0 +1,106 @@
+package jsonstr
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name        string
+		a           string
+		b           string
+		expected    bool
+		expectError bool
+	}{
+		{
+			name:     "Identical objects",
+			a:        `{"name":"John","age":30}`,
+			b:        `{"name":"John","age":30}`,
+			expected: true,
+		},
+		{
+			name:     "Different key order and whitespace",
+			a:        `{"name":"John","age":30}`,
+			b:        "{\n  \"age\": 30,\n  \"name\": \"John\"\n}",
+			expected: true,
+		},
+		{
+			name:     "Integer and decimal representations",
+			a:        `{"value":1}`,
+			b:        `{"value":1.0}`,
+			expected: true,
+		},
+		{
+			name:     "Exponent and integer representations",
+			a:        `[1e2, 0.5]`,
+			b:        `[100, 5e-1]`,
+			expected: true,
+		},
+		{
+			name:     "Nested arrays and objects",
+			a:        `{"a":[{"b":[1,2,{"c":null}]}],"d":true}`,
+			b:        `{"d":true,"a":[{"b":[1,2,{"c":null}]}]}`,
+			expected: true,
+		},
+		{
+			name:     "Different number values",
+			a:        `{"value":1}`,
+			b:        `{"value":1.5}`,
+			expected: false,
+		},
+		{
+			name:     "Array order matters",
+			a:        `[1,2,3]`,
+			b:        `[3,2,1]`,
+			expected: false,
+		},
+		{
+			name:     "Missing key",
+			a:        `{"a":1,"b":2}`,
+			b:        `{"a":1}`,
+			expected: false,
+		},
+		{
+			name:     "Different types",
+			a:        `{"a":"1"}`,
+			b:        `{"a":1}`,
+			expected: false,
+		},
+		{
+			name:     "Null and false differ",
+			a:        `null`,
+			b:        `false`,
+			expected: false,
+		},
+		{
+			name:        "Invalid first document",
+			a:           `{"a":}`,
+			b:           `{}`,
+			expectError: true,
+		},
+		{
+			name:        "Invalid second document",
+			a:           `{}`,
+			b:           `{} {}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Equal([]byte(tc.a), []byte(tc.b))
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				if result != tc.expected {
+					t.Errorf("expected %v but got %v", tc.expected, result)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package jsonstr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Escaper encodes and decodes JSON with a fixed set of options, so they do not
+// have to be passed on every call. Buffers and encoders are taken from a pool
+// shared by all conversions. An Escaper is safe for concurrent use.
+type Escaper struct {
+	opts EncodeOptions
+}
+
+// NewEscaper returns an Escaper that applies opts to every conversion
+func NewEscaper(opts EncodeOptions) *Escaper {
+	return &Escaper{opts: opts}
+}
+
+// Escape takes a JSON byte slice and returns a properly escaped string
+// representation, like EncodeWithOptions
+func (e *Escaper) Escape(input []byte) (string, error) {
+	return e.escape(input, false)
+}
+
+// EscapeBytes works like Escape but returns the escaped text as a byte slice,
+// for callers that write it to a buffer or an io.Writer
+func (e *Escaper) EscapeBytes(input []byte) ([]byte, error) {
+	b := getBuffer()
+	defer putBuffer(b)
+
+	result, err := e.escapeInto(b, input, false)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(result), nil
+}
+
+// escape encodes input as a JSON string, keeping the surrounding quotes if quoted is set
+func (e *Escaper) escape(input []byte, quoted bool) (string, error) {
+	b := getBuffer()
+	defer putBuffer(b)
+
+	result, err := e.escapeInto(b, input, quoted)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// escapeInto encodes input as a JSON string in b, keeping the surrounding quotes
+// if quoted is set. The result aliases b and must be copied before b is released.
+func (e *Escaper) escapeInto(b *pooledBuffer, input []byte, quoted bool) ([]byte, error) {
+	jsonStr, err := PrepareWithOptions(input, e.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the JSON to a string with proper escaping
+	result, err := b.encode(jsonStr, "")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding JSON: %w", err)
+	}
+
+	// Unless quoted, drop the outer quotes. Only the enclosing pair is removed,
+	// as the escaped text may itself end in \".
+	if !quoted {
+		result = result[1 : len(result)-1]
+	}
+	return result, nil
+}
+
+// Unescape takes an escaped JSON string and converts it back to JSON, like
+// DecodeWithOptions
+func (e *Escaper) Unescape(input []byte) (string, error) {
+	b := getBuffer()
+	defer putBuffer(b)
+
+	result, err := e.unescapeInto(b, input)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// UnescapeBytes works like Unescape but returns the JSON as a byte slice, for
+// callers that write it to a buffer or an io.Writer
+func (e *Escaper) UnescapeBytes(input []byte) ([]byte, error) {
+	b := getBuffer()
+	defer putBuffer(b)
+
+	result, err := e.unescapeInto(b, input)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(result), nil
+}
+
+// unescapeInto converts an escaped JSON string back to JSON using b. The result
+// may alias b and must be copied before b is released.
+func (e *Escaper) unescapeInto(b *pooledBuffer, input []byte) ([]byte, error) {
+	// First, we need to add quotes to make it a valid JSON string
+	b.quoted = append(b.quoted[:0], '"')
+	b.quoted = append(b.quoted, input...)
+	b.quoted = append(b.quoted, '"')
+
+	// Unmarshal the string to get the actual JSON string with escapes interpreted
+	var jsonString string
+	if err := json.Unmarshal(b.quoted, &jsonString); err != nil {
+		return nil, fmt.Errorf("invalid JSON string: %w", err)
+	}
+
+	// Validate that the result is valid JSON
+	var parsedJSON interface{}
+	if err := json.Unmarshal([]byte(jsonString), &parsedJSON); err != nil {
+		return nil, fmt.Errorf("decoded string is not valid JSON: %w", err)
+	}
+	if e.opts.StrictKeys {
+		if err := CheckDuplicateKeys([]byte(jsonString)); err != nil {
+			return nil, fmt.Errorf("decoded JSON has a %w", err)
+		}
+	}
+	if e.opts.IndentJSONStrings {
+		indented, err := IndentJSONStrings([]byte(jsonString), e.opts.indent())
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(indented, &parsedJSON); err != nil {
+			return nil, fmt.Errorf("decoded string is not valid JSON: %w", err)
+		}
+	}
+
+	// Format the output according to the pretty option. The custom layouts and
+	// wrapping work on strings, which only display-oriented output pays for.
+	if e.opts.Pretty && (e.opts.Align || e.opts.CompactThreshold > 0 || e.opts.PrettyDepth > 0) {
+		formatted, err := printPretty(parsedJSON, e.opts)
+		if err != nil {
+			return nil, fmt.Errorf("error formatting JSON: %w", err)
+		}
+		if e.opts.Wrap > 0 {
+			formatted = wrapStrings(formatted, e.opts.Wrap)
+		}
+		return []byte(formatted), nil
+	}
+
+	indent := ""
+	if e.opts.Pretty {
+		indent = e.opts.indent()
+	}
+	formatted, err := b.encode(parsedJSON, indent)
+	if err != nil {
+		if e.opts.Pretty {
+			return nil, fmt.Errorf("error formatting JSON: %w", err)
+		}
+		return nil, fmt.Errorf("error marshaling JSON: %w", err)
+	}
+
+	if e.opts.Pretty && e.opts.Wrap > 0 {
+		return []byte(wrapStrings(string(formatted), e.opts.Wrap)), nil
+	}
+	return formatted, nil
+}
@@ -0,0 +1,265 @@
+package jsonstr
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEscaper(t *testing.T) {
+	inputs := []string{
+		`{"name":"John","tags":["a","b"],"html":"<b>&</b>"}`,
+		"{\n  \"nested\": {\"quote\": \"say \\\"hi\\\"\"}\n}",
+		`"hi"`,
+		`[1, 2.50, null, true]`,
+	}
+	optionSets := []EncodeOptions{
+		{},
+		{Compact: true},
+		{Pretty: true},
+		{Pretty: true, Indent: "\t"},
+		{StripWhitespace: true},
+	}
+
+	for _, opts := range optionSets {
+		escaper := NewEscaper(opts)
+
+		// Run twice so the second pass uses the buffers left by the first
+		for pass := 0; pass < 2; pass++ {
+			for _, input := range inputs {
+				expected, err := PrepareWithOptions([]byte(input), opts)
+				if err != nil {
+					t.Fatalf("unexpected error preparing %s: %v", input, err)
+				}
+
+				escaped, err := escaper.Escape([]byte(input))
+				if err != nil {
+					t.Fatalf("unexpected error escaping %s: %v", input, err)
+				}
+				if strings.Contains(escaped, "\n") {
+					t.Errorf("expected escaped output without newlines but got %s", escaped)
+				}
+
+				unescaped, err := NewEscaper(EncodeOptions{}).Unescape([]byte(escaped))
+				if err != nil {
+					t.Fatalf("unexpected error unescaping %s: %v", escaped, err)
+				}
+				if equal, _ := Equal([]byte(unescaped), []byte(expected)); !equal {
+					t.Errorf("expected %s to unescape to %s but got %s", escaped, expected, unescaped)
+				}
+			}
+		}
+	}
+}
+
+func TestEscaperUnescape(t *testing.T) {
+	escaper := NewEscaper(EncodeOptions{Pretty: true})
+
+	for pass := 0; pass < 2; pass++ {
+		result, err := escaper.Unescape([]byte(`{\"b\":[1],\"a\":\"x\"}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := "{\n  \"a\": \"x\",\n  \"b\": [\n    1\n  ]\n}"
+		if result != expected {
+			t.Errorf("expected\n%s\nbut got\n%s", expected, result)
+		}
+
+		// A failed call must not affect the next one
+		if _, err := escaper.Unescape([]byte(`{\"a\":`)); err == nil {
+			t.Errorf("expected error but got none")
+		}
+	}
+}
+
+func TestEscaperConcurrentUse(t *testing.T) {
+	escaper := NewEscaper(EncodeOptions{Compact: true})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				result, err := escaper.Escape([]byte(`{"key": "value"}`))
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if result != `{\"key\":\"value\"}` {
+					t.Errorf("unexpected result %s", result)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// benchmarkInput is a moderately sized document used by the benchmarks
+var benchmarkInput = []byte(`{"name":"John","age":30,"address":{"city":"New York","zip":"10001"},` +
+	`"tags":["a","b","c"],"bio":"` + strings.Repeat(`Lorem ipsum \"dolor\" sit amet. `, 20) + `"}`)
+
+func BenchmarkEncodeWithOptions(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := EncodeWithOptions(benchmarkInput, EncodeOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEscaperEscape(b *testing.B) {
+	escaper := NewEscaper(EncodeOptions{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := escaper.Escape(benchmarkInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeWithOptions(b *testing.B) {
+	escaped, err := EncodeWithOptions(benchmarkInput, EncodeOptions{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeWithOptions([]byte(escaped), EncodeOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEscaperUnescape(b *testing.B) {
+	escaped, err := EncodeWithOptions(benchmarkInput, EncodeOptions{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	escaper := NewEscaper(EncodeOptions{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := escaper.Unescape([]byte(escaped)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEncodeDecodeBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		compact bool
+		pretty  bool
+	}{
+		{name: "Object", input: `{"b": [1, 2], "a": "say \"hi\""}`},
+		{name: "Compact", input: "{\n  \"b\": 1,\n  \"a\": 2\n}", compact: true},
+		{name: "Pretty decode", input: `{"a":{"b":[1]}}`, pretty: true},
+		{name: "Escaped text ending in a quote", input: `"x"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			expected, err := Encode([]byte(tc.input), tc.compact)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			encoded, err := EncodeBytes([]byte(tc.input), tc.compact)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(encoded) != expected {
+				t.Errorf("expected EncodeBytes to return %s but got %s", expected, encoded)
+			}
+
+			expectedDecoded, err := Decode(encoded, tc.pretty)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			decoded, err := DecodeBytes(encoded, tc.pretty)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(decoded) != expectedDecoded {
+				t.Errorf("expected DecodeBytes to return %s but got %s", expectedDecoded, decoded)
+			}
+		})
+	}
+
+	// The results must not share memory with the pooled buffers
+	first, err := EncodeBytes([]byte(`{"a":1}`), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := EncodeBytes([]byte(`{"zzzzzz":2}`), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(first) != `{\"a\":1}` {
+		t.Errorf("expected an earlier result to be unchanged but got %s", first)
+	}
+
+	if _, err := EncodeBytes([]byte(`{`), false); err == nil {
+		t.Errorf("expected EncodeBytes to fail on invalid JSON")
+	}
+	if _, err := DecodeBytes([]byte(`{\"a\":`), false); err == nil {
+		t.Errorf("expected DecodeBytes to fail on invalid input")
+	}
+}
+
+// largeBenchmarkInput is a large document for comparing the string and byte
+// slice results, where the extra copy of a conversion is most noticeable
+var largeBenchmarkInput = []byte(`{"items":[` + strings.TrimSuffix(strings.Repeat(`{"id":1,"text":"`+
+	strings.Repeat(`Lorem ipsum \"dolor\" sit amet. `, 10)+`"},`, 2000), ",") + `]}`)
+
+func BenchmarkEncodeStringToBytes(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		escaped, err := Encode(largeBenchmarkInput, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = []byte(escaped)
+	}
+}
+
+func BenchmarkEncodeBytes(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := EncodeBytes(largeBenchmarkInput, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeStringToBytes(b *testing.B) {
+	escaped, err := EncodeBytes(largeBenchmarkInput, false)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decoded, err := Decode(escaped, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = []byte(decoded)
+	}
+}
+
+func BenchmarkDecodeBytes(b *testing.B) {
+	escaped, err := EncodeBytes(largeBenchmarkInput, false)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeBytes(escaped, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
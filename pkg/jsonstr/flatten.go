@@ -0,0 +1,111 @@
+package jsonstr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Flatten turns a parsed JSON object, as returned by Parse, into a single-level
+// map whose keys are the paths of the nested values joined with sep, such as
+// "a.b" for {"a":{"b":1}}. Leaves are scalars or arrays of scalars, which are
+// kept as arrays. Empty objects have no leaves and disappear. Arrays holding
+// objects or arrays cannot be flattened and are an error, as is a top-level
+// value that is not an object and two values whose paths join to the same key.
+func Flatten(data interface{}, sep string) (map[string]interface{}, error) {
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot flatten %s: top-level value must be an object", typeName(data))
+	}
+	flat := make(map[string]interface{})
+	if err := flattenInto(flat, object, "", sep, nil); err != nil {
+		return nil, err
+	}
+	return flat, nil
+}
+
+// flattenInto adds the leaves of object to flat, prefixing their keys with
+// prefix. location is the JSON pointer of object, used in errors.
+func flattenInto(flat map[string]interface{}, object map[string]interface{}, prefix, sep string, location []string) error {
+	for key, value := range object {
+		path := append(location[:len(location):len(location)], key)
+		flatKey := key
+		if prefix != "" {
+			flatKey = prefix + sep + key
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flattenInto(flat, v, flatKey, sep, path); err != nil {
+				return err
+			}
+			continue
+		case []interface{}:
+			for i, element := range v {
+				switch element.(type) {
+				case map[string]interface{}, []interface{}:
+					return fmt.Errorf("cannot flatten %s/%d: arrays can only hold scalars", formatPointer(path), i)
+				}
+			}
+		}
+		if err := addLeaf(flat, flatKey, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addLeaf adds a leaf to flat, failing if another path already produced its key,
+// as for {"a.b":1,"a":{"b":2}}
+func addLeaf(flat map[string]interface{}, key string, value interface{}) error {
+	if _, exists := flat[key]; exists {
+		return fmt.Errorf("cannot flatten: more than one value has the key %q", key)
+	}
+	flat[key] = value
+	return nil
+}
+
+// Unflatten is the inverse of Flatten: it splits each key of flat on sep and
+// nests the values in objects, so "a.b" becomes {"a":{"b":...}}. A key that is
+// both a value and the parent of other keys, as in "a" and "a.b", is an error.
+func Unflatten(flat map[string]interface{}, sep string) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	// Sorted keys make the reported conflict the same on every run
+	sort.Strings(keys)
+
+	result := make(map[string]interface{})
+	for _, key := range keys {
+		parts := strings.Split(key, sep)
+		object := result
+		for i, part := range parts[:len(parts)-1] {
+			switch child := object[part].(type) {
+			case nil:
+				if _, exists := object[part]; exists {
+					return nil, unflattenConflict(parts[:i+1], sep)
+				}
+				created := make(map[string]interface{})
+				object[part] = created
+				object = created
+			case map[string]interface{}:
+				object = child
+			default:
+				return nil, unflattenConflict(parts[:i+1], sep)
+			}
+		}
+
+		last := parts[len(parts)-1]
+		if _, exists := object[last]; exists {
+			return nil, unflattenConflict(parts, sep)
+		}
+		object[last] = flat[key]
+	}
+	return result, nil
+}
+
+// unflattenConflict reports a key that is both a value and the parent of other keys
+func unflattenConflict(parts []string, sep string) error {
+	return fmt.Errorf("cannot unflatten: key %q is both a value and the parent of other keys", strings.Join(parts, sep))
+}
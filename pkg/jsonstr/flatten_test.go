@@ -0,0 +1,135 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]interface{}
+		errorMsg string
+	}{
+		{
+			name:     "Flat object",
+			input:    `{"a":1,"b":"x"}`,
+			expected: map[string]interface{}{"a": json.Number("1"), "b": "x"},
+		},
+		{
+			name:     "Nested objects",
+			input:    `{"a":{"b":{"c":true}},"d":null}`,
+			expected: map[string]interface{}{"a.b.c": true, "d": nil},
+		},
+		{
+			name:     "Arrays of scalars are kept",
+			input:    `{"a":{"tags":["x","y"]},"empty":[]}`,
+			expected: map[string]interface{}{"a.tags": []interface{}{"x", "y"}, "empty": []interface{}{}},
+		},
+		{
+			name:     "Empty objects disappear",
+			input:    `{"a":{},"b":1}`,
+			expected: map[string]interface{}{"b": json.Number("1")},
+		},
+		{
+			name:     "Array holding an object",
+			input:    `{"a":{"b":[1,{"c":2}]}}`,
+			errorMsg: "cannot flatten /a/b/1: arrays can only hold scalars",
+		},
+		{
+			name:     "Keys joining to the same path",
+			input:    `{"a.b":1,"a":{"b":2}}`,
+			errorMsg: `more than one value has the key "a.b"`,
+		},
+		{
+			name:     "Top-level array",
+			input:    `[1]`,
+			errorMsg: "cannot flatten array: top-level value must be an object",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Parse([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, err := Flatten(data, ".")
+			if tc.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+					t.Errorf("expected error containing %q but got %v", tc.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected %v but got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	tests := []struct {
+		name     string
+		flat     map[string]interface{}
+		expected string
+		errorMsg string
+	}{
+		{name: "Flat keys", flat: map[string]interface{}{"a": "1", "b": "x"}, expected: `{"a":"1","b":"x"}`},
+		{
+			name:     "Dotted keys",
+			flat:     map[string]interface{}{"a.b.c": "1", "a.d": []interface{}{"x", "y"}, "e": "2"},
+			expected: `{"a":{"b":{"c":"1"},"d":["x","y"]},"e":"2"}`,
+		},
+		{name: "Empty", flat: map[string]interface{}{}, expected: `{}`},
+		{name: "Value and parent", flat: map[string]interface{}{"a": "1", "a.b": "2"}, errorMsg: `key "a" is both a value and the parent`},
+		{name: "Null value and parent", flat: map[string]interface{}{"a.b": nil, "a.b.c": "2"}, errorMsg: `key "a.b" is both`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Unflatten(tc.flat, ".")
+			if tc.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+					t.Errorf("expected error containing %q but got %v", tc.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			encoded, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(encoded) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, encoded)
+			}
+		})
+	}
+
+	// Unflatten undoes Flatten
+	document := `{"a":{"b":{"c":1},"d":[true,null]},"e":"x"}`
+	data, err := Parse([]byte(document))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	flat, err := Flatten(data, "/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := Unflatten(flat, "/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result, data) {
+		t.Errorf("expected %v after flattening and unflattening but got %v", data, result)
+	}
+}
@@ -0,0 +1,42 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// MaxFloatPrecision is the largest number of significant digits accepted by
+// RoundFloats. Seventeen digits are enough to represent any float64 exactly.
+const MaxFloatPrecision = 17
+
+// RoundFloats rewrites the floating-point numbers of a parsed JSON document, as
+// returned by Parse, with at most precision significant digits, using the
+// shortest of decimal and exponent notation as strconv.FormatFloat does with
+// the 'g' format. A number is floating-point when it is written with a decimal
+// point or an exponent, so integers such as 10 are left as they are, while 1.50
+// becomes 1.5 and 0.30000000000000004 becomes 0.3 with a precision of 3.
+// Numbers too large for a float64 are also left unchanged. The document is
+// changed in place and returned, as a top-level number is replaced.
+func RoundFloats(data interface{}, precision int) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = RoundFloats(value, precision)
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = RoundFloats(element, precision)
+		}
+	case json.Number:
+		if !strings.ContainsAny(string(v), ".eE") {
+			return v
+		}
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return v
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', precision, 64))
+	}
+	return data
+}
@@ -0,0 +1,80 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRoundFloats(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		precision int
+		expected  string
+	}{
+		{
+			name:      "Recurring decimals",
+			input:     `[0.30000000000000004,0.3333333333333333,0.6666666666666666]`,
+			precision: 3,
+			expected:  `[0.3,0.333,0.667]`,
+		},
+		{
+			name:      "Integers are unaffected",
+			input:     `[10,12345678901234567890,-7]`,
+			precision: 2,
+			expected:  `[10,12345678901234567890,-7]`,
+		},
+		{
+			name:      "Scientific notation",
+			input:     `[6.02214076e23,1.602176634E-19,1e3]`,
+			precision: 4,
+			expected:  `[6.022e+23,1.602e-19,1000]`,
+		},
+		{
+			name:      "Large values use an exponent",
+			input:     `[1234.5678]`,
+			precision: 2,
+			expected:  `[1.2e+03]`,
+		},
+		{
+			name:      "Trailing zeros are dropped",
+			input:     `[1.50,2.0]`,
+			precision: 5,
+			expected:  `[1.5,2]`,
+		},
+		{
+			name:      "Nested values",
+			input:     `{"a":{"b":[3.14159]},"c":"3.14159"}`,
+			precision: 3,
+			expected:  `{"a":{"b":[3.14]},"c":"3.14159"}`,
+		},
+		{
+			name:      "Top-level number",
+			input:     `2.718281828`,
+			precision: 2,
+			expected:  `2.7`,
+		},
+		{
+			name:      "Numbers too large for a float64",
+			input:     `[1.5e400]`,
+			precision: 2,
+			expected:  `[1.5e400]`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Parse([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("failed to parse input: %v", err)
+			}
+			result, err := json.Marshal(RoundFloats(data, tc.precision))
+			if err != nil {
+				t.Fatalf("failed to marshal result: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package jsonstr
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// WriteGzip calls write with a writer that compresses what is written to it with
+// gzip into w. The gzip stream is closed, which flushes it and writes its footer,
+// on every path: when write fails, w holds a complete gzip stream of the output
+// written before the error, and the error from write is returned.
+func WriteGzip(w io.Writer, write func(io.Writer) error) error {
+	gz := gzip.NewWriter(w)
+	err := write(gz)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// EncodeStreamGzip works like EncodeStream but compresses the output with gzip
+// as it is written, so a large document can be escaped to a compressed file
+// without holding the escaped text in memory. As with WriteGzip, the gzip stream
+// is closed on every path.
+func EncodeStreamGzip(w io.Writer, r io.Reader) error {
+	return WriteGzip(w, func(gz io.Writer) error {
+		return EncodeStream(gz, r)
+	})
+}
+
+// DecodeStreamGzip unescapes the escaped JSON string read from r like Decode,
+// writing the JSON to w compressed with gzip. Decoding parses and re-marshals
+// the whole document, so the input is read completely first, but the JSON is
+// compressed as it is written rather than collected in a buffer. As with
+// WriteGzip, the gzip stream is closed on every path, and is empty when the
+// input cannot be decoded.
+func DecodeStreamGzip(w io.Writer, r io.Reader, pretty bool) error {
+	return WriteGzip(w, func(gz io.Writer) error {
+		input, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		b := getBuffer()
+		defer putBuffer(b)
+		result, err := NewEscaper(EncodeOptions{Pretty: pretty}).unescapeInto(b, input)
+		if err != nil {
+			return err
+		}
+		_, err = gz.Write(result)
+		return err
+	})
+}
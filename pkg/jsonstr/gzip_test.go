@@ -0,0 +1,146 @@
+package jsonstr
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// gunzip decompresses the output of the gzip functions
+func gunzip(t *testing.T, data []byte) string {
+	t.Helper()
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("output is not gzip data: %v", err)
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("output is not a complete gzip stream: %v", err)
+	}
+	return string(decompressed)
+}
+
+func TestWriteGzip(t *testing.T) {
+	writeErr := errors.New("write failed")
+	var b bytes.Buffer
+	err := WriteGzip(&b, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "partial\n"); err != nil {
+			return err
+		}
+		return writeErr
+	})
+	if !errors.Is(err, writeErr) {
+		t.Fatalf("expected the write error but got %v", err)
+	}
+	// The stream is closed, so the output written before the error can be read
+	if output := gunzip(t, b.Bytes()); output != "partial\n" {
+		t.Errorf("expected %q but got %q", "partial\n", output)
+	}
+}
+
+func TestEncodeStreamGzip(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{name: "Object", input: `{"name": "John", "tags": ["a"]}`, expected: `{\"name\": \"John\", \"tags\": [\"a\"]}`},
+		// The gzip stream is still complete, holding what was written before the error
+		{name: "Invalid JSON", input: `{"a": 1, "b": }`, expected: ``, expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			err := EncodeStreamGzip(&b, strings.NewReader(tc.input))
+			if tc.expectError != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tc.expectError, err)
+			}
+			if output := gunzip(t, b.Bytes()); output != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, output)
+			}
+		})
+	}
+}
+
+func TestDecodeStreamGzip(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		pretty      bool
+		expected    string
+		expectError bool
+	}{
+		{name: "Object", input: `{\"b\":1,\"a\":[true]}`, expected: `{"a":[true],"b":1}`},
+		{name: "Pretty", input: `{\"a\":1}`, pretty: true, expected: "{\n  \"a\": 1\n}"},
+		{name: "Not JSON", input: `{\"a\":`, expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			err := DecodeStreamGzip(&b, strings.NewReader(tc.input), tc.pretty)
+			if tc.expectError != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tc.expectError, err)
+			}
+			if output := gunzip(t, b.Bytes()); output != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, output)
+			}
+		})
+	}
+}
+
+func TestDecodeStreamGzipReadError(t *testing.T) {
+	readErr := errors.New("read failed")
+	var b bytes.Buffer
+	if err := DecodeStreamGzip(&b, iotest.ErrReader(readErr), false); !errors.Is(err, readErr) {
+		t.Fatalf("expected the read error but got %v", err)
+	}
+	if output := gunzip(t, b.Bytes()); output != "" {
+		t.Errorf("expected an empty gzip stream but got %s", output)
+	}
+}
+
+// largeStreamInput is a document of several megabytes for the gzip benchmarks
+var largeStreamInput = []byte(`[` + strings.Repeat(`{"name":"John","bio":"Lorem ipsum \"dolor\" sit amet."},`, 100000) + `null]`)
+
+// BenchmarkEncodeStreamGzip compresses the output as it is written, so its
+// memory use stays flat however large the document is
+func BenchmarkEncodeStreamGzip(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(largeStreamInput)))
+	for i := 0; i < b.N; i++ {
+		if err := EncodeStreamGzip(io.Discard, bytes.NewReader(largeStreamInput)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEncodeGzipBuffered escapes the whole document into memory and then
+// compresses it, for comparison with BenchmarkEncodeStreamGzip
+func BenchmarkEncodeGzipBuffered(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(largeStreamInput)))
+	for i := 0; i < b.N; i++ {
+		escaped, err := EncodeBytes(largeStreamInput, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		if _, err := gz.Write(escaped); err != nil {
+			b.Fatal(err)
+		}
+		if err := gz.Close(); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.Copy(io.Discard, &compressed); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
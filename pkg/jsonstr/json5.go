@@ -0,0 +1,183 @@
+package jsonstr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EncodeJSON5 rewrites a JSON document as JSON5, which is easier for people to
+// read and edit as configuration. Object keys that are valid identifiers are
+// written without quotes, strings use single quotes, and each element of a
+// multiline object or array ends with a comma, including the last. Keys and
+// numbers keep their order and representation. Objects and arrays are indented
+// with indent, or the default indent when it is empty.
+func EncodeJSON5(input []byte, indent string) (string, error) {
+	if indent == "" {
+		indent = DefaultIndent
+	}
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+
+	w := &json5Writer{decoder: decoder, indent: indent}
+	if err := w.value(0); err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("invalid JSON: unexpected data after top-level value")
+	}
+	return w.b.String(), nil
+}
+
+// json5Writer writes the tokens of a JSON document as JSON5
+type json5Writer struct {
+	decoder *json.Decoder
+	indent  string
+	b       strings.Builder
+}
+
+// newline starts a new line indented to the given depth
+func (w *json5Writer) newline(depth int) {
+	w.b.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		w.b.WriteString(w.indent)
+	}
+}
+
+// value writes the next value of the document at the given nesting depth
+func (w *json5Writer) value(depth int) error {
+	token, err := w.decoder.Token()
+	if err != nil {
+		return err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			return w.object(depth)
+		}
+		if t == '[' {
+			return w.array(depth)
+		}
+		return fmt.Errorf("unexpected %q", rune(t))
+	case string:
+		w.b.WriteString(json5String(t))
+	case json.Number:
+		w.b.WriteString(t.String())
+	case bool:
+		if t {
+			w.b.WriteString("true")
+		} else {
+			w.b.WriteString("false")
+		}
+	case nil:
+		w.b.WriteString("null")
+	}
+	return nil
+}
+
+// object writes the members of an object whose opening brace has been read
+func (w *json5Writer) object(depth int) error {
+	w.b.WriteByte('{')
+	empty := true
+	for w.decoder.More() {
+		token, err := w.decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("object key must be a string")
+		}
+		empty = false
+		w.newline(depth + 1)
+		if isJSON5Identifier(key) {
+			w.b.WriteString(key)
+		} else {
+			w.b.WriteString(json5String(key))
+		}
+		w.b.WriteString(": ")
+		if err := w.value(depth + 1); err != nil {
+			return err
+		}
+		w.b.WriteByte(',')
+	}
+	if _, err := w.decoder.Token(); err != nil {
+		return err
+	}
+	if !empty {
+		w.newline(depth)
+	}
+	w.b.WriteByte('}')
+	return nil
+}
+
+// array writes the elements of an array whose opening bracket has been read
+func (w *json5Writer) array(depth int) error {
+	w.b.WriteByte('[')
+	empty := true
+	for w.decoder.More() {
+		empty = false
+		w.newline(depth + 1)
+		if err := w.value(depth + 1); err != nil {
+			return err
+		}
+		w.b.WriteByte(',')
+	}
+	if _, err := w.decoder.Token(); err != nil {
+		return err
+	}
+	if !empty {
+		w.newline(depth)
+	}
+	w.b.WriteByte(']')
+	return nil
+}
+
+// isJSON5Identifier reports whether key can be written as an unquoted JSON5
+// member name. Only ASCII identifiers are written unquoted, although JSON5 also
+// accepts Unicode letters. Reserved words such as "true" are valid member names.
+func isJSON5Identifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// json5String returns s as a single-quoted JSON5 string. Double quotes need no
+// escaping, while single quotes, backslashes, control characters and the line
+// separators U+2028 and U+2029 are escaped.
+func json5String(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('\'')
+	for _, r := range s {
+		if short, ok := shortEscapes[r]; ok {
+			b.WriteByte('\\')
+			b.WriteByte(short)
+			continue
+		}
+		switch {
+		case r == '\'' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f || r == '\u2028' || r == '\u2029':
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
@@ -0,0 +1,287 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestEncodeJSON5(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		indent      string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Object with identifier keys",
+			input:    `{"name":"John","age":30,"active":true,"spouse":null}`,
+			expected: "{\n  name: 'John',\n  age: 30,\n  active: true,\n  spouse: null,\n}",
+		},
+		{
+			name:     "Keys that are not identifiers stay quoted",
+			input:    `{"first-name":1,"2nd":2,"":3,"$ref":4,"_id":5,"true":6,"café":7}`,
+			expected: "{\n  'first-name': 1,\n  '2nd': 2,\n  '': 3,\n  $ref: 4,\n  _id: 5,\n  true: 6,\n  'café': 7,\n}",
+		},
+		{
+			name:     "Nested arrays and objects",
+			input:    `{"a":[1,[2,3],{"b":[]},{}]}`,
+			expected: "{\n  a: [\n    1,\n    [\n      2,\n      3,\n    ],\n    {\n      b: [],\n    },\n    {},\n  ],\n}",
+		},
+		{
+			name:     "String escapes",
+			input:    `["it's","say \"hi\"","C:\\dir","a\nb\tc","\u0007\u007f","\u2028"]`,
+			expected: "[\n  'it\\'s',\n  'say \"hi\"',\n  'C:\\\\dir',\n  'a\\nb\\tc',\n  '\\u0007\\u007f',\n  '\\u2028',\n]",
+		},
+		{
+			name:     "Numbers keep their representation",
+			input:    `[1.50,-0,2e10,12345678901234567890]`,
+			expected: "[\n  1.50,\n  -0,\n  2e10,\n  12345678901234567890,\n]",
+		},
+		{
+			name:     "Key order is kept",
+			input:    `{"b":1,"a":2}`,
+			indent:   "\t",
+			expected: "{\n\tb: 1,\n\ta: 2,\n}",
+		},
+		{
+			name:     "Top-level scalar",
+			input:    `"text"`,
+			expected: `'text'`,
+		},
+		{
+			name:        "Invalid JSON",
+			input:       `{"a":}`,
+			expectError: true,
+		},
+		{
+			name:        "Trailing data",
+			input:       `{} {}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := EncodeJSON5([]byte(tc.input), tc.indent)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, result)
+			}
+
+			parsed, err := parseJSON5(result)
+			if err != nil {
+				t.Fatalf("output is not valid JSON5: %v\n%s", err, result)
+			}
+			original, err := Parse([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("failed to parse input: %v", err)
+			}
+			if !reflect.DeepEqual(parsed, original) {
+				t.Errorf("round trip changed the value: expected %#v but got %#v", original, parsed)
+			}
+		})
+	}
+}
+
+// parseJSON5 parses the JSON5 that EncodeJSON5 writes: identifier or quoted
+// member names, single- or double-quoted strings, JSON numbers and trailing
+// commas. Comments, hexadecimal numbers and other JSON5 extensions are not
+// supported.
+func parseJSON5(s string) (interface{}, error) {
+	p := &json5Parser{s: s}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos != len(p.s) {
+		return nil, fmt.Errorf("unexpected data at offset %d", p.pos)
+	}
+	return value, nil
+}
+
+type json5Parser struct {
+	s   string
+	pos int
+}
+
+func (p *json5Parser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *json5Parser) peek() byte {
+	p.skipSpace()
+	if p.pos == len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *json5Parser) value() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '{':
+		p.pos++
+		object := map[string]interface{}{}
+		for p.peek() != '}' {
+			key, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			if p.peek() != ':' {
+				return nil, fmt.Errorf("expected ':' at offset %d", p.pos)
+			}
+			p.pos++
+			if object[key], err = p.value(); err != nil {
+				return nil, err
+			}
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		if p.peek() != '}' {
+			return nil, fmt.Errorf("expected '}' at offset %d", p.pos)
+		}
+		p.pos++
+		return object, nil
+	case c == '[':
+		p.pos++
+		array := []interface{}{}
+		for p.peek() != ']' {
+			element, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, element)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		if p.peek() != ']' {
+			return nil, fmt.Errorf("expected ']' at offset %d", p.pos)
+		}
+		p.pos++
+		return array, nil
+	case c == '\'' || c == '"':
+		return p.str()
+	case strings.HasPrefix(p.s[p.pos:], "true"):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(p.s[p.pos:], "false"):
+		p.pos += 5
+		return false, nil
+	case strings.HasPrefix(p.s[p.pos:], "null"):
+		p.pos += 4
+		return nil, nil
+	default:
+		start := p.pos
+		for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+			p.pos++
+		}
+		number := p.s[start:p.pos]
+		if !json.Valid([]byte(number)) {
+			return nil, fmt.Errorf("invalid value at offset %d", start)
+		}
+		return json.Number(number), nil
+	}
+}
+
+func (p *json5Parser) key() (string, error) {
+	if c := p.peek(); c == '\'' || c == '"' {
+		return p.str()
+	}
+	start := p.pos
+	for p.pos < len(p.s) && isJSON5Identifier(p.s[start:p.pos+1]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", fmt.Errorf("expected a member name at offset %d", p.pos)
+	}
+	return p.s[start:p.pos], nil
+}
+
+func (p *json5Parser) str() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\n' || c == '\r':
+			return "", fmt.Errorf("unescaped line break in string at offset %d", p.pos-1)
+		case c != '\\':
+			b.WriteByte(c)
+		case p.pos == len(p.s):
+			return "", fmt.Errorf("unterminated escape")
+		default:
+			escape := p.s[p.pos]
+			p.pos++
+			switch escape {
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				r, err := p.hex(4)
+				if err != nil {
+					return "", err
+				}
+				if utf16.IsSurrogate(r) && strings.HasPrefix(p.s[p.pos:], `\u`) {
+					p.pos += 2
+					low, err := p.hex(4)
+					if err != nil {
+						return "", err
+					}
+					r = utf16.DecodeRune(r, low)
+				}
+				b.WriteRune(r)
+			case 'x':
+				r, err := p.hex(2)
+				if err != nil {
+					return "", err
+				}
+				b.WriteRune(r)
+			default:
+				b.WriteByte(escape)
+			}
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *json5Parser) hex(digits int) (rune, error) {
+	if p.pos+digits > len(p.s) {
+		return 0, fmt.Errorf("short escape at offset %d", p.pos)
+	}
+	value, err := strconv.ParseUint(p.s[p.pos:p.pos+digits], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid escape at offset %d", p.pos)
+	}
+	p.pos += digits
+	return rune(value), nil
+}
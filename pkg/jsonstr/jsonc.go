@@ -0,0 +1,117 @@
+package jsonstr
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// commentSpan locates a comment in JSONC input: input[start:end] is the whole
+// comment, including its // or /* */ delimiters
+type commentSpan struct {
+	start, end int
+}
+
+// findComments returns the // line comments and /* */ block comments of JSONC
+// input, in order. Comment markers inside strings are ignored. A line comment
+// ends before the newline that ends it.
+func findComments(input []byte) ([]commentSpan, error) {
+	var comments []commentSpan
+	inString := false
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case inString:
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(input) && input[i+1] == '/':
+			end := bytes.IndexByte(input[i:], '\n')
+			if end < 0 {
+				end = len(input) - i
+			}
+			comments = append(comments, commentSpan{start: i, end: i + end})
+			i += end - 1
+		case c == '/' && i+1 < len(input) && input[i+1] == '*':
+			end := bytes.Index(input[i+2:], []byte("*/"))
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			comments = append(comments, commentSpan{start: i, end: i + 2 + end + 2})
+			i += 2 + end + 1
+		}
+	}
+	return comments, nil
+}
+
+// Comment is a comment removed from JSONC input by ExtractComments
+type Comment struct {
+	// Offset is the byte offset of the comment in the input
+	Offset int `json:"offset"`
+	// Line and Column are the 1-based line and byte column of the comment
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// Text is the whole comment, including its // or /* */ delimiters
+	Text string `json:"text"`
+}
+
+// StripComments removes the // and /* */ comments of JSONC input, such as a
+// configuration file, so it can be parsed as JSON. The spaces and tabs before a
+// line comment are removed with it, and the line breaks inside a block comment
+// are kept, so the remaining JSON stays on the same lines. Other JSONC
+// extensions, such as trailing commas, are not removed.
+func StripComments(input []byte) ([]byte, error) {
+	stripped, _, err := ExtractComments(input)
+	return stripped, err
+}
+
+// ExtractComments works like StripComments but also returns the comments it
+// removed, in order, located in the input, so they can be kept elsewhere and
+// associated with the JSON again later. The JSON keeps its lines, so a comment
+// is on the same line of the stripped input as in the original input.
+func ExtractComments(input []byte) ([]byte, []Comment, error) {
+	spans, err := findComments(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(spans) == 0 {
+		return input, nil, nil
+	}
+
+	result := make([]byte, 0, len(input))
+	comments := make([]Comment, 0, len(spans))
+	last := 0
+	// line and lineStart track the line of the input up to last
+	line, lineStart := 1, 0
+	for _, span := range spans {
+		for i := last; i < span.start; i++ {
+			if input[i] == '\n' {
+				line++
+				lineStart = i + 1
+			}
+		}
+		text := input[span.start:span.end]
+		comments = append(comments, Comment{Offset: span.start, Line: line, Column: span.start - lineStart + 1, Text: string(text)})
+
+		result = append(result, input[last:span.start]...)
+		if bytes.HasPrefix(text, []byte("//")) {
+			result = bytes.TrimRight(result, " \t")
+		} else {
+			for i, c := range text {
+				if c == '\n' || c == '\r' {
+					result = append(result, c)
+				}
+				if c == '\n' {
+					line++
+					lineStart = span.start + i + 1
+				}
+			}
+		}
+		last = span.end
+	}
+	return append(result, input[last:]...), comments, nil
+}
@@ -0,0 +1,123 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Line comments",
+			input:    "// header\n{\"a\":1, // one\n\"b\":2}",
+			expected: "\n{\"a\":1,\n\"b\":2}",
+		},
+		{
+			name:     "Block comments keep their newlines",
+			input:    "{/* a\r\nb */\"a\":1}",
+			expected: "{\r\n\"a\":1}",
+		},
+		{
+			name:     "Markers inside strings",
+			input:    `{"url":"http://x/*y*/","q":"\"//"}`,
+			expected: `{"url":"http://x/*y*/","q":"\"//"}`,
+		},
+		{
+			name:     "Spaces before a line comment",
+			input:    "[1, \t// one\n2 /* two */]",
+			expected: "[1,\n2 ]",
+		},
+		{
+			name:     "Comment at the end without a newline",
+			input:    "[1]//end",
+			expected: "[1]",
+		},
+		{
+			name:     "No comments",
+			input:    `{"a":"/"}`,
+			expected: `{"a":"/"}`,
+		},
+		{
+			name:        "Unterminated block comment",
+			input:       "[1] /* open",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := StripComments([]byte(tc.input))
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, result)
+			}
+			if !json.Valid(result) {
+				t.Errorf("result is not valid JSON: %s", result)
+			}
+		})
+	}
+}
+
+func TestExtractComments(t *testing.T) {
+	input := "// settings\n{\n  \"a\": \"x // y\", /* first\n  second */ \"b\": 1 // end\n}\n/* tail */"
+
+	stripped, comments, err := ExtractComments([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedStripped := "\n{\n  \"a\": \"x // y\", \n \"b\": 1\n}\n"
+	if string(stripped) != expectedStripped {
+		t.Errorf("expected stripped input %q but got %q", expectedStripped, stripped)
+	}
+
+	expected := []Comment{
+		{Offset: 0, Line: 1, Column: 1, Text: "// settings"},
+		{Offset: 31, Line: 3, Column: 18, Text: "/* first\n  second */"},
+		{Offset: 59, Line: 4, Column: 20, Text: "// end"},
+		{Offset: 68, Line: 6, Column: 1, Text: "/* tail */"},
+	}
+	if len(comments) != len(expected) {
+		t.Fatalf("expected %d comments but got %v", len(expected), comments)
+	}
+	lines := strings.Split(input, "\n")
+	for i, comment := range comments {
+		if comment != expected[i] {
+			t.Errorf("expected comment %+v but got %+v", expected[i], comment)
+		}
+		// The offset, and the line and column, both locate the comment in the input
+		if !strings.HasPrefix(input[comment.Offset:], comment.Text) {
+			t.Errorf("offset %d does not locate %q", comment.Offset, comment.Text)
+		}
+		if !strings.HasPrefix(lines[comment.Line-1][comment.Column-1:], strings.SplitN(comment.Text, "\n", 2)[0]) {
+			t.Errorf("line %d, column %d does not locate %q", comment.Line, comment.Column, comment.Text)
+		}
+	}
+}
+
+func TestExtractCommentsWithout(t *testing.T) {
+	input := []byte(`{"a": "/* not a comment */"}`)
+	stripped, comments, err := ExtractComments(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(stripped) != string(input) || len(comments) != 0 {
+		t.Errorf("expected the input unchanged and no comments but got %s and %v", stripped, comments)
+	}
+	if _, _, err := ExtractComments([]byte(`{} /* open`)); err == nil {
+		t.Errorf("expected an error for an unterminated comment")
+	}
+}
@@ -1,73 +1,263 @@
 package jsonstr
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"io"
 )
 
+// DefaultIndent is the indentation used for pretty output when none is specified
+const DefaultIndent = "  "
+
+// EncodeOptions configures EncodeWithOptions and DecodeWithOptions
+type EncodeOptions struct {
+	// Compact removes newlines and extra whitespace from the JSON before encoding
+	Compact bool
+	// StripWhitespace removes only insignificant whitespace before encoding, keeping
+	// key order, duplicate keys and number formatting exactly as in the input
+	StripWhitespace bool
+	// StrictKeys rejects documents in which an object has duplicate keys
+	StrictKeys bool
+	// ASCIIKeys rejects documents in which an object key, as written in the JSON
+	// to be escaped, contains non-ASCII characters
+	ASCIIKeys bool
+	// EscapeNonASCIIKeys writes the non-ASCII characters of object keys as \uXXXX
+	// escapes in the JSON to be escaped, so ASCIIKeys accepts them
+	EscapeNonASCIIKeys bool
+	// Pretty indents the JSON before encoding, or formats the decoded output
+	Pretty bool
+	// Indent is the indentation used when Pretty is set (DefaultIndent if empty)
+	Indent string
+	// Wrap soft-wraps string values so pretty decoded lines stay within this many
+	// columns (0 disables). Wrapped output is for display only and is not valid JSON.
+	Wrap int
+	// Align pads the keys of each object in pretty decoded output so that the colons
+	// of its members line up. The output is still valid JSON.
+	Align bool
+	// CompactThreshold keeps objects and arrays in pretty decoded output on a single
+	// line, such as {"x": 1, "y": 2}, when that line is shorter than this many
+	// characters (0 disables). Longer ones are expanded as usual.
+	CompactThreshold int
+	// PrettyDepth indents pretty decoded output only down to this many levels of
+	// nesting. Objects and arrays nested deeper are written compactly on one line
+	// (0 disables).
+	PrettyDepth int
+	// IndentJSONStrings pretty-prints, with Indent, the string values of decoded
+	// output that hold JSON objects or arrays, as IndentJSONStrings does
+	IndentJSONStrings bool
+}
+
+// indent returns the configured indentation, falling back to DefaultIndent
+func (o EncodeOptions) indent() string {
+	if o.Indent == "" {
+		return DefaultIndent
+	}
+	return o.Indent
+}
+
 // Encode takes a JSON byte slice and returns a properly escaped string representation
 // If compact is true, it will remove newlines and extra whitespace from the input
 func Encode(input []byte, compact bool) (string, error) {
+	return EncodeWithOptions(input, EncodeOptions{Compact: compact})
+}
+
+// EncodeBytes works like Encode but returns the escaped text as a byte slice,
+// avoiding a copy for callers that write it to a buffer or an io.Writer
+func EncodeBytes(input []byte, compact bool) ([]byte, error) {
+	return NewEscaper(EncodeOptions{Compact: compact}).EscapeBytes(input)
+}
+
+// EncodeWithOptions takes a JSON byte slice and returns a properly escaped string
+// representation, formatting the JSON first as configured by opts
+func EncodeWithOptions(input []byte, opts EncodeOptions) (string, error) {
+	return NewEscaper(opts).Escape(input)
+}
+
+// EncodeJSONString works like EncodeWithOptions but keeps the surrounding quotes,
+// so the result is itself a valid JSON document holding the escaped string
+func EncodeJSONString(input []byte, opts EncodeOptions) (string, error) {
+	return NewEscaper(opts).escape(input, true)
+}
+
+// EscapeRaw escapes arbitrary text as the body of a JSON string, in the same way
+// as Encode escapes a document, but without checking that the text is JSON or
+// formatting it. Invalid UTF-8 sequences, such as a character cut in two at the
+// end of the text, are replaced with U+FFFD.
+func EscapeRaw(input []byte) string {
+	return escapeString(string(input))
+}
+
+// Prepare validates a JSON byte slice and returns the JSON text that would be escaped
+// If compact is true, the JSON is re-marshaled to remove newlines and extra whitespace
+func Prepare(input []byte, compact bool) (string, error) {
+	return PrepareWithOptions(input, EncodeOptions{Compact: compact})
+}
+
+// PrepareWithOptions validates a JSON byte slice and returns the JSON text that would
+// be escaped. With Compact or Pretty set the JSON is re-marshaled, which sorts object
+// keys in the same way as the decoded output, unless with Compact it is already in
+// that form. StripWhitespace only removes whitespace. The keys are checked or
+// escaped for ASCIIKeys and EscapeNonASCIIKeys once the text is formatted, as
+// re-marshaling writes characters that were escaped in the input as they are.
+func PrepareWithOptions(input []byte, opts EncodeOptions) (string, error) {
+	prepared, err := prepare(input, opts)
+	if err != nil || !(opts.ASCIIKeys || opts.EscapeNonASCIIKeys) {
+		return prepared, err
+	}
+
+	result := []byte(prepared)
+	if opts.EscapeNonASCIIKeys {
+		if result, err = EscapeNonASCIIKeys(result); err != nil {
+			return "", err
+		}
+	}
+	if opts.ASCIIKeys {
+		if err := CheckASCIIKeys(result); err != nil {
+			return "", err
+		}
+	}
+	return string(result), nil
+}
+
+// prepare validates and formats the JSON for PrepareWithOptions
+func prepare(input []byte, opts EncodeOptions) (string, error) {
+	if opts.Compact && opts.Pretty {
+		return "", fmt.Errorf("compact and pretty options cannot be combined")
+	}
+	if opts.StripWhitespace && (opts.Compact || opts.Pretty) {
+		return "", fmt.Errorf("strip whitespace option cannot be combined with compact or pretty")
+	}
+
+	// Input that is already in compact, sorted form is used as it is. Its keys
+	// are unique, so it also passes StrictKeys.
+	if opts.Compact {
+		if compact, ok := marshaledForm(input); ok {
+			return string(compact), nil
+		}
+	}
+
 	// Validate that the input is valid JSON
 	var temp interface{}
 	if err := json.Unmarshal(input, &temp); err != nil {
 		return "", fmt.Errorf("invalid JSON: %w", err)
 	}
+	if opts.StrictKeys {
+		if err := CheckDuplicateKeys(input); err != nil {
+			return "", err
+		}
+	}
 
-	var jsonStr string
-	if compact {
-		// If compact mode is enabled, re-marshal the JSON to remove formatting
-		compactBytes, err := json.Marshal(temp)
+	if opts.Pretty {
+		// Re-marshal with indentation so the escaped string decodes to pretty JSON
+		prettyBytes, err := json.MarshalIndent(temp, "", opts.indent())
 		if err != nil {
+			return "", fmt.Errorf("error formatting JSON: %w", err)
+		}
+		return string(prettyBytes), nil
+	}
+
+	if opts.StripWhitespace {
+		// Unlike Compact, json.Compact works on the raw text and changes nothing else
+		b := getBuffer()
+		defer putBuffer(b)
+		if err := json.Compact(&b.buf, input); err != nil {
 			return "", fmt.Errorf("error compacting JSON: %w", err)
 		}
-		jsonStr = string(compactBytes)
-	} else {
-		jsonStr = string(input)
+		return b.buf.String(), nil
 	}
 
-	// Convert the JSON to a string with proper escaping
-	result, err := json.Marshal(jsonStr)
-	if err != nil {
-		return "", fmt.Errorf("error encoding JSON: %w", err)
+	if !opts.Compact {
+		return string(input), nil
 	}
 
-	// The result is a JSON string, so we need to remove the outer quotes
-	return strings.Trim(string(result), "\""), nil
+	// If compact mode is enabled, re-marshal the JSON to remove formatting
+	compactBytes, err := json.Marshal(temp)
+	if err != nil {
+		return "", fmt.Errorf("error compacting JSON: %w", err)
+	}
+	return string(compactBytes), nil
 }
 
 // Decode takes an escaped JSON string and converts it back to JSON
 // If pretty is true, it will format the output JSON with indentation
 func Decode(input []byte, pretty bool) (string, error) {
-	// First, we need to add quotes to make it a valid JSON string
-	quotedInput := fmt.Sprintf("\"%s\"", string(input))
+	return DecodeWithOptions(input, EncodeOptions{Pretty: pretty})
+}
+
+// DecodeBytes works like Decode but returns the JSON as a byte slice, avoiding
+// a copy for callers that write it to a buffer or an io.Writer
+func DecodeBytes(input []byte, pretty bool) ([]byte, error) {
+	return NewEscaper(EncodeOptions{Pretty: pretty}).UnescapeBytes(input)
+}
 
-	// Unmarshal the string to get the actual JSON string with escapes interpreted
-	var jsonString string
-	if err := json.Unmarshal([]byte(quotedInput), &jsonString); err != nil {
+// DecodeWithOptions takes an escaped JSON string and converts it back to JSON
+// If opts.Pretty is set, the output is indented with opts.Indent and long string
+// values are wrapped at opts.Wrap columns
+func DecodeWithOptions(input []byte, opts EncodeOptions) (string, error) {
+	return NewEscaper(opts).Unescape(input)
+}
+
+// DecodeVerbatim unescapes an escaped JSON string without parsing and
+// re-marshaling the JSON it holds, so the result is exactly the text that was
+// escaped, with its layout, key order and number formatting. The result is not
+// validated as JSON.
+func DecodeVerbatim(input []byte) (string, error) {
+	quoted := make([]byte, 0, len(input)+2)
+	quoted = append(quoted, '"')
+	quoted = append(quoted, input...)
+	quoted = append(quoted, '"')
+
+	var text string
+	if err := json.Unmarshal(quoted, &text); err != nil {
 		return "", fmt.Errorf("invalid JSON string: %w", err)
 	}
+	return text, nil
+}
 
-	// Validate that the result is valid JSON
-	var parsedJSON interface{}
-	if err := json.Unmarshal([]byte(jsonString), &parsedJSON); err != nil {
-		return "", fmt.Errorf("decoded string is not valid JSON: %w", err)
+// Parse unmarshals a single JSON document into maps, slices and scalar values.
+// Numbers are kept as json.Number so their original representation is preserved.
+func Parse(input []byte) (interface{}, error) {
+	value, err := parse(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
 	}
+	return value, nil
+}
 
-	// Format the output according to the pretty flag
-	if pretty {
-		prettyBytes, err := json.MarshalIndent(parsedJSON, "", "  ")
-		if err != nil {
-			return "", fmt.Errorf("error formatting JSON: %w", err)
-		}
-		return string(prettyBytes), nil
+// parse unmarshals a single JSON document, keeping numbers as json.Number
+// so their original representation is preserved
+func parse(input []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unexpected data after top-level value")
 	}
+	return value, nil
+}
 
-	// Return the compact JSON
-	compactBytes, err := json.Marshal(parsedJSON)
-	if err != nil {
-		return "", fmt.Errorf("error marshaling JSON: %w", err)
+// typeName returns the JSON type name of a parsed value
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
 	}
-	return string(compactBytes), nil
 }
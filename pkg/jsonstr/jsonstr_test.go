@@ -502,6 +502,65 @@ func TestDecodeUnmarshalEdgeCases(t *testing.T) {
 	})
 }
 
+func TestDecodeVerbatim(t *testing.T) {
+	inputs := []string{
+		"{\"b\": 1,\n \"a\": 2.50}\n",
+		`{"s":"say \"hi\"\n","e":"caf\u00e9"}`,
+		"[1e3, -0, \"\u2028\"]",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			encoded, err := Encode([]byte(input), false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, err := DecodeVerbatim([]byte(encoded))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != input {
+				t.Errorf("expected %q but got %q", input, result)
+			}
+		})
+	}
+
+	if _, err := DecodeVerbatim([]byte(`a"b`)); err == nil {
+		t.Errorf("expected error for an unescaped quote but got none")
+	}
+}
+
+func TestEscapeRaw(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Part of a document", input: `"a": [1, <2>`, expected: `\"a\": [1, \u003c2\u003e`},
+		{name: "Control characters", input: "x\ty\n", expected: `x\ty\n`},
+		{name: "Cut multibyte character", input: "caf\xc3", expected: "caf\ufffd"},
+		{name: "Empty", input: "", expected: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := EscapeRaw([]byte(tc.input)); result != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+
+	// A whole document is escaped as Encode escapes it
+	input := []byte("{\"a\": \"<b>\"}\n")
+	encoded, err := Encode(input, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := EscapeRaw(input); result != encoded {
+		t.Errorf("expected %s but got %s", encoded, result)
+	}
+}
+
 // Test both functions with nil input
 func TestNilInput(t *testing.T) {
 	t.Run("Encode with nil input", func(t *testing.T) {
@@ -518,3 +577,193 @@ func TestNilInput(t *testing.T) {
 		}
 	})
 }
+
+func TestEncodeWithOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		opts        EncodeOptions
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Default options keep input",
+			input:    `{"b":1, "a":2}`,
+			opts:     EncodeOptions{},
+			expected: `{\"b\":1, \"a\":2}`,
+		},
+		{
+			name:     "Pretty with default indent",
+			input:    `{"name":"John","tags":["a"]}`,
+			opts:     EncodeOptions{Pretty: true},
+			expected: `{\n  \"name\": \"John\",\n  \"tags\": [\n    \"a\"\n  ]\n}`,
+		},
+		{
+			name:     "Pretty with custom indent",
+			input:    `{"a":[1]}`,
+			opts:     EncodeOptions{Pretty: true, Indent: "\t"},
+			expected: `{\n\t\"a\": [\n\t\t1\n\t]\n}`,
+		},
+		{
+			name:     "Pretty scalar",
+			input:    `42`,
+			opts:     EncodeOptions{Pretty: true},
+			expected: `42`,
+		},
+		{
+			name:     "Compact",
+			input:    "{\n  \"a\": 1\n}",
+			opts:     EncodeOptions{Compact: true},
+			expected: `{\"a\":1}`,
+		},
+		{
+			name:     "Strip whitespace keeps key order and numbers",
+			input:    "{\n  \"b\": 1.50,\n  \"a\": [1e2, \"x y\"]\n}",
+			opts:     EncodeOptions{StripWhitespace: true},
+			expected: `{\"b\":1.50,\"a\":[1e2,\"x y\"]}`,
+		},
+		{
+			name:     "Strip whitespace keeps duplicate keys",
+			input:    `{"a": 1, "a": 2}`,
+			opts:     EncodeOptions{StripWhitespace: true},
+			expected: `{\"a\":1,\"a\":2}`,
+		},
+		{
+			name:        "Strip whitespace and compact",
+			input:       `{}`,
+			opts:        EncodeOptions{StripWhitespace: true, Compact: true},
+			expectError: true,
+		},
+		{
+			name:     "String ending in a quote",
+			input:    `"hi"`,
+			opts:     EncodeOptions{},
+			expected: `\"hi\"`,
+		},
+		{
+			name:        "Compact and pretty",
+			input:       `{}`,
+			opts:        EncodeOptions{Compact: true, Pretty: true},
+			expectError: true,
+		},
+		{
+			name:        "Invalid JSON",
+			input:       `{"a":`,
+			opts:        EncodeOptions{Pretty: true},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := EncodeWithOptions([]byte(tc.input), tc.opts)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				if result != tc.expected {
+					t.Errorf("expected %s but got %s", tc.expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeJSONString(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		opts        EncodeOptions
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Object",
+			input:    `{"a":"b"}`,
+			opts:     EncodeOptions{},
+			expected: `"{\"a\":\"b\"}"`,
+		},
+		{
+			name:     "Compact",
+			input:    "{\n  \"a\": 1\n}",
+			opts:     EncodeOptions{Compact: true},
+			expected: `"{\"a\":1}"`,
+		},
+		{
+			name:     "Top-level string",
+			input:    `"hi"`,
+			opts:     EncodeOptions{},
+			expected: `"\"hi\""`,
+		},
+		{
+			name:        "Invalid JSON",
+			input:       `{"a":`,
+			opts:        EncodeOptions{},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := EncodeJSONString([]byte(tc.input), tc.opts)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+
+			// The result must be a JSON string holding the original document
+			var decoded string
+			if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+				t.Fatalf("result is not a valid JSON string: %v", err)
+			}
+			prepared, _ := PrepareWithOptions([]byte(tc.input), tc.opts)
+			if decoded != prepared {
+				t.Errorf("expected the JSON string to hold %s but got %s", prepared, decoded)
+			}
+		})
+	}
+}
+
+// Test that pretty encoding and pretty decoding produce the same layout
+func TestPrettyRoundTrip(t *testing.T) {
+	input := []byte(`{"name":"John","address":{"city":"New York","zip":"10001"},"tags":["a","b"],"age":30}`)
+
+	for _, indent := range []string{"", "    ", "\t"} {
+		opts := EncodeOptions{Pretty: true, Indent: indent}
+
+		encoded, err := EncodeWithOptions(input, opts)
+		if err != nil {
+			t.Fatalf("unexpected error encoding: %v", err)
+		}
+		decoded, err := DecodeWithOptions([]byte(encoded), opts)
+		if err != nil {
+			t.Fatalf("unexpected error decoding: %v", err)
+		}
+		prepared, err := PrepareWithOptions(input, opts)
+		if err != nil {
+			t.Fatalf("unexpected error preparing: %v", err)
+		}
+
+		if decoded != prepared {
+			t.Errorf("expected round trip with indent %q to reproduce\n%s\nbut got\n%s", indent, prepared, decoded)
+		}
+		if !strings.Contains(decoded, "\n"+opts.indent()+`"address": {`) {
+			t.Errorf("expected indented output with indent %q but got\n%s", indent, decoded)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package jsonstr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxJSONStringDepth is how many levels of JSON nested in strings inside each
+// other IndentJSONStrings formats. Strings nested deeper are left as they are.
+const MaxJSONStringDepth = 8
+
+// IndentJSONStrings pretty-prints the string values of a JSON document that hold
+// a serialized JSON object or array, such as "{\"a\":1}", so embedded payloads
+// are easier to read. Each becomes a string holding the same JSON indented with
+// indent, and JSON in the strings of that JSON is formatted too, down to
+// MaxJSONStringDepth levels. The strings keep their key order and number
+// formatting, and the result is still valid JSON in which they are strings.
+// Object keys and other strings are unchanged.
+func IndentJSONStrings(input []byte, indent string) ([]byte, error) {
+	return indentJSONStrings(input, indent, MaxJSONStringDepth)
+}
+
+// indentJSONStrings formats the JSON strings of input down to depth levels
+func indentJSONStrings(input []byte, indent string, depth int) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+
+	var stack []*keyFrame
+	var result []byte
+	last, previous := 0, 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) && len(stack) > 0 {
+			return nil, errors.New("invalid JSON: unexpected end of JSON input")
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		start := previous
+		previous = int(decoder.InputOffset())
+
+		var top *keyFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if top != nil && top.expectKey {
+			top.expectKey = false
+			continue
+		}
+		if top != nil && top.object {
+			top.expectKey = true
+		}
+
+		switch token {
+		case json.Delim('{'):
+			stack = append(stack, &keyFrame{object: true, expectKey: true})
+			continue
+		case json.Delim('['):
+			stack = append(stack, &keyFrame{})
+			continue
+		}
+
+		value, ok := token.(string)
+		if !ok {
+			continue
+		}
+		formatted, ok, err := indentJSONString(value, indent, depth)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		// The token may be preceded by whitespace and a separator
+		tokenStart := start + bytes.IndexByte(input[start:previous], '"')
+		result = append(result, input[last:tokenStart]...)
+		result = append(result, formatted...)
+		last = previous
+	}
+	if result == nil {
+		return input, nil
+	}
+	return append(result, input[last:]...), nil
+}
+
+// indentJSONString returns value formatted by IndentJSONStrings as a quoted JSON
+// string, or false when value does not hold a JSON object or array
+func indentJSONString(value, indent string, depth int) ([]byte, bool, error) {
+	inner := bytes.TrimSpace([]byte(value))
+	if len(inner) == 0 || (inner[0] != '{' && inner[0] != '[') || !json.Valid(inner) {
+		return nil, false, nil
+	}
+	if depth > 1 {
+		var err error
+		if inner, err = indentJSONStrings(inner, indent, depth-1); err != nil {
+			return nil, false, err
+		}
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, inner, "", indent); err != nil {
+		return nil, false, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return []byte(`"` + escapeString(b.String()) + `"`), true, nil
+}
@@ -0,0 +1,121 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestIndentJSONStrings(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Object in a string",
+			input:    `{"a":"{\"b\":1}"}`,
+			expected: `{"a":"{\n  \"b\": 1\n}"}`,
+		},
+		{
+			name:     "Array in a string, with surrounding whitespace",
+			input:    `[" [1, 2] "]`,
+			expected: `["[\n  1,\n  2\n]"]`,
+		},
+		{
+			name:     "Key order and numbers are kept",
+			input:    `{"a":"{\"z\":1.50,\"y\":1e3}"}`,
+			expected: `{"a":"{\n  \"z\": 1.50,\n  \"y\": 1e3\n}"}`,
+		},
+		{
+			name:     "JSON nested in the string of a string",
+			input:    `{"a":"{\"b\":\"[1]\"}"}`,
+			expected: `{"a":"{\n  \"b\": \"[\\n  1\\n]\"\n}"}`,
+		},
+		{
+			name:     "Keys holding JSON are unchanged",
+			input:    `{"{\"b\":1}":1}`,
+			expected: `{"{\"b\":1}":1}`,
+		},
+		{
+			name:     "Scalars and invalid JSON are unchanged",
+			input:    `{"a": "123", "b": "true", "c": "{not json", "d": "", "e": "[1,"}`,
+			expected: `{"a": "123", "b": "true", "c": "{not json", "d": "", "e": "[1,"}`,
+		},
+		{
+			name:     "Layout of the document is kept",
+			input:    "{\n  \"a\": [\"{}\", 1]\n}",
+			expected: "{\n  \"a\": [\"{}\", 1]\n}",
+		},
+		{
+			name:        "Invalid JSON",
+			input:       `{"a":`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := IndentJSONStrings([]byte(tc.input), "  ")
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got %s", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+			if !json.Valid(result) {
+				t.Errorf("result is not valid JSON: %s", result)
+			}
+		})
+	}
+}
+
+func TestIndentJSONStringsDepth(t *testing.T) {
+	// Nest an object in a string one level deeper than the cap
+	document := `{"a":1}`
+	for i := 0; i <= MaxJSONStringDepth; i++ {
+		quoted, err := json.Marshal(document)
+		if err != nil {
+			t.Fatalf("failed to marshal document: %v", err)
+		}
+		document = `{"a":` + string(quoted) + `}`
+	}
+
+	result, err := IndentJSONStrings([]byte(document), "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := string(result)
+	for level := 1; level <= MaxJSONStringDepth+1; level++ {
+		var outer map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &outer); err != nil {
+			t.Fatalf("level %d is not valid JSON: %v", level, err)
+		}
+		text = outer["a"].(string)
+		indented := strings.Contains(text, "\n")
+		if level <= MaxJSONStringDepth && !indented {
+			t.Errorf("expected level %d to be indented but got %s", level, text)
+		}
+		if level > MaxJSONStringDepth && indented {
+			t.Errorf("expected level %d to be left as it is but got %s", level, text)
+		}
+	}
+}
+
+func TestDecodeIndentJSONStrings(t *testing.T) {
+	result, err := DecodeWithOptions([]byte(`{\"a\":\"[1]\"}`), EncodeOptions{IndentJSONStrings: true, Indent: "\t"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"a":"[\n\t1\n]"}`
+	if result != expected {
+		t.Errorf("expected %s but got %s", expected, result)
+	}
+}
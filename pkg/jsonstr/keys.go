@@ -0,0 +1,152 @@
+package jsonstr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// keyFrame tracks an open object or array while scanning object keys
+type keyFrame struct {
+	object    bool
+	keys      map[string]struct{}
+	expectKey bool
+	index     int
+	// token is the key or array index of the value currently being scanned
+	token string
+}
+
+// CheckDuplicateKeys scans a JSON document token by token and returns an error
+// naming the first object key that appears more than once within the same
+// object. Unmarshaling silently keeps the last of several duplicate keys, so
+// this is the only way to detect them.
+func CheckDuplicateKeys(input []byte) error {
+	return scanKeys(input, func(stack []*keyFrame, key string, start, end int) error {
+		top := stack[len(stack)-1]
+		if _, seen := top.keys[key]; seen {
+			return fmt.Errorf("duplicate key %q at %s", key, formatPointer(keyPath(stack)))
+		}
+		top.keys[key] = struct{}{}
+		return nil
+	})
+}
+
+// CheckASCIIKeys returns an error naming the first object key of a JSON document
+// that contains non-ASCII characters. Keys are checked as they are written, so
+// a key whose characters are written as \uXXXX escapes is accepted.
+func CheckASCIIKeys(input []byte) error {
+	return scanKeys(input, func(stack []*keyFrame, key string, start, end int) error {
+		if !isASCII(input[start:end]) {
+			return fmt.Errorf("non-ASCII key %q at %s", key, formatPointer(keyPath(stack)))
+		}
+		return nil
+	})
+}
+
+// EscapeNonASCIIKeys rewrites the non-ASCII characters of the object keys of a
+// JSON document as \uXXXX escapes, as EscapeNonASCII does for a whole document,
+// so the keys are pure ASCII while still decoding to the same strings. Values
+// and the layout of the document are left as they are.
+func EscapeNonASCIIKeys(input []byte) ([]byte, error) {
+	var result []byte
+	last := 0
+	err := scanKeys(input, func(stack []*keyFrame, key string, start, end int) error {
+		if isASCII(input[start:end]) {
+			return nil
+		}
+		result = append(result, input[last:start]...)
+		result = append(result, EscapeNonASCII(string(input[start:end]))...)
+		last = end
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return input, nil
+	}
+	return append(result, input[last:]...), nil
+}
+
+// scanKeys scans a JSON document token by token and calls visit with each
+// object key and the stack of open containers, whose last frame is the object
+// holding the key. input[start:end] is the key as written, which may be
+// preceded by whitespace and a comma.
+func scanKeys(input []byte, visit func(stack []*keyFrame, key string, start, end int) error) error {
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+
+	var stack []*keyFrame
+	var previous int
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+		start := previous
+		previous = int(decoder.InputOffset())
+
+		var top *keyFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if top != nil && top.expectKey {
+			key := token.(string)
+			top.token = key
+			top.expectKey = false
+			if err := visit(stack, key, start, previous); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// A value: record its position in the enclosing container
+		if top != nil {
+			if top.object {
+				top.expectKey = true
+			} else {
+				top.token = strconv.Itoa(top.index)
+				top.index++
+			}
+		}
+
+		switch token {
+		case json.Delim('{'):
+			stack = append(stack, &keyFrame{object: true, keys: map[string]struct{}{}, expectKey: true})
+		case json.Delim('['):
+			stack = append(stack, &keyFrame{})
+		}
+	}
+}
+
+// isASCII reports whether text holds only ASCII characters
+func isASCII(text []byte) bool {
+	for _, c := range text {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// keyPath returns the reference tokens leading to the value being scanned
+func keyPath(stack []*keyFrame) []string {
+	path := make([]string, len(stack))
+	for i, frame := range stack {
+		path[i] = frame.token
+	}
+	return path
+}
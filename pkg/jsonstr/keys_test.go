@@ -0,0 +1,122 @@
+package jsonstr
+
+import "testing"
+
+func TestCheckDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{name: "Scalar", input: `42`},
+		{name: "Unique keys", input: `{"a":1,"b":{"a":2},"c":[{"a":3},{"a":4}]}`},
+		{name: "Same key in sibling objects", input: `[{"a":1},{"a":2}]`},
+		{name: "Duplicate at top level", input: `{"a":1,"b":2,"a":3}`, expectedErr: `duplicate key "a" at /a`},
+		{name: "Duplicate in nested object", input: `{"x":{"y":true,"y":false}}`, expectedErr: `duplicate key "y" at /x/y`},
+		{name: "Duplicate inside array", input: `{"list":[{},{"k":1,"k":2}]}`, expectedErr: `duplicate key "k" at /list/1/k`},
+		{name: "Duplicate after nested container", input: `{"a":{"b":[1]},"a":null}`, expectedErr: `duplicate key "a" at /a`},
+		{name: "Key needing escaping", input: `{"a/b":1,"a/b":2}`, expectedErr: `duplicate key "a/b" at /a~1b`},
+		{name: "Invalid JSON", input: `{"a":}`, expectedErr: `invalid JSON: missing value after object key`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckDuplicateKeys([]byte(tc.input))
+
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expectedErr {
+				t.Errorf("expected error %q but got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestStrictKeys(t *testing.T) {
+	opts := EncodeOptions{StrictKeys: true}
+
+	if _, err := EncodeWithOptions([]byte(`{"a":1,"a":2}`), opts); err == nil {
+		t.Errorf("expected encoding duplicate keys to fail")
+	}
+	if _, err := DecodeWithOptions([]byte(`{\"a\":1,\"a\":2}`), opts); err == nil {
+		t.Errorf("expected decoding duplicate keys to fail")
+	}
+
+	// Without StrictKeys the last duplicate wins, as before
+	result, err := DecodeWithOptions([]byte(`{\"a\":1,\"a\":2}`), EncodeOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != `{"a":2}` {
+		t.Errorf("expected {\"a\":2} but got %s", result)
+	}
+}
+
+func TestASCIIKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		opts        EncodeOptions
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "ASCII keys are accepted",
+			input:    `{"cafe":"café"}`,
+			opts:     EncodeOptions{ASCIIKeys: true},
+			expected: `{"cafe":"café"}`,
+		},
+		{
+			name:        "Non-ASCII key is rejected",
+			input:       `{"menu":[{"café":1}]}`,
+			opts:        EncodeOptions{ASCIIKeys: true},
+			expectedErr: `non-ASCII key "café" at /menu/0/café`,
+		},
+		{
+			name:     "Escaped non-ASCII key is accepted",
+			input:    `{"caf\u00e9":1}`,
+			opts:     EncodeOptions{ASCIIKeys: true},
+			expected: `{"caf\u00e9":1}`,
+		},
+		{
+			name:        "Escaped key written as a character by compacting",
+			input:       `{"caf\u00e9": 1}`,
+			opts:        EncodeOptions{ASCIIKeys: true, Compact: true},
+			expectedErr: `non-ASCII key "café" at /café`,
+		},
+		{
+			name:     "Non-ASCII key is escaped",
+			input:    `{"café": "café", "list": [{"😀": true}]}`,
+			opts:     EncodeOptions{ASCIIKeys: true, EscapeNonASCIIKeys: true},
+			expected: `{"caf\u00e9": "café", "list": [{"\ud83d\ude00": true}]}`,
+		},
+		{
+			name:     "Keys are escaped after pretty-printing",
+			input:    `{"é":1}`,
+			opts:     EncodeOptions{EscapeNonASCIIKeys: true, Pretty: true},
+			expected: "{\n  \"\\u00e9\": 1\n}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := PrepareWithOptions([]byte(tc.input), tc.opts)
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Errorf("expected error %q but got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, result)
+			}
+		})
+	}
+}
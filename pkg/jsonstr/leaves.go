@@ -0,0 +1,49 @@
+package jsonstr
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Leaf is a value of a JSON document that holds no other values, with the JSON
+// Pointer that locates it
+type Leaf struct {
+	Pointer string
+	Value   interface{}
+}
+
+// Leaves lists the leaves of a parsed JSON document, as returned by Parse: its
+// scalars and its empty objects and arrays, so no part of the document is left
+// out. Object members are visited in sorted key order and array elements in
+// order. A top-level scalar is a single leaf with the empty pointer.
+func Leaves(data interface{}) []Leaf {
+	var leaves []Leaf
+	collectLeaves(&leaves, data, nil)
+	return leaves
+}
+
+// collectLeaves appends the leaves of value, located at location, to leaves
+func collectLeaves(leaves *[]Leaf, value interface{}, location []string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				collectLeaves(leaves, v[key], append(location[:len(location):len(location)], key))
+			}
+			return
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			for i, element := range v {
+				collectLeaves(leaves, element, append(location[:len(location):len(location)], strconv.Itoa(i)))
+			}
+			return
+		}
+	}
+	*leaves = append(*leaves, Leaf{Pointer: formatPointer(location), Value: value})
+}
@@ -0,0 +1,83 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLeaves(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Leaf
+	}{
+		{
+			name:  "Nested objects and arrays",
+			input: `{"b":{"c":[1,{"d":true}]},"a":"x"}`,
+			expected: []Leaf{
+				{Pointer: "/a", Value: "x"},
+				{Pointer: "/b/c/0", Value: json.Number("1")},
+				{Pointer: "/b/c/1/d", Value: true},
+			},
+		},
+		{
+			name:  "Nested arrays",
+			input: `[[1,2],[[null]]]`,
+			expected: []Leaf{
+				{Pointer: "/0/0", Value: json.Number("1")},
+				{Pointer: "/0/1", Value: json.Number("2")},
+				{Pointer: "/1/0/0", Value: nil},
+			},
+		},
+		{
+			name:  "Keys with ~ and / are escaped",
+			input: `{"a/b":{"m~n":1}}`,
+			expected: []Leaf{
+				{Pointer: "/a~1b/m~0n", Value: json.Number("1")},
+			},
+		},
+		{
+			name:  "Empty objects and arrays are leaves",
+			input: `{"o":{},"a":[],"s":""}`,
+			expected: []Leaf{
+				{Pointer: "/a", Value: []interface{}{}},
+				{Pointer: "/o", Value: map[string]interface{}{}},
+				{Pointer: "/s", Value: ""},
+			},
+		},
+		{
+			name:     "Top-level scalar",
+			input:    `42`,
+			expected: []Leaf{{Pointer: "", Value: json.Number("42")}},
+		},
+		{
+			name:     "Empty key",
+			input:    `{"":1}`,
+			expected: []Leaf{{Pointer: "/", Value: json.Number("1")}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Parse([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("failed to parse input: %v", err)
+			}
+			leaves := Leaves(data)
+			if !reflect.DeepEqual(leaves, tc.expected) {
+				t.Errorf("expected %#v but got %#v", tc.expected, leaves)
+			}
+
+			// Every pointer resolves to its value
+			for _, leaf := range leaves {
+				value, err := ResolvePointer(data, leaf.Pointer)
+				if err != nil {
+					t.Errorf("pointer %q does not resolve: %v", leaf.Pointer, err)
+				} else if !reflect.DeepEqual(value, leaf.Value) {
+					t.Errorf("pointer %q resolves to %#v, not %#v", leaf.Pointer, value, leaf.Value)
+				}
+			}
+		})
+	}
+}
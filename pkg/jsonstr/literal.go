@@ -0,0 +1,285 @@
+package jsonstr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EncodeCLiteral validates a JSON byte slice and returns it as a C string literal,
+// including the surrounding double quotes. Quotes, backslashes and common control
+// characters use their short escapes, while other control characters and all
+// non-ASCII bytes are written as \xNN so the literal holds the exact UTF-8 bytes.
+// If width is greater than zero, the literal is split into several adjacent
+// literals, one per line, which the C compiler concatenates back together. Each
+// line is at most width characters long, counting its quotes, unless a single
+// escape sequence does not fit.
+func EncodeCLiteral(input []byte, width int) (string, error) {
+	return EncodeCLiteralWithOptions(input, width, EncodeOptions{})
+}
+
+// EncodeCLiteralWithOptions is EncodeCLiteral for the JSON text prepared with
+// the given options, as by PrepareWithOptions
+func EncodeCLiteralWithOptions(input []byte, width int, opts EncodeOptions) (string, error) {
+	jsonStr, err := PrepareWithOptions(input, opts)
+	if err != nil {
+		return "", err
+	}
+
+	// Escape each byte into its own token so splitting never breaks an escape sequence
+	tokens := make([]string, 0, len(jsonStr))
+	for i := 0; i < len(jsonStr); i++ {
+		c := jsonStr[i]
+		switch {
+		case c == '"':
+			tokens = append(tokens, `\"`)
+		case c == '\\':
+			tokens = append(tokens, `\\`)
+		case c == '\n':
+			tokens = append(tokens, `\n`)
+		case c == '\t':
+			tokens = append(tokens, `\t`)
+		case c == '\r':
+			tokens = append(tokens, `\r`)
+		case c == '?' && i > 0 && jsonStr[i-1] == '?':
+			// Avoid accidentally forming a trigraph such as ??=
+			tokens = append(tokens, `\?`)
+		case c < 0x20 || c >= 0x7f:
+			tokens = append(tokens, fmt.Sprintf(`\x%02x`, c))
+		default:
+			tokens = append(tokens, string(c))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	// length counts the characters of the current line, including its quotes
+	length := 1
+	for i, token := range tokens {
+		// A hex escape greedily consumes following hex digits, so the literal
+		// is closed and reopened to terminate it
+		split := i > 0 && isHexEscape(tokens[i-1]) && isHexDigit(token[0]) && len(token) == 1
+		needed := len(token) + 1
+		if split {
+			needed += 2
+		}
+		if width > 0 && length > 1 && length+needed > width {
+			// Start a new adjacent literal on the next line, which also
+			// terminates a hex escape
+			b.WriteString("\"\n\"")
+			length = 1
+		} else if split {
+			b.WriteString(`""`)
+			length += 2
+		}
+		b.WriteString(token)
+		length += len(token)
+	}
+	b.WriteByte('"')
+
+	return b.String(), nil
+}
+
+// isHexEscape reports whether token is a \xNN escape sequence
+func isHexEscape(token string) bool {
+	return strings.HasPrefix(token, `\x`)
+}
+
+// isHexDigit reports whether c is a hexadecimal digit
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// EncodeSQLLiteral validates a JSON byte slice and returns it as a single-quoted SQL
+// string literal. Embedded single quotes are doubled in every dialect. Supported
+// dialects are:
+//   - "ansi" (or ""): standard SQL, backslashes are left as-is
+//   - "postgres": like ansi, but when backslashes are present the literal is written
+//     as an E'...' escape string with doubled backslashes, so it is read correctly
+//     regardless of the standard_conforming_strings setting
+//   - "mysql": backslashes are doubled since MySQL treats them as escapes by default
+func EncodeSQLLiteral(input []byte, dialect string) (string, error) {
+	return EncodeSQLLiteralWithOptions(input, dialect, EncodeOptions{})
+}
+
+// EncodeSQLLiteralWithOptions is EncodeSQLLiteral for the JSON text prepared with
+// the given options, as by PrepareWithOptions
+func EncodeSQLLiteralWithOptions(input []byte, dialect string, opts EncodeOptions) (string, error) {
+	jsonStr, err := PrepareWithOptions(input, opts)
+	if err != nil {
+		return "", err
+	}
+
+	prefix := ""
+	escapeBackslashes := false
+	switch dialect {
+	case "", "ansi":
+	case "postgres":
+		if strings.Contains(jsonStr, `\`) {
+			prefix = "E"
+			escapeBackslashes = true
+		}
+	case "mysql":
+		escapeBackslashes = true
+	default:
+		return "", fmt.Errorf("unsupported SQL dialect %q", dialect)
+	}
+
+	escaped := strings.ReplaceAll(jsonStr, "'", "''")
+	if escapeBackslashes {
+		escaped = strings.ReplaceAll(escaped, `\`, `\\`)
+	}
+	return prefix + "'" + escaped + "'", nil
+}
+
+// EncodePropertiesValue validates a JSON byte slice and returns it escaped as the
+// value of a Java .properties entry, to be written after "key=". Backslashes and
+// the separator and comment characters =, :, # and ! are escaped with a
+// backslash, line breaks, tabs and form feeds use their short escapes, other
+// control characters are written as \uXXXX, and leading spaces are escaped so
+// they are not dropped. If asciiOnly is true, non-ASCII characters are also
+// written as \uXXXX escapes, using surrogate pairs outside the Basic Multilingual
+// Plane, for files read as ISO-8859-1 rather than UTF-8.
+func EncodePropertiesValue(input []byte, asciiOnly bool) (string, error) {
+	return EncodePropertiesValueWithOptions(input, asciiOnly, EncodeOptions{})
+}
+
+// EncodePropertiesValueWithOptions is EncodePropertiesValue for the JSON text prepared with
+// the given options, as by PrepareWithOptions
+func EncodePropertiesValueWithOptions(input []byte, asciiOnly bool, opts EncodeOptions) (string, error) {
+	jsonStr, err := PrepareWithOptions(input, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	leading := true
+	for _, r := range jsonStr {
+		switch {
+		case r == ' ' && leading:
+			b.WriteString(`\ `)
+			continue
+		case r == '\\' || r == '=' || r == ':' || r == '#' || r == '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		case r > 0x7f && asciiOnly:
+			if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+				fmt.Fprintf(&b, `\u%04X\u%04X`, r1, r2)
+			} else {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+		leading = false
+	}
+	return b.String(), nil
+}
+
+// EncodeYAMLScalar validates a JSON byte slice and returns it as a YAML string
+// scalar that reads back as the exact JSON text. Single-line JSON is written as a
+// double-quoted scalar, so text such as true, null or 1 stays a string, and JSON
+// spanning several lines as a literal block scalar indented by two spaces. The
+// scalar is written by a YAML emitter, which falls back to a double-quoted scalar
+// when a block scalar cannot hold the text, such as lines with trailing spaces.
+func EncodeYAMLScalar(input []byte) (string, error) {
+	return EncodeYAMLScalarWithOptions(input, EncodeOptions{})
+}
+
+// EncodeYAMLScalarWithOptions is EncodeYAMLScalar for the JSON text prepared with
+// the given options, as by PrepareWithOptions
+func EncodeYAMLScalarWithOptions(input []byte, opts EncodeOptions) (string, error) {
+	jsonStr, err := PrepareWithOptions(input, opts)
+	if err != nil {
+		return "", err
+	}
+
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: jsonStr, Style: yaml.DoubleQuotedStyle}
+	if strings.Contains(jsonStr, "\n") {
+		node.Style = yaml.LiteralStyle
+	}
+
+	var b strings.Builder
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return "", fmt.Errorf("error writing YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("error writing YAML: %w", err)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// EncodeTOMLString validates a JSON byte slice and returns it as a TOML string
+// that reads back as the exact JSON text. A literal string, which needs no
+// escaping, is used where TOML allows one: a literal string in apostrophes for
+// JSON on a single line without apostrophes, and a multi-line literal string
+// delimited by three apostrophes for JSON spanning several lines without three
+// apostrophes in a row. Otherwise a basic string is written, escaping
+// quotes, backslashes and the control characters TOML does not allow in it.
+func EncodeTOMLString(input []byte) (string, error) {
+	return EncodeTOMLStringWithOptions(input, EncodeOptions{})
+}
+
+// EncodeTOMLStringWithOptions is EncodeTOMLString for the JSON text prepared with
+// the given options, as by PrepareWithOptions
+func EncodeTOMLStringWithOptions(input []byte, opts EncodeOptions) (string, error) {
+	jsonStr, err := PrepareWithOptions(input, opts)
+	if err != nil {
+		return "", err
+	}
+
+	multiline := strings.Contains(jsonStr, "\n")
+	switch {
+	case !multiline && !strings.Contains(jsonStr, "'") && tomlLiteralSafe(jsonStr):
+		return "'" + jsonStr + "'", nil
+	case multiline && !strings.Contains(jsonStr, "'''") && !strings.HasSuffix(jsonStr, "'") &&
+		tomlLiteralSafe(strings.ReplaceAll(strings.ReplaceAll(jsonStr, "\r\n", ""), "\n", "")):
+		// The newline after the opening delimiter is not part of the string
+		return "'''\n" + jsonStr + "'''", nil
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range jsonStr {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case shortEscapes[r] != 0:
+			b.WriteByte('\\')
+			b.WriteByte(shortEscapes[r])
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String(), nil
+}
+
+// tomlLiteralSafe reports whether s holds only characters a TOML literal string
+// may contain: no control characters other than tab
+func tomlLiteralSafe(s string) bool {
+	for _, r := range s {
+		if (r < 0x20 && r != '\t') || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,430 @@
+package jsonstr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEncodeCLiteral(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		width       int
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Simple JSON object",
+			input:    `{"name":"John","age":30}`,
+			expected: `"{\"name\":\"John\",\"age\":30}"`,
+		},
+		{
+			name:     "Newlines and tabs",
+			input:    "{\n\t\"a\": 1\r\n}",
+			expected: `"{\n\t\"a\": 1\r\n}"`,
+		},
+		{
+			name:     "Backslashes in string values",
+			input:    `{"path":"C:\\dir"}`,
+			expected: `"{\"path\":\"C:\\\\dir\"}"`,
+		},
+		{
+			name:     "Non-ASCII bytes use hex escapes",
+			input:    `{"name":"José"}`,
+			expected: `"{\"name\":\"Jos\xc3\xa9\"}"`,
+		},
+		{
+			name:     "Hex escape followed by a hex digit is split",
+			input:    `["é1","éa","éz"]`,
+			expected: `"[\"\xc3\xa9""1\",\"\xc3\xa9""a\",\"\xc3\xa9z\"]"`,
+		},
+		{
+			name:     "Trigraph sequences are broken up",
+			input:    `{"q":"??="}`,
+			expected: `"{\"q\":\"?\?=\"}"`,
+		},
+		{
+			name:     "Split long literal across lines",
+			input:    `{"abc":"defghij"}`,
+			width:    10,
+			expected: "\"{\\\"abc\\\"\"\n\":\\\"defgh\"\n\"ij\\\"}\"",
+		},
+		{
+			name:     "Split never breaks an escape sequence",
+			input:    `"é"`,
+			width:    7,
+			expected: "\"\\\"\"\n\"\\xc3\"\n\"\\xa9\"\n\"\\\"\"",
+		},
+		{
+			name:     "Split width counts the quotes that terminate a hex escape",
+			input:    `["é1"]`,
+			width:    12,
+			expected: "\"[\\\"\\xc3\"\n\"\\xa9\"\"1\\\"]\"",
+		},
+		{
+			name:        "Invalid JSON",
+			input:       `{"name":}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := EncodeCLiteral([]byte(tc.input), tc.width)
+			if tc.width > 0 {
+				for _, line := range strings.Split(result, "\n") {
+					if len(line) > tc.width {
+						t.Errorf("line %s is longer than %d characters", line, tc.width)
+					}
+				}
+			}
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				if result != tc.expected {
+					t.Errorf("expected %s but got %s", tc.expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeSQLLiteral(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		dialect     string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Simple JSON object",
+			input:    `{"name":"John"}`,
+			dialect:  "ansi",
+			expected: `'{"name":"John"}'`,
+		},
+		{
+			name:     "Apostrophes are doubled",
+			input:    `{"name":"O'Brien"}`,
+			dialect:  "",
+			expected: `'{"name":"O''Brien"}'`,
+		},
+		{
+			name:     "Backslashes kept in standard SQL",
+			input:    `{"quote":"say \"hi\""}`,
+			dialect:  "ansi",
+			expected: `'{"quote":"say \"hi\""}'`,
+		},
+		{
+			name:     "Postgres without backslashes",
+			input:    `{"name":"O'Brien"}`,
+			dialect:  "postgres",
+			expected: `'{"name":"O''Brien"}'`,
+		},
+		{
+			name:     "Postgres escape string with backslashes",
+			input:    `{"name":"O'Brien","path":"C:\\dir"}`,
+			dialect:  "postgres",
+			expected: `E'{"name":"O''Brien","path":"C:\\\\dir"}'`,
+		},
+		{
+			name:     "MySQL doubles backslashes",
+			input:    `{"name":"it's","quote":"\"x\""}`,
+			dialect:  "mysql",
+			expected: `'{"name":"it''s","quote":"\\"x\\""}'`,
+		},
+		{
+			name:        "Unsupported dialect",
+			input:       `{}`,
+			dialect:     "oracle",
+			expectError: true,
+		},
+		{
+			name:        "Invalid JSON",
+			input:       `{"name":`,
+			dialect:     "mysql",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := EncodeSQLLiteral([]byte(tc.input), tc.dialect)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				if result != tc.expected {
+					t.Errorf("expected %s but got %s", tc.expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodePropertiesValue(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		asciiOnly   bool
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Separators and comment characters are escaped",
+			input:    `{"url":"a=b:c","tag":"#x!"}`,
+			expected: `{"url"\:"a\=b\:c","tag"\:"\#x\!"}`,
+		},
+		{
+			name:     "Backslashes are doubled",
+			input:    `{"quote":"say \"hi\""}`,
+			expected: `{"quote"\:"say \\"hi\\""}`,
+		},
+		{
+			name:     "Line breaks use short escapes",
+			input:    "{\n\t\"a\": 1\r\n}",
+			expected: `{\n\t"a"\: 1\r\n}`,
+		},
+		{
+			name:     "Leading spaces are escaped",
+			input:    `  "a b"`,
+			expected: `\ \ "a b"`,
+		},
+		{
+			name:     "Multibyte characters are kept",
+			input:    `{"name":"Zoë 日本"}`,
+			expected: `{"name"\:"Zoë 日本"}`,
+		},
+		{
+			name:      "Multibyte characters as unicode escapes",
+			input:     `{"name":"Zoë 日本 😀"}`,
+			asciiOnly: true,
+			expected:  `{"name"\:"Zo\u00EB \u65E5\u672C \uD83D\uDE00"}`,
+		},
+		{
+			name:        "Invalid JSON",
+			input:       `{"name":`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := EncodePropertiesValue([]byte(tc.input), tc.asciiOnly)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				if result != tc.expected {
+					t.Errorf("expected %s but got %s", tc.expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeYAMLScalar(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Single line is double-quoted",
+			input:    `{"name":"John: \"JJ\""}`,
+			expected: `"{\"name\":\"John: \\\"JJ\\\"\"}"`,
+		},
+		{name: "Boolean stays a string", input: `true`, expected: `"true"`},
+		{name: "Null stays a string", input: `null`, expected: `"null"`},
+		{name: "Number stays a string", input: `1e3`, expected: `"1e3"`},
+		{
+			name:     "Multiple lines use a block scalar",
+			input:    "{\n  \"a\": \"yes\"\n}",
+			expected: "|-\n  {\n    \"a\": \"yes\"\n  }",
+		},
+		{
+			name:     "Trailing spaces fall back to double quotes",
+			input:    "{\n  \"a\": 1  \n}",
+			expected: `"{\n  \"a\": 1  \n}"`,
+		},
+		{name: "Invalid JSON", input: `{"name":`, expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := EncodeYAMLScalar([]byte(tc.input))
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+
+			// The scalar must read back as the original JSON text
+			var parsed interface{}
+			if err := yaml.Unmarshal([]byte("value: "+result), &parsed); err != nil {
+				t.Fatalf("emitted scalar is not valid YAML: %v", err)
+			}
+			value, ok := parsed.(map[string]interface{})["value"].(string)
+			if !ok || value != tc.input {
+				t.Errorf("expected YAML to read back as %q but got %#v", tc.input, parsed)
+			}
+		})
+	}
+}
+
+func TestEncodeTOMLString(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Literal string",
+			input:    `{"path":"C:\\dir","quote":"\"x\""}`,
+			expected: `'{"path":"C:\\dir","quote":"\"x\""}'`,
+		},
+		{
+			name:     "Apostrophes need a basic string",
+			input:    `{"name":"O'Brien","path":"C:\\dir"}`,
+			expected: `"{\"name\":\"O'Brien\",\"path\":\"C:\\\\dir\"}"`,
+		},
+		{
+			name:     "Multiple lines use a multi-line literal string",
+			input:    "{\n\t\"name\": \"O'Brien\"\n}",
+			expected: "'''\n{\n\t\"name\": \"O'Brien\"\n}'''",
+		},
+		{
+			name:     "Three apostrophes need a basic string",
+			input:    "{\n  \"a\": \"'''\"\n}",
+			expected: `"{\n  \"a\": \"'''\"\n}"`,
+		},
+		{
+			name:     "Carriage returns need a basic string",
+			input:    "{\r\"a\": 1}",
+			expected: `"{\r\"a\": 1}"`,
+		},
+		{
+			name:     "Windows line endings stay literal",
+			input:    "{\r\n\"a\": 1\r\n}",
+			expected: "'''\n{\r\n\"a\": 1\r\n}'''",
+		},
+		{
+			name:     "Delete characters are escaped",
+			input:    "{\"a\":\"x\x7fy\"}",
+			expected: `"{\"a\":\"x\u007Fy\"}"`,
+		},
+		{name: "Multibyte characters", input: `"日本 ☃"`, expected: `'"日本 ☃"'`},
+		{name: "Invalid JSON", input: `{"name":`, expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := EncodeTOMLString([]byte(tc.input))
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+
+			// The string must read back as the original JSON text
+			var parsed struct{ Value string }
+			if _, err := toml.Decode("Value = "+result, &parsed); err != nil {
+				t.Fatalf("emitted string is not valid TOML: %v", err)
+			}
+			if parsed.Value != tc.input {
+				t.Errorf("expected TOML to read back as %q but got %q", tc.input, parsed.Value)
+			}
+		})
+	}
+}
+
+// TestEncodeLiteralWithOptions verifies that the literal encoders format the JSON
+// with the given options before escaping it
+func TestEncodeLiteralWithOptions(t *testing.T) {
+	input := []byte(`{ "b": "it's", "a": 1 }`)
+	opts := EncodeOptions{Compact: true}
+
+	tests := []struct {
+		name     string
+		encode   func() (string, error)
+		expected string
+	}{
+		{
+			name:     "C",
+			encode:   func() (string, error) { return EncodeCLiteralWithOptions(input, 0, opts) },
+			expected: `"{\"a\":1,\"b\":\"it's\"}"`,
+		},
+		{
+			name:     "SQL",
+			encode:   func() (string, error) { return EncodeSQLLiteralWithOptions(input, "ansi", opts) },
+			expected: `'{"a":1,"b":"it''s"}'`,
+		},
+		{
+			name:     "Properties",
+			encode:   func() (string, error) { return EncodePropertiesValueWithOptions(input, false, opts) },
+			expected: `{"a"\:1,"b"\:"it's"}`,
+		},
+		{
+			name:     "YAML",
+			encode:   func() (string, error) { return EncodeYAMLScalarWithOptions(input, opts) },
+			expected: `"{\"a\":1,\"b\":\"it's\"}"`,
+		},
+		{
+			name:     "TOML",
+			encode:   func() (string, error) { return EncodeTOMLStringWithOptions(input, opts) },
+			expected: `"{\"a\":1,\"b\":\"it's\"}"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.encode()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+}
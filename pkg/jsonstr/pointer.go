@@ -0,0 +1,264 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNoValue is matched by the errors of ResolvePointer and RemovePointer that report
+// a missing object member or an array index past the end, as opposed to an invalid
+// pointer or a pointer that indexes into a scalar. Use errors.Is to check for it.
+var ErrNoValue = errors.New("no value")
+
+// noValueError is an error about a pointer that does not resolve, matching ErrNoValue
+type noValueError struct {
+	message string
+}
+
+func (e *noValueError) Error() string {
+	return e.message
+}
+
+func (e *noValueError) Unwrap() error {
+	return ErrNoValue
+}
+
+// noValue returns a formatted error matching ErrNoValue
+func noValue(format string, args ...interface{}) error {
+	return &noValueError{message: fmt.Sprintf(format, args...)}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped reference tokens
+// The empty pointer refers to the whole document and yields no tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must be empty or start with '/'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("invalid JSON pointer %q: '~' must be followed by '0' or '1'", pointer)
+			}
+		}
+		// ~1 must be replaced before ~0 so that "~01" becomes "~1" rather than "/"
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// formatPointer builds a JSON Pointer from reference tokens, escaping '~' and '/'
+func formatPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// arrayIndex parses an array reference token, allowing indexes up to max
+func arrayIndex(token string, max int, location []string) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q at %s", token, formatPointer(location))
+	}
+	if index > max {
+		return 0, noValue("array index %d out of range at %s", index, formatPointer(location))
+	}
+	return index, nil
+}
+
+// ResolvePointer returns the value at the given RFC 6901 JSON Pointer within data,
+// where data is a document as returned by Parse. The empty pointer returns data itself.
+func ResolvePointer(data interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	current := data
+	for i, token := range tokens {
+		location := tokens[:i+1]
+		switch node := current.(type) {
+		case map[string]interface{}:
+			child, ok := node[token]
+			if !ok {
+				return nil, noValue("no value at %s: object has no member %q", formatPointer(location), token)
+			}
+			current = child
+		case []interface{}:
+			if token == "-" {
+				return nil, noValue("no value at %s: '-' refers to the element after the end of the array", formatPointer(location))
+			}
+			index, err := arrayIndex(token, len(node)-1, location)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("no value at %s: cannot index into %s", formatPointer(location), typeName(current))
+		}
+	}
+	return current, nil
+}
+
+// RemovePointer removes the value at the given RFC 6901 JSON Pointer from data, where
+// data is a document as returned by Parse, and returns the updated document. Removing
+// an array element shifts the following elements down. Objects are modified in place,
+// but removing from an array creates a shorter one, so always use the returned
+// document. The error matches ErrNoValue when there is no value at the pointer.
+func RemovePointer(data interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+
+	parentTokens, token := tokens[:len(tokens)-1], tokens[len(tokens)-1]
+	parent, err := ResolvePointer(data, formatPointer(parentTokens))
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[token]; !ok {
+			return nil, noValue("no value at %s: object has no member %q", formatPointer(tokens), token)
+		}
+		delete(node, token)
+		return data, nil
+	case []interface{}:
+		if token == "-" {
+			return nil, noValue("no value at %s: '-' refers to the element after the end of the array", formatPointer(tokens))
+		}
+		index, err := arrayIndex(token, len(node)-1, tokens)
+		if err != nil {
+			return nil, err
+		}
+		shorter := make([]interface{}, 0, len(node)-1)
+		shorter = append(append(shorter, node[:index]...), node[index+1:]...)
+		return setPointer(data, parentTokens, shorter)
+	default:
+		return nil, fmt.Errorf("cannot remove %s: parent is not an object or array", formatPointer(tokens))
+	}
+}
+
+// setPointer sets value at the location described by tokens and returns the updated
+// document. Object members are added or replaced, array elements are replaced, and
+// "-" (or an index equal to the array length) appends to an array.
+func setPointer(data interface{}, tokens []string, value interface{}) (interface{}, error) {
+	return setAt(data, tokens, value, nil, false)
+}
+
+// SetPointer sets value at the given RFC 6901 JSON Pointer within data, where data is
+// a document as returned by Parse, modifying it in place. Object members are added or
+// replaced, and missing intermediate members are created as empty objects as long as
+// the path up to them exists. Array elements are replaced, "-" or an index equal to
+// the array length appends, and larger indexes are an error. Because data is modified
+// in place, the empty pointer and appending to a top-level array are not supported.
+func SetPointer(data interface{}, pointer string, value interface{}) error {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("cannot set the whole document in place")
+	}
+	if node, ok := data.([]interface{}); ok && len(tokens) == 1 && (tokens[0] == "-" || tokens[0] == strconv.Itoa(len(node))) {
+		return fmt.Errorf("cannot set %s: appending to the top-level array is not supported in place", pointer)
+	}
+
+	_, err = setAt(data, tokens, value, nil, true)
+	return err
+}
+
+// setAt recursively walks the remaining tokens, tracking the traversed location for
+// errors. With create set, missing object members along the path become empty objects.
+func setAt(data interface{}, tokens []string, value interface{}, location []string, create bool) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	token := tokens[0]
+	location = append(location, token)
+	switch node := data.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok && len(tokens) > 1 {
+			if !create {
+				return nil, fmt.Errorf("no value at %s", formatPointer(location))
+			}
+			child = map[string]interface{}{}
+		}
+		updated, err := setAt(child, tokens[1:], value, location, create)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = updated
+		return node, nil
+	case []interface{}:
+		index := len(node)
+		if token != "-" {
+			var err error
+			if index, err = arrayIndex(token, len(node), location); err != nil {
+				return nil, err
+			}
+		}
+		if index == len(node) {
+			if len(tokens) > 1 {
+				return nil, fmt.Errorf("no value at %s", formatPointer(location))
+			}
+			return append(node, value), nil
+		}
+		updated, err := setAt(node[index], tokens[1:], value, location, create)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = updated
+		return node, nil
+	default:
+		return nil, fmt.Errorf("cannot set %s: parent is not an object or array", formatPointer(location))
+	}
+}
+
+// EmbedIn escapes the inner JSON document and stores it as a string value at the
+// given JSON Pointer location in the outer template, returning the updated template.
+// Both documents are validated first. The returned JSON is compact and, as with
+// the compact encode mode, object keys are sorted.
+func EmbedIn(inner []byte, outerTemplate []byte, pointer string) ([]byte, error) {
+	innerStr, err := Prepare(inner, false)
+	if err != nil {
+		return nil, err
+	}
+
+	outer, err := parse(outerTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON template: %w", err)
+	}
+
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	outer, err = setPointer(outer, tokens, innerStr)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := json.Marshal(outer)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling JSON: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,335 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		name        string
+		pointer     string
+		expected    []string
+		expectError bool
+	}{
+		{name: "Whole document", pointer: "", expected: nil},
+		{name: "Single token", pointer: "/foo", expected: []string{"foo"}},
+		{name: "Nested tokens", pointer: "/user/addresses/0/zip", expected: []string{"user", "addresses", "0", "zip"}},
+		{name: "Empty key", pointer: "/", expected: []string{""}},
+		{name: "Escaped slash and tilde", pointer: "/a~1b/m~0n", expected: []string{"a/b", "m~n"}},
+		{name: "Escape order", pointer: "/~01", expected: []string{"~1"}},
+		{name: "Missing leading slash", pointer: "foo", expectError: true},
+		{name: "Invalid escape", pointer: "/a~2", expectError: true},
+		{name: "Trailing tilde", pointer: "/a~", expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := parsePointer(tc.pointer)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				if !reflect.DeepEqual(result, tc.expected) {
+					t.Errorf("expected %q but got %q", tc.expected, result)
+				}
+				if formatPointer(result) != tc.pointer && tc.pointer != "/~01" {
+					t.Errorf("expected %q to round-trip but got %q", tc.pointer, formatPointer(result))
+				}
+			}
+		})
+	}
+}
+
+func TestEmbedIn(t *testing.T) {
+	tests := []struct {
+		name        string
+		inner       string
+		outer       string
+		pointer     string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Replace object member",
+			inner:    `{"name":"John"}`,
+			outer:    `{"body":null,"id":1}`,
+			pointer:  "/body",
+			expected: `{"body":"{\"name\":\"John\"}","id":1}`,
+		},
+		{
+			name:     "Add object member",
+			inner:    `[1,2]`,
+			outer:    `{"request":{}}`,
+			pointer:  "/request/payload",
+			expected: `{"request":{"payload":"[1,2]"}}`,
+		},
+		{
+			name:     "Replace array element",
+			inner:    `true`,
+			outer:    `{"items":["a","b"]}`,
+			pointer:  "/items/1",
+			expected: `{"items":["a","true"]}`,
+		},
+		{
+			name:     "Append to array",
+			inner:    `{}`,
+			outer:    `[1]`,
+			pointer:  "/-",
+			expected: `[1,"{}"]`,
+		},
+		{
+			name:     "Whole document",
+			inner:    `{"a":1}`,
+			outer:    `{}`,
+			pointer:  "",
+			expected: `"{\"a\":1}"`,
+		},
+		{
+			name:     "Number formatting preserved",
+			inner:    `1`,
+			outer:    `{"big":12345678901234567890,"x":1.50}`,
+			pointer:  "/x",
+			expected: `{"big":12345678901234567890,"x":"1"}`,
+		},
+		{
+			name:        "Missing parent",
+			inner:       `{}`,
+			outer:       `{"a":{}}`,
+			pointer:     "/b/c",
+			expectError: true,
+		},
+		{
+			name:        "Index out of range",
+			inner:       `{}`,
+			outer:       `[1]`,
+			pointer:     "/5",
+			expectError: true,
+		},
+		{
+			name:        "Scalar parent",
+			inner:       `{}`,
+			outer:       `{"a":1}`,
+			pointer:     "/a/b",
+			expectError: true,
+		},
+		{
+			name:        "Invalid inner JSON",
+			inner:       `{"a":`,
+			outer:       `{}`,
+			pointer:     "/a",
+			expectError: true,
+		},
+		{
+			name:        "Invalid template",
+			inner:       `{}`,
+			outer:       `{"a":`,
+			pointer:     "/a",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := EmbedIn([]byte(tc.inner), []byte(tc.outer), tc.pointer)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				if string(result) != tc.expected {
+					t.Errorf("expected %s but got %s", tc.expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestResolvePointer(t *testing.T) {
+	document := `{
+		"user": {"name": "John", "addresses": [{"zip": "12345"}, {"zip": "67890"}]},
+		"a/b": 1,
+		"m~n": 2,
+		"": 3,
+		"count": 1.50
+	}`
+	data, err := Parse([]byte(document))
+	if err != nil {
+		t.Fatalf("unexpected error parsing document: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		pointer       string
+		expected      string
+		errorContains string
+	}{
+		{name: "Whole document", pointer: "", expected: `{"":3,"a/b":1,"count":1.50,"m~n":2,"user":{"addresses":[{"zip":"12345"},{"zip":"67890"}],"name":"John"}}`},
+		{name: "Nested member", pointer: "/user/name", expected: `"John"`},
+		{name: "Array element", pointer: "/user/addresses/1/zip", expected: `"67890"`},
+		{name: "Subtree", pointer: "/user/addresses/0", expected: `{"zip":"12345"}`},
+		{name: "Escaped slash", pointer: "/a~1b", expected: `1`},
+		{name: "Escaped tilde", pointer: "/m~0n", expected: `2`},
+		{name: "Empty key", pointer: "/", expected: `3`},
+		{name: "Number representation kept", pointer: "/count", expected: `1.50`},
+		{name: "Missing member", pointer: "/user/email", errorContains: `no value at /user/email`},
+		{name: "Index out of range", pointer: "/user/addresses/2", errorContains: "out of range at /user/addresses/2"},
+		{name: "Non-numeric index", pointer: "/user/addresses/first", errorContains: `invalid array index "first"`},
+		{name: "Leading zero index", pointer: "/user/addresses/01", errorContains: `invalid array index "01"`},
+		{name: "End of array", pointer: "/user/addresses/-", errorContains: "no value at /user/addresses/-"},
+		{name: "Indexing a string", pointer: "/user/name/0", errorContains: "cannot index into string"},
+		{name: "Invalid pointer", pointer: "user", errorContains: "must be empty or start with '/'"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := ResolvePointer(data, tc.pointer)
+
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Errorf("expected error containing %q but got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			encoded, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("unexpected error marshaling result: %v", err)
+			}
+			if string(encoded) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, encoded)
+			}
+		})
+	}
+}
+
+func TestSetPointer(t *testing.T) {
+	tests := []struct {
+		name          string
+		document      string
+		pointer       string
+		value         interface{}
+		expected      string
+		errorContains string
+	}{
+		{name: "Replace member", document: `{"a":1,"b":2}`, pointer: "/a", value: "x", expected: `{"a":"x","b":2}`},
+		{name: "Add member", document: `{"a":1}`, pointer: "/b", value: true, expected: `{"a":1,"b":true}`},
+		{name: "Replace nested array element", document: `{"items":[1,2,3]}`, pointer: "/items/1", value: nil, expected: `{"items":[1,null,3]}`},
+		{name: "Append to nested array", document: `{"items":[1]}`, pointer: "/items/-", value: json.Number("2"), expected: `{"items":[1,2]}`},
+		{name: "Append at array length", document: `{"items":[1]}`, pointer: "/items/1", value: json.Number("2"), expected: `{"items":[1,2]}`},
+		{name: "Replace top-level array element", document: `[1,2]`, pointer: "/0", value: "a", expected: `["a",2]`},
+		{name: "Create intermediate objects", document: `{"a":{}}`, pointer: "/a/b/c", value: json.Number("1"), expected: `{"a":{"b":{"c":1}}}`},
+		{name: "Set object value", document: `{"a":1}`, pointer: "/a", value: map[string]interface{}{"b": "c"}, expected: `{"a":{"b":"c"}}`},
+		{name: "Escaped key", document: `{}`, pointer: "/a~1b", value: json.Number("1"), expected: `{"a/b":1}`},
+		{name: "Array index out of range", document: `{"items":[1]}`, pointer: "/items/3", value: "x", errorContains: "array index 3 out of range at /items/3"},
+		{name: "Missing array element in path", document: `{"items":[]}`, pointer: "/items/0/a", value: "x", errorContains: "no value at /items/0"},
+		{name: "Scalar parent", document: `{"a":1}`, pointer: "/a/b", value: "x", errorContains: "parent is not an object or array"},
+		{name: "Whole document", document: `{}`, pointer: "", value: "x", errorContains: "cannot set the whole document"},
+		{name: "Append to top-level array", document: `[1]`, pointer: "/-", value: "x", errorContains: "appending to the top-level array"},
+		{name: "Invalid pointer", document: `{}`, pointer: "a", value: "x", errorContains: "must be empty or start with '/'"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Parse([]byte(tc.document))
+			if err != nil {
+				t.Fatalf("unexpected error parsing document: %v", err)
+			}
+
+			err = SetPointer(data, tc.pointer, tc.value)
+
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Errorf("expected error containing %q but got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			encoded, err := json.Marshal(data)
+			if err != nil {
+				t.Fatalf("unexpected error marshaling result: %v", err)
+			}
+			if string(encoded) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, encoded)
+			}
+		})
+	}
+}
+
+func TestRemovePointer(t *testing.T) {
+	tests := []struct {
+		name          string
+		document      string
+		pointer       string
+		expected      string
+		errorContains string
+		noValue       bool
+	}{
+		{name: "Remove member", document: `{"a":1,"b":2}`, pointer: "/a", expected: `{"b":2}`},
+		{name: "Remove nested member", document: `{"user":{"name":"John","password":"x"}}`, pointer: "/user/password", expected: `{"user":{"name":"John"}}`},
+		{name: "Remove array element shifts the rest", document: `{"items":[1,2,3]}`, pointer: "/items/0", expected: `{"items":[2,3]}`},
+		{name: "Remove last array element", document: `{"items":[1,2,3]}`, pointer: "/items/2", expected: `{"items":[1,2]}`},
+		{name: "Remove top-level array element", document: `[1,2,3]`, pointer: "/1", expected: `[1,3]`},
+		{name: "Remove escaped key", document: `{"a/b":1,"c":2}`, pointer: "/a~1b", expected: `{"c":2}`},
+		{name: "Missing member", document: `{"a":1}`, pointer: "/b", errorContains: `no value at /b`, noValue: true},
+		{name: "Missing parent", document: `{"a":1}`, pointer: "/b/c", errorContains: `no value at /b`, noValue: true},
+		{name: "Index out of range", document: `[1]`, pointer: "/1", errorContains: "array index 1 out of range at /1", noValue: true},
+		{name: "End of array", document: `[1]`, pointer: "/-", errorContains: "no value at /-", noValue: true},
+		{name: "Invalid index", document: `[1]`, pointer: "/x", errorContains: `invalid array index "x"`},
+		{name: "Scalar parent", document: `{"a":1}`, pointer: "/a/b", errorContains: "parent is not an object or array"},
+		{name: "Whole document", document: `{}`, pointer: "", errorContains: "cannot remove the whole document"},
+		{name: "Invalid pointer", document: `{}`, pointer: "a", errorContains: "must be empty or start with '/'"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Parse([]byte(tc.document))
+			if err != nil {
+				t.Fatalf("unexpected error parsing document: %v", err)
+			}
+
+			result, err := RemovePointer(data, tc.pointer)
+
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Errorf("expected error containing %q but got %v", tc.errorContains, err)
+				}
+				if errors.Is(err, ErrNoValue) != tc.noValue {
+					t.Errorf("expected errors.Is(err, ErrNoValue) to be %v for %v", tc.noValue, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			encoded, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("unexpected error marshaling result: %v", err)
+			}
+			if string(encoded) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, encoded)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package jsonstr
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledBufferSize is the largest buffer returned to the pool. Larger buffers
+// are left to the garbage collector so one huge document does not pin its memory.
+const maxPooledBufferSize = 1 << 20
+
+// pooledBuffer is a buffer with an encoder writing to it, reused across conversions
+type pooledBuffer struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+	// quoted holds escaped input wrapped in quotes while it is unescaped
+	quoted []byte
+}
+
+// bufferPool holds the buffers used by the encode and decode paths, so hot loops
+// such as batch processing do not allocate new ones for every document
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := &pooledBuffer{}
+		b.enc = json.NewEncoder(&b.buf)
+		return b
+	},
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *pooledBuffer {
+	b := bufferPool.Get().(*pooledBuffer)
+	b.buf.Reset()
+	return b
+}
+
+// putBuffer returns a buffer to the pool. The caller must not keep references
+// to its contents.
+func putBuffer(b *pooledBuffer) {
+	if b.buf.Cap() > maxPooledBufferSize || cap(b.quoted) > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(b)
+}
+
+// encode writes v to the buffer as JSON, indented if indent is not empty, and
+// returns the output without the trailing newline written by the encoder
+func (b *pooledBuffer) encode(v interface{}, indent string) ([]byte, error) {
+	b.buf.Reset()
+	b.enc.SetIndent("", indent)
+	if err := b.enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return b.buf.Bytes()[:b.buf.Len()-1], nil
+}
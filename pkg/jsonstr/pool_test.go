@@ -0,0 +1,85 @@
+package jsonstr
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Test that concurrent conversions with different options never see each
+// other's pooled buffers or indentation settings
+func TestBufferPoolConcurrentUse(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			input := []byte(fmt.Sprintf(`{"worker":%d,"tags":["a","b"]}`, i))
+			opts := EncodeOptions{Pretty: i%2 == 0, Indent: strings.Repeat(" ", i%4+1)}
+
+			expectedEncoded := strings.ReplaceAll(string(input), `"`, `\"`)
+			if opts.Pretty {
+				prepared, err := PrepareWithOptions(input, opts)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				expectedEncoded = escapeString(prepared)
+			}
+			expectedDecoded, err := NewEscaper(opts).Unescape([]byte(expectedEncoded))
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			for j := 0; j < 200; j++ {
+				encoded, err := EncodeWithOptions(input, opts)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if encoded != expectedEncoded {
+					t.Errorf("worker %d: expected %s but got %s", i, expectedEncoded, encoded)
+					return
+				}
+
+				decoded, err := DecodeWithOptions([]byte(encoded), opts)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if decoded != expectedDecoded {
+					t.Errorf("worker %d: expected %s but got %s", i, expectedDecoded, decoded)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Test that oversized buffers are not kept in the pool
+func TestPutBufferDropsLargeBuffers(t *testing.T) {
+	b := getBuffer()
+	b.buf.Grow(maxPooledBufferSize + 1)
+	putBuffer(b)
+
+	for i := 0; i < 10; i++ {
+		if got := getBuffer(); got == b {
+			t.Fatalf("expected an oversized buffer not to be reused")
+		}
+	}
+}
+
+func BenchmarkEncodeWithOptionsParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := EncodeWithOptions(benchmarkInput, EncodeOptions{}); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,292 @@
+package jsonstr
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// printer writes a parsed JSON value in pretty layouts that encoding/json cannot
+// produce. Object keys are sorted and scalars are marshaled by encoding/json, so
+// apart from the layout the output matches the encoder used for pretty output.
+type printer struct {
+	indent string
+	// align pads the keys of each object so that its colons line up
+	align bool
+	// threshold keeps objects and arrays on one line when that line is shorter
+	// than this many characters (0 disables)
+	threshold int
+	// maxDepth writes objects and arrays nested this deep or deeper compactly on
+	// one line (0 disables)
+	maxDepth int
+	b        strings.Builder
+}
+
+// printPretty returns v pretty-printed as configured by opts
+func printPretty(v interface{}, opts EncodeOptions) (string, error) {
+	p := &printer{indent: opts.indent(), align: opts.Align, threshold: opts.CompactThreshold, maxDepth: opts.PrettyDepth}
+	if err := p.write(v, 0); err != nil {
+		return "", err
+	}
+	return p.b.String(), nil
+}
+
+// newline starts a new line indented to the given depth
+func (p *printer) newline(depth int) {
+	p.b.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		p.b.WriteString(p.indent)
+	}
+}
+
+// write writes v at the given nesting depth
+func (p *printer) write(v interface{}, depth int) error {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		return p.writeObject(node, depth)
+	case []interface{}:
+		if len(node) == 0 {
+			p.b.WriteString("[]")
+			return nil
+		}
+		if ok, err := p.writeDeep(node, depth); ok || err != nil {
+			return err
+		}
+		if ok, err := p.writeShort(node); ok || err != nil {
+			return err
+		}
+		p.b.WriteByte('[')
+		for i, child := range node {
+			if i > 0 {
+				p.b.WriteByte(',')
+			}
+			p.newline(depth + 1)
+			if err := p.write(child, depth+1); err != nil {
+				return err
+			}
+		}
+		p.newline(depth)
+		p.b.WriteByte(']')
+		return nil
+	default:
+		scalar, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		p.b.Write(scalar)
+		return nil
+	}
+}
+
+// writeObject writes an object with sorted keys, aligning its colons if configured
+func (p *printer) writeObject(node map[string]interface{}, depth int) error {
+	if len(node) == 0 {
+		p.b.WriteString("{}")
+		return nil
+	}
+
+	if ok, err := p.writeDeep(node, depth); ok || err != nil {
+		return err
+	}
+	if ok, err := p.writeShort(node); ok || err != nil {
+		return err
+	}
+
+	keys := sortedKeys(node)
+	encoded := make([]string, len(keys))
+	width := 0
+	for i, key := range keys {
+		encoded[i] = "\"" + escapeString(key) + "\""
+		if n := utf8.RuneCountInString(encoded[i]); n > width {
+			width = n
+		}
+	}
+
+	p.b.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			p.b.WriteByte(',')
+		}
+		p.newline(depth + 1)
+		p.b.WriteString(encoded[i])
+		if p.align {
+			p.b.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(encoded[i])))
+		}
+		p.b.WriteString(": ")
+		if err := p.write(node[key], depth+1); err != nil {
+			return err
+		}
+	}
+	p.newline(depth)
+	p.b.WriteByte('}')
+	return nil
+}
+
+// sortedKeys returns the keys of an object in the order encoding/json writes them
+func sortedKeys(node map[string]interface{}) []string {
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeDeep writes a container compactly if it is nested at or below the maximum
+// depth, and reports whether it did
+func (p *printer) writeDeep(v interface{}, depth int) (bool, error) {
+	if p.maxDepth <= 0 || depth < p.maxDepth {
+		return false, nil
+	}
+	compact, err := json.Marshal(v)
+	if err != nil {
+		return false, err
+	}
+	p.b.Write(compact)
+	return true, nil
+}
+
+// writeShort writes a container on a single line if that line is shorter than the
+// threshold, and reports whether it did
+func (p *printer) writeShort(v interface{}) (bool, error) {
+	if p.threshold <= 0 {
+		return false, nil
+	}
+	line, ok, err := p.inline(v)
+	if !ok || err != nil {
+		return false, err
+	}
+	p.b.WriteString(line)
+	return true, nil
+}
+
+// inline returns the single-line form of v, such as {"a": 1, "b": [1, 2]}, and
+// whether it is shorter than the threshold. Building the line stops as soon as it
+// reaches the threshold, so large values are not rendered just to be rejected.
+func (p *printer) inline(v interface{}) (string, bool, error) {
+	var line strings.Builder
+	width := 0
+	ok, err := p.writeInline(&line, v, &width)
+	return line.String(), ok, err
+}
+
+// writeInline appends the single-line form of v to line, counting its width in
+// characters, and reports whether the line is still shorter than the threshold
+func (p *printer) writeInline(line *strings.Builder, v interface{}, width *int) (bool, error) {
+	put := func(s string) bool {
+		line.WriteString(s)
+		*width += utf8.RuneCountInString(s)
+		return *width < p.threshold
+	}
+
+	switch node := v.(type) {
+	case map[string]interface{}:
+		if !put("{") {
+			return false, nil
+		}
+		for i, key := range sortedKeys(node) {
+			if i > 0 && !put(", ") {
+				return false, nil
+			}
+			if !put("\"" + escapeString(key) + "\": ") {
+				return false, nil
+			}
+			if ok, err := p.writeInline(line, node[key], width); !ok || err != nil {
+				return false, err
+			}
+		}
+		return put("}"), nil
+	case []interface{}:
+		if !put("[") {
+			return false, nil
+		}
+		for i, child := range node {
+			if i > 0 && !put(", ") {
+				return false, nil
+			}
+			if ok, err := p.writeInline(line, child, width); !ok || err != nil {
+				return false, err
+			}
+		}
+		return put("]"), nil
+	default:
+		scalar, err := json.Marshal(v)
+		if err != nil {
+			return false, err
+		}
+		return put(string(scalar)), nil
+	}
+}
+
+// IndentDepth appends to dst an indented form of the JSON in src, like
+// json.Indent, but only down to the given depth: objects and arrays nested that
+// deep or deeper are written compactly on one line. The top-level value is at
+// depth 0, so with depth 0 the whole document is compact. Key order, duplicate
+// keys and number formatting are kept as in src.
+func IndentDepth(dst *bytes.Buffer, src []byte, indent string, depth int) error {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, src); err != nil {
+		return err
+	}
+
+	newline := func(level int) {
+		dst.WriteByte('\n')
+		for i := 0; i < level; i++ {
+			dst.WriteString(indent)
+		}
+	}
+
+	// expanded records for each open object or array whether it is indented
+	var expanded []bool
+	inString, escaped := false, false
+	data := compact.Bytes()
+	for i, c := range data {
+		if inString {
+			dst.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		inExpanded := len(expanded) > 0 && expanded[len(expanded)-1]
+		switch c {
+		case '"':
+			inString = true
+			dst.WriteByte(c)
+		case '{', '[':
+			expand := len(expanded) < depth
+			expanded = append(expanded, expand)
+			dst.WriteByte(c)
+			if expand && data[i+1] != '}' && data[i+1] != ']' {
+				newline(len(expanded))
+			}
+		case '}', ']':
+			expanded = expanded[:len(expanded)-1]
+			if inExpanded && data[i-1] != '{' && data[i-1] != '[' {
+				newline(len(expanded))
+			}
+			dst.WriteByte(c)
+		case ',':
+			dst.WriteByte(c)
+			if inExpanded {
+				newline(len(expanded))
+			}
+		case ':':
+			dst.WriteByte(c)
+			if inExpanded {
+				dst.WriteByte(' ')
+			}
+		default:
+			dst.WriteByte(c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+package jsonstr
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// Test that without layout options the printer matches encoding/json
+func TestPrintPrettyMatchesEncoder(t *testing.T) {
+	documents := []string{
+		`{"b":1,"a":[1,2.5,{"c":null}],"e":{},"f":[]}`,
+		`[true,false,null,"x"]`,
+		`{"html":"<a href=\"x\">&</a>","unicode":"café  ","big":1e21}`,
+		`"just a string"`,
+		`[[[]],[{}]]`,
+	}
+
+	for _, document := range documents {
+		t.Run(document, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(document), &value); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected, err := json.MarshalIndent(value, "", "\t")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, err := printPretty(value, EncodeOptions{Indent: "\t"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != string(expected) {
+				t.Errorf("expected\n%s\nbut got\n%s", expected, result)
+			}
+		})
+	}
+}
+
+func TestPrintPrettyAlign(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Flat object",
+			input:    `{"id":1,"name":"x","active":true}`,
+			expected: "{\n  \"active\": true,\n  \"id\"    : 1,\n  \"name\"  : \"x\"\n}",
+		},
+		{
+			name:  "Nested objects are aligned independently",
+			input: `{"a":{"long key":1,"k":2},"outer key":{"x":3,"yy":4}}`,
+			expected: "{\n" +
+				"  \"a\"        : {\n" +
+				"    \"k\"       : 2,\n" +
+				"    \"long key\": 1\n" +
+				"  },\n" +
+				"  \"outer key\": {\n" +
+				"    \"x\" : 3,\n" +
+				"    \"yy\": 4\n" +
+				"  }\n" +
+				"}",
+		},
+		{
+			name:     "Objects inside arrays",
+			input:    `[{"a":1,"bbb":2},{"cc":3}]`,
+			expected: "[\n  {\n    \"a\"  : 1,\n    \"bbb\": 2\n  },\n  {\n    \"cc\": 3\n  }\n]",
+		},
+		{
+			name:     "Width counts characters, not bytes",
+			input:    `{"café":1,"abcde":2}`,
+			expected: "{\n  \"abcde\": 2,\n  \"café\" : 1\n}",
+		},
+		{
+			name:     "Width counts escape sequences",
+			input:    `{"a\"b":1,"abcd":2}`,
+			expected: "{\n  \"a\\\"b\": 1,\n  \"abcd\": 2\n}",
+		},
+		{
+			name:     "Empty containers",
+			input:    `{"a":{},"bb":[]}`,
+			expected: "{\n  \"a\" : {},\n  \"bb\": []\n}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tc.input), &value); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, err := printPretty(value, EncodeOptions{Align: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected\n%s\nbut got\n%s", tc.expected, result)
+			}
+
+			// Aligned output is still valid JSON with the same content
+			var reparsed interface{}
+			if err := json.Unmarshal([]byte(result), &reparsed); err != nil {
+				t.Errorf("aligned output is not valid JSON: %v", err)
+			}
+		})
+	}
+}
+
+func TestPrintPrettyCompactThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		threshold int
+		align     bool
+		expected  string
+	}{
+		{
+			// {"x": 1, "y": 2} is 16 characters long
+			name:      "One character above the line length",
+			input:     `{"x":1,"y":2}`,
+			threshold: 17,
+			expected:  `{"x": 1, "y": 2}`,
+		},
+		{
+			name:      "Exactly at the line length",
+			input:     `{"x":1,"y":2}`,
+			threshold: 16,
+			expected:  "{\n  \"x\": 1,\n  \"y\": 2\n}",
+		},
+		{
+			// [1, 2, 3] is 9 characters long
+			name:      "Array below the threshold",
+			input:     `[1,2,3]`,
+			threshold: 10,
+			expected:  `[1, 2, 3]`,
+		},
+		{
+			name:      "Array at the threshold",
+			input:     `[1,2,3]`,
+			threshold: 9,
+			expected:  "[\n  1,\n  2,\n  3\n]",
+		},
+		{
+			name:      "Small children of a large object",
+			input:     `{"origin":{"x":0,"y":0},"size":{"w":100,"h":50},"tags":["a","b"]}`,
+			threshold: 30,
+			expected: "{\n" +
+				"  \"origin\": {\"x\": 0, \"y\": 0},\n" +
+				"  \"size\": {\"h\": 50, \"w\": 100},\n" +
+				"  \"tags\": [\"a\", \"b\"]\n" +
+				"}",
+		},
+		{
+			name:      "Nested large values expand",
+			input:     `{"points":[{"x":1,"y":2},{"x":3,"y":4}]}`,
+			threshold: 20,
+			expected: "{\n" +
+				"  \"points\": [\n" +
+				"    {\"x\": 1, \"y\": 2},\n" +
+				"    {\"x\": 3, \"y\": 4}\n" +
+				"  ]\n" +
+				"}",
+		},
+		{
+			name:      "Width counts characters, not bytes",
+			input:     `["é"]`,
+			threshold: 6,
+			expected:  `["é"]`,
+		},
+		{
+			name:      "Expanded objects are still aligned",
+			input:     `{"a":[1,2],"long key":"value that does not fit"}`,
+			threshold: 10,
+			align:     true,
+			expected:  "{\n  \"a\"       : [1, 2],\n  \"long key\": \"value that does not fit\"\n}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tc.input), &value); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, err := printPretty(value, EncodeOptions{CompactThreshold: tc.threshold, Align: tc.align})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected\n%s\nbut got\n%s", tc.expected, result)
+			}
+
+			var reparsed interface{}
+			if err := json.Unmarshal([]byte(result), &reparsed); err != nil {
+				t.Errorf("output is not valid JSON: %v", err)
+			}
+		})
+	}
+}
+
+func TestPrintPrettyDepth(t *testing.T) {
+	// The document is nested four levels deep
+	input := `{"a":{"b":{"c":{"d":[1,2]}}},"e":[1,{"f":2}],"g":{}}`
+
+	tests := []struct {
+		name     string
+		depth    int
+		expected string
+	}{
+		{
+			name:     "Top level only",
+			depth:    1,
+			expected: "{\n  \"a\": {\"b\":{\"c\":{\"d\":[1,2]}}},\n  \"e\": [1,{\"f\":2}],\n  \"g\": {}\n}",
+		},
+		{
+			name:  "Two levels",
+			depth: 2,
+			expected: "{\n" +
+				"  \"a\": {\n" +
+				"    \"b\": {\"c\":{\"d\":[1,2]}}\n" +
+				"  },\n" +
+				"  \"e\": [\n" +
+				"    1,\n" +
+				"    {\"f\":2}\n" +
+				"  ],\n" +
+				"  \"g\": {}\n" +
+				"}",
+		},
+		{
+			name:  "Deeper than the document",
+			depth: 10,
+			expected: "{\n" +
+				"  \"a\": {\n" +
+				"    \"b\": {\n" +
+				"      \"c\": {\n" +
+				"        \"d\": [\n" +
+				"          1,\n" +
+				"          2\n" +
+				"        ]\n" +
+				"      }\n" +
+				"    }\n" +
+				"  },\n" +
+				"  \"e\": [\n" +
+				"    1,\n" +
+				"    {\n" +
+				"      \"f\": 2\n" +
+				"    }\n" +
+				"  ],\n" +
+				"  \"g\": {}\n" +
+				"}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(input), &value); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, err := printPretty(value, EncodeOptions{PrettyDepth: tc.depth})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected\n%s\nbut got\n%s", tc.expected, result)
+			}
+
+			// The text indenter used for unparsed JSON lays out sorted input the same way
+			var b bytes.Buffer
+			if err := IndentDepth(&b, []byte(input), DefaultIndent, tc.depth); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b.String() != tc.expected {
+				t.Errorf("expected IndentDepth to return\n%s\nbut got\n%s", tc.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestIndentDepth(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		depth    int
+		expected string
+	}{
+		{
+			name:     "Key order, duplicates and numbers are kept",
+			input:    `{"b":1.50, "a":1e2, "b":2}`,
+			depth:    1,
+			expected: "{\n  \"b\": 1.50,\n  \"a\": 1e2,\n  \"b\": 2\n}",
+		},
+		{
+			name:     "Structural characters inside strings",
+			input:    `{"k{,}":"[:]\"{", "x": ["\\", "]"]}`,
+			depth:    2,
+			expected: "{\n  \"k{,}\": \"[:]\\\"{\",\n  \"x\": [\n    \"\\\\\",\n    \"]\"\n  ]\n}",
+		},
+		{
+			name:     "Whitespace in compact parts is removed",
+			input:    "[ { \"a\" : [ 1 , 2 ] } ]",
+			depth:    1,
+			expected: "[\n  {\"a\":[1,2]}\n]",
+		},
+		{
+			name:     "Depth zero is compact",
+			input:    `{ "a": [1, 2] }`,
+			depth:    0,
+			expected: `{"a":[1,2]}`,
+		},
+		{
+			name:     "Scalar document",
+			input:    ` "x" `,
+			depth:    3,
+			expected: `"x"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := IndentDepth(&b, []byte(tc.input), DefaultIndent, tc.depth); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b.String() != tc.expected {
+				t.Errorf("expected\n%s\nbut got\n%s", tc.expected, b.String())
+			}
+		})
+	}
+
+	var b bytes.Buffer
+	if err := IndentDepth(&b, []byte(`{"a":`), DefaultIndent, 1); err == nil {
+		t.Errorf("expected invalid JSON to fail")
+	}
+}
+
+func TestDecodeWithCustomLayout(t *testing.T) {
+	input := []byte(`{\"id\":1,\"name\":\"x\"}`)
+
+	result, err := DecodeWithOptions(input, EncodeOptions{Pretty: true, Align: true, Indent: "    "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "{\n    \"id\"  : 1,\n    \"name\": \"x\"\n}"
+	if result != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, result)
+	}
+
+	result, err = DecodeWithOptions(input, EncodeOptions{Pretty: true, CompactThreshold: 40})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != `{"id": 1, "name": "x"}` {
+		t.Errorf("expected a single line below the threshold but got %s", result)
+	}
+
+	result, err = DecodeWithOptions([]byte(`{\"a\":{\"b\":[1]}}`), EncodeOptions{Pretty: true, PrettyDepth: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "{\n  \"a\": {\"b\":[1]}\n}" {
+		t.Errorf("expected nesting below the depth to be compact but got %s", result)
+	}
+
+	// Alignment only applies to pretty output
+	result, err = DecodeWithOptions(input, EncodeOptions{Align: true, CompactThreshold: 40})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != `{"id":1,"name":"x"}` {
+		t.Errorf("expected compact output to be unchanged but got %s", result)
+	}
+}
@@ -0,0 +1,75 @@
+package jsonstr
+
+import "regexp"
+
+// RedactedSecret replaces the text of each secret removed by RedactSecrets
+const RedactedSecret = "[REDACTED]"
+
+// Secret is a string value of a JSON document that looks like it holds a
+// credential, with the JSON Pointer that locates it
+type Secret struct {
+	Pointer string
+	// Kind names what the value looks like, such as "AWS access key ID"
+	Kind string
+}
+
+// secretDetector matches one kind of credential in a string
+type secretDetector struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// secretDetectors are the patterns DetectSecrets looks for. They match the
+// documented formats of a few common credentials, so they catch neither secrets
+// in other formats nor ones that are split or encoded.
+var secretDetectors = []secretDetector{
+	{kind: "AWS access key ID", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "GitHub token", pattern: regexp.MustCompile(`\bgh[pousr]_[0-9A-Za-z]{36}\b`)},
+	{kind: "Slack token", pattern: regexp.MustCompile(`\bxox[abprs]-[0-9A-Za-z-]{10,}`)},
+	{kind: "Google API key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{kind: "JSON Web Token", pattern: regexp.MustCompile(`\beyJ[0-9A-Za-z_-]+\.eyJ[0-9A-Za-z_-]+\.[0-9A-Za-z_-]+`)},
+	{kind: "private key", pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )*PRIVATE KEY-----`)},
+}
+
+// DetectSecrets lists the string values of a parsed JSON document, as returned
+// by Parse, that look like API keys, tokens or private keys, in the order of
+// Leaves. A value matching several kinds is listed once for each. Object keys
+// are not checked. This is a guardrail against sharing credentials by mistake,
+// not a guarantee that a document holds none.
+func DetectSecrets(data interface{}) []Secret {
+	var secrets []Secret
+	for _, leaf := range Leaves(data) {
+		value, ok := leaf.Value.(string)
+		if !ok {
+			continue
+		}
+		for _, detector := range secretDetectors {
+			if detector.pattern.MatchString(value) {
+				secrets = append(secrets, Secret{Pointer: leaf.Pointer, Kind: detector.kind})
+			}
+		}
+	}
+	return secrets
+}
+
+// RedactSecrets returns a copy of a parsed JSON document in which the parts of
+// string values found by DetectSecrets are replaced with RedactedSecret. The
+// rest of each string is kept, and a private key is replaced from its BEGIN
+// line through its END line.
+func RedactSecrets(data interface{}) interface{} {
+	// Mapping only string values cannot fail
+	result, _ := MapStrings(data, redactSecrets, nil)
+	return result
+}
+
+// privateKeyBlock matches a whole PEM private key for RedactSecrets
+var privateKeyBlock = regexp.MustCompile(`(?s)-----BEGIN (?:[A-Z]+ )*PRIVATE KEY-----.*?(?:-----END (?:[A-Z]+ )*PRIVATE KEY-----|\z)`)
+
+// redactSecrets replaces the secrets in s with RedactedSecret
+func redactSecrets(s string) string {
+	s = privateKeyBlock.ReplaceAllLiteralString(s, RedactedSecret)
+	for _, detector := range secretDetectors {
+		s = detector.pattern.ReplaceAllLiteralString(s, RedactedSecret)
+	}
+	return s
+}
@@ -0,0 +1,55 @@
+package jsonstr
+
+// DocumentStats describes the structure of a parsed JSON document, as reported
+// by Stats
+type DocumentStats struct {
+	// Nodes is the total number of values, including the root
+	Nodes int `json:"nodes"`
+	// Objects is the number of objects
+	Objects int `json:"objects"`
+	// Arrays is the number of arrays
+	Arrays int `json:"arrays"`
+	// Keys is the total number of object keys across all objects
+	Keys int `json:"keys"`
+	// Elements is the total number of array elements across all arrays
+	Elements int `json:"elements"`
+	// MaxDepth is the deepest level of object/array nesting (0 for a scalar)
+	MaxDepth int `json:"maxDepth"`
+}
+
+// Stats walks a parsed JSON document, as returned by Parse, and reports
+// structural statistics about it
+func Stats(data interface{}) DocumentStats {
+	var stats DocumentStats
+	stats.walk(data, 0)
+	return stats
+}
+
+// walk accumulates statistics for a value nested inside depth containers
+func (s *DocumentStats) walk(value interface{}, depth int) {
+	s.Nodes++
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.Objects++
+		s.Keys += len(v)
+		s.enter(depth + 1)
+		for _, child := range v {
+			s.walk(child, depth+1)
+		}
+	case []interface{}:
+		s.Arrays++
+		s.Elements += len(v)
+		s.enter(depth + 1)
+		for _, child := range v {
+			s.walk(child, depth+1)
+		}
+	}
+}
+
+// enter records that a container was found at the given nesting depth
+func (s *DocumentStats) enter(depth int) {
+	if depth > s.MaxDepth {
+		s.MaxDepth = depth
+	}
+}
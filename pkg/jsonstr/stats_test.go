@@ -0,0 +1,51 @@
+package jsonstr
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected DocumentStats
+	}{
+		{
+			name:     "Scalar",
+			input:    `42`,
+			expected: DocumentStats{Nodes: 1},
+		},
+		{
+			name:     "Empty object",
+			input:    `{}`,
+			expected: DocumentStats{Nodes: 1, Objects: 1, MaxDepth: 1},
+		},
+		{
+			name:     "Flat object",
+			input:    `{"name":"John","age":30,"active":true}`,
+			expected: DocumentStats{Nodes: 4, Objects: 1, Keys: 3, MaxDepth: 1},
+		},
+		{
+			name:     "Flat array",
+			input:    `[1,2,3,null]`,
+			expected: DocumentStats{Nodes: 5, Arrays: 1, Elements: 4, MaxDepth: 1},
+		},
+		{
+			name:     "Nested structure",
+			input:    `{"user":{"tags":["a","b"],"address":{"city":"X"}},"ids":[[1],[2,3]]}`,
+			expected: DocumentStats{Nodes: 13, Objects: 3, Arrays: 4, Keys: 5, Elements: 7, MaxDepth: 3},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Parse([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result := Stats(data)
+			if result != tc.expected {
+				t.Errorf("expected %+v but got %+v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// streamChunkSize is how much validated input EncodeStream collects before it
+// escapes and writes it
+const streamChunkSize = 32 * 1024
+
+// EncodeStream escapes the JSON document read from r like Encode with its
+// default layout, writing the output to w while the input is still being read.
+// The input is validated token by token, and only the part that has been
+// validated is written, so a syntax error stops the output at the last complete
+// token before it. Unlike Encode, which writes nothing for invalid input, the
+// error is then returned after part of the output has been written.
+func EncodeStream(w io.Writer, r io.Reader) error {
+	s := &streamer{r: r, w: w}
+	decoder := json.NewDecoder(s)
+
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("invalid JSON at offset %d: %w", decoder.InputOffset(), err)
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+		if s.validated(decoder.InputOffset()) >= streamChunkSize {
+			if err := s.flush(decoder.InputOffset()); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Anything after the top-level value must be whitespace, which is kept as it is
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid JSON: unexpected data after top-level value")
+	}
+	return s.flush(s.offset + int64(len(s.pending)))
+}
+
+// streamer records the input read by the decoder of EncodeStream until it has
+// been validated and written
+type streamer struct {
+	r       io.Reader
+	w       io.Writer
+	pending []byte
+	// offset is the input offset of the first pending byte
+	offset int64
+}
+
+// Read reads from the input, keeping a copy of what was read
+func (s *streamer) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.pending = append(s.pending, p[:n]...)
+	return n, err
+}
+
+// validated returns how many pending bytes are before the input offset end
+func (s *streamer) validated(end int64) int {
+	return int(end - s.offset)
+}
+
+// flush escapes and writes the pending input up to the input offset end. The
+// offset is always at the end of a token or of the input, so no character is
+// split between two writes.
+func (s *streamer) flush(end int64) error {
+	n := s.validated(end)
+	escaped, err := json.Marshal(string(s.pending[:n]))
+	if err != nil {
+		return fmt.Errorf("error encoding JSON: %w", err)
+	}
+	if _, err := s.w.Write(escaped[1 : len(escaped)-1]); err != nil {
+		return err
+	}
+	s.pending = append(s.pending[:0], s.pending[n:]...)
+	s.offset = end
+	return nil
+}
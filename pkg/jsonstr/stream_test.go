@@ -0,0 +1,123 @@
+package jsonstr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEncodeStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Object",
+			input:    `{"name": "John", "tags": ["a", "<b>"]}`,
+			expected: `{\"name\": \"John\", \"tags\": [\"a\", \"\u003cb\u003e\"]}`,
+		},
+		{
+			name:     "Surrounding whitespace is kept",
+			input:    "\n {\"a\":1}\n",
+			expected: `\n {\"a\":1}\n`,
+		},
+		{
+			name:     "Top-level scalar",
+			input:    `"é\n"`,
+			expected: `\"é\\n\"`,
+		},
+		{
+			name:        "Late syntax error",
+			input:       `[1, 2, 3 4]`,
+			expectError: true,
+		},
+		{
+			name:        "Unexpected end of input",
+			input:       `{"a": [1`,
+			expectError: true,
+		},
+		{
+			name:        "Empty input",
+			input:       ``,
+			expectError: true,
+		},
+		{
+			name:        "Trailing data",
+			input:       `{} []`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			// One byte at a time, so tokens are split between reads
+			err := EncodeStream(&b, iotest.OneByteReader(strings.NewReader(tc.input)))
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b.String() != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, b.String())
+			}
+		})
+	}
+}
+
+// TestEncodeStreamLargeInput verifies that streaming gives the same output as
+// Encode for an input much larger than the chunks it is written in
+func TestEncodeStreamLargeInput(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("[\n")
+	for i := 0; i < 20000; i++ {
+		if i > 0 {
+			input.WriteString(",\n")
+		}
+		fmt.Fprintf(&input, `  {"id": %d, "name": "user \"%d\"", "note": "naïve <%d> & 😀\t", "ok": %t}`, i, i, i, i%2 == 0)
+	}
+	input.WriteString("\n]\n")
+
+	expected, err := Encode([]byte(input.String()), false)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	var b bytes.Buffer
+	if err := EncodeStream(&b, strings.NewReader(input.String())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.String() != expected {
+		t.Errorf("streamed output differs from Encode (%d bytes, expected %d)", b.Len(), len(expected))
+	}
+}
+
+// TestEncodeStreamPartialOutput verifies that a late syntax error comes after
+// the output of the tokens validated before it
+func TestEncodeStreamPartialOutput(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("[")
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&input, `"%05d",`, i)
+	}
+	input.WriteString("}")
+
+	var b bytes.Buffer
+	err := EncodeStream(&b, strings.NewReader(input.String()))
+	if err == nil {
+		t.Fatalf("expected error but got none")
+	}
+	if b.Len() == 0 {
+		t.Errorf("expected partial output before the error")
+	}
+	if !strings.HasPrefix(input.String(), strings.ReplaceAll(b.String(), `\"`, `"`)) {
+		t.Errorf("partial output is not a prefix of the escaped input")
+	}
+}
@@ -0,0 +1,37 @@
+package jsonstr
+
+import "fmt"
+
+// TruncateArrays keeps the first max elements of every array in a parsed JSON
+// document, as returned by Parse. When marker is set, the dropped elements of an
+// array are replaced by a single string element such as "…(+42 more)", so the
+// array has max+1 elements. Nested arrays are truncated too, but only those in
+// the kept elements. Arrays are changed in place, and the document is returned
+// because a top-level array may become shorter.
+func TruncateArrays(data interface{}, max int, marker bool) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = TruncateArrays(value, max, marker)
+		}
+	case []interface{}:
+		dropped := len(v) - max
+		if dropped > 0 {
+			v = v[:max]
+		}
+		for i, element := range v {
+			v[i] = TruncateArrays(element, max, marker)
+		}
+		if dropped > 0 && marker {
+			v = append(v, TruncationMarker(dropped))
+		}
+		return v
+	}
+	return data
+}
+
+// TruncationMarker returns the element TruncateArrays puts in place of the
+// dropped elements of an array
+func TruncationMarker(dropped int) string {
+	return fmt.Sprintf("…(+%d more)", dropped)
+}
@@ -0,0 +1,50 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTruncateArrays(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		max      int
+		marker   bool
+		expected string
+	}{
+		{name: "Longer array", input: `[1,2,3,4,5]`, max: 2, marker: true, expected: `[1,2,"…(+3 more)"]`},
+		{name: "Array at the limit", input: `[1,2]`, max: 2, marker: true, expected: `[1,2]`},
+		{name: "Shorter array", input: `[1]`, max: 2, marker: true, expected: `[1]`},
+		{name: "Without a marker", input: `[1,2,3,4,5]`, max: 2, expected: `[1,2]`},
+		{
+			name:     "Nested arrays of differing lengths",
+			input:    `{"a":[[1,2,3],[4],[5,6,7,8]],"b":{"c":[true,false,null]},"d":[]}`,
+			max:      2,
+			marker:   true,
+			expected: `{"a":[[1,2,"…(+1 more)"],[4],"…(+1 more)"],"b":{"c":[true,false,"…(+1 more)"]},"d":[]}`,
+		},
+		{
+			name:     "Nested arrays without a marker",
+			input:    `[[1,2,3],[4,5],[6]]`,
+			max:      1,
+			expected: `[[1]]`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Parse([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("failed to parse input: %v", err)
+			}
+			result, err := json.Marshal(TruncateArrays(data, tc.max, tc.marker))
+			if err != nil {
+				t.Fatalf("failed to marshal result: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+}
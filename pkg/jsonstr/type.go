@@ -0,0 +1,71 @@
+package jsonstr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// TopLevelType returns the JSON type of the top-level value in input: object,
+// array, string, number, boolean or null. Only the first token is read for objects
+// and arrays, so the rest of the document is not validated. Scalars are read in
+// full and must not be followed by other data.
+func TopLevelType(input []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("invalid JSON: empty input")
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	switch token {
+	case json.Delim('{'):
+		return "object", nil
+	case json.Delim('['):
+		return "array", nil
+	case json.Delim('}'), json.Delim(']'):
+		return "", fmt.Errorf("invalid JSON: unexpected %v", token)
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("invalid JSON: unexpected data after top-level value")
+	}
+	return typeName(token), nil
+}
+
+// IsEscaped reports whether input looks like a JSON document that was already
+// escaped, so encoding it would escape it a second time. That is the case for a
+// JSON string whose value is a JSON object or array, as written by
+// EncodeJSONString, and for text that is not valid JSON itself but unescapes to
+// a JSON object or array, as written by Encode. It is a heuristic: escaped
+// scalars are not detected, and a raw JSON string that happens to hold a JSON
+// document is reported as escaped.
+func IsEscaped(input []byte) bool {
+	input = bytes.TrimSpace(input)
+
+	var value string
+	if err := json.Unmarshal(input, &value); err == nil {
+		return isDocument(value)
+	}
+	if json.Valid(input) {
+		return false
+	}
+
+	quoted := append(append([]byte{'"'}, input...), '"')
+	if err := json.Unmarshal(quoted, &value); err != nil {
+		return false
+	}
+	return isDocument(value)
+}
+
+// isDocument reports whether s is a valid JSON object or array
+func isDocument(s string) bool {
+	typ, err := TopLevelType([]byte(s))
+	return err == nil && (typ == "object" || typ == "array") && json.Valid([]byte(s))
+}
@@ -0,0 +1,74 @@
+package jsonstr
+
+import "testing"
+
+func TestTopLevelType(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{name: "Object", input: `{"a":[1,2]}`, expected: "object"},
+		{name: "Array", input: " \n[1,2]", expected: "array"},
+		{name: "String", input: `"hello"`, expected: "string"},
+		{name: "Integer", input: `42`, expected: "number"},
+		{name: "Negative float", input: `-1.5e3`, expected: "number"},
+		{name: "True", input: `true`, expected: "boolean"},
+		{name: "False", input: `false`, expected: "boolean"},
+		{name: "Null", input: "null\n", expected: "null"},
+		{name: "Unterminated object is only peeked", input: `{"a":`, expected: "object"},
+		{name: "Empty input", input: "", expectError: true},
+		{name: "Whitespace only", input: "  \n", expectError: true},
+		{name: "Closing delimiter", input: `]`, expectError: true},
+		{name: "Invalid literal", input: `nope`, expectError: true},
+		{name: "Trailing data after scalar", input: `1 2`, expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := TopLevelType([]byte(tc.input))
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error but got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestIsEscaped(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "Raw object", input: `{"a":"say \"hi\""}`, expected: false},
+		{name: "Raw array", input: "[1, 2]\n", expected: false},
+		{name: "Raw scalar", input: `"hello"`, expected: false},
+		{name: "Escaped object", input: `{\"a\":\"say \\\"hi\\\"\"}`, expected: true},
+		{name: "Escaped array with newline", input: "[\\\"a\\\"]\n", expected: true},
+		{name: "JSON string holding an object", input: `"{\"a\":1}"`, expected: true},
+		{name: "JSON string holding an array", input: `"[1,2]"`, expected: true},
+		{name: "JSON string holding a scalar", input: `"42"`, expected: false},
+		{name: "Invalid JSON that is not escaped", input: `{"a":`, expected: false},
+		{name: "Escaped invalid JSON", input: `{\"a\":`, expected: false},
+		{name: "Empty", input: ``, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := IsEscaped([]byte(tc.input)); result != tc.expected {
+				t.Errorf("expected %v but got %v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package jsonstr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// EscapeNonASCII rewrites every non-ASCII character in marshaled JSON as a \uXXXX
+// escape, using a UTF-16 surrogate pair for characters above U+FFFF. Outside of
+// strings JSON is pure ASCII, so the document is otherwise unchanged.
+func EscapeNonASCII(data string) string {
+	var b strings.Builder
+	b.Grow(len(data))
+	for _, r := range data {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case r > 0xFFFF:
+			// Encode as a UTF-16 surrogate pair
+			r -= 0x10000
+			fmt.Fprintf(&b, `\u%04x\u%04x`, 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+	}
+	return b.String()
+}
+
+// ErrInvalidUTF8 is matched by the errors of CheckUTF8. Use errors.Is to check for it.
+var ErrInvalidUTF8 = errors.New("invalid UTF-8")
+
+// CheckUTF8 reports the byte offset of the first invalid UTF-8 sequence in input.
+// encoding/json silently replaces such sequences with U+FFFD, so checking first
+// avoids corrupting data that is not really text.
+func CheckUTF8(input []byte) error {
+	if utf8.Valid(input) {
+		return nil
+	}
+	for offset := 0; offset < len(input); {
+		r, size := utf8.DecodeRune(input[offset:])
+		if r == utf8.RuneError && size == 1 {
+			return fmt.Errorf("%w: byte 0x%02x at offset %d", ErrInvalidUTF8, input[offset], offset)
+		}
+		offset += size
+	}
+	return nil
+}
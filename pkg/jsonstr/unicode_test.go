@@ -0,0 +1,110 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEscapeNonASCII(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "ASCII only",
+			input:    `{"a":"plain \"text\"\n"}`,
+			expected: `{"a":"plain \"text\"\n"}`,
+		},
+		{
+			name:     "Latin characters",
+			input:    `{"name":"café"}`,
+			expected: `{"name":"caf\u00e9"}`,
+		},
+		{
+			name:     "Non-ASCII keys",
+			input:    `{"ключ":1}`,
+			expected: `{"\u043a\u043b\u044e\u0447":1}`,
+		},
+		{
+			name:     "Character above U+FFFF",
+			input:    `["😀"]`,
+			expected: `["\ud83d\ude00"]`,
+		},
+		{
+			name:     "Mixed",
+			input:    `"a€𝄞b"`,
+			expected: `"a\u20ac\ud834\udd1eb"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := EscapeNonASCII(tc.input)
+			if result != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+
+			// Escaping must not change the decoded value
+			var original, escaped interface{}
+			if err := json.Unmarshal([]byte(tc.input), &original); err != nil {
+				t.Fatalf("invalid test input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(result), &escaped); err != nil {
+				t.Fatalf("escaped output is not valid JSON: %v", err)
+			}
+			originalJSON, _ := json.Marshal(original)
+			escapedJSON, _ := json.Marshal(escaped)
+			if string(originalJSON) != string(escapedJSON) {
+				t.Errorf("expected escaped output to decode to %s but got %s", originalJSON, escapedJSON)
+			}
+		})
+	}
+}
+
+func TestCheckUTF8(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         []byte
+		errorContains string
+	}{
+		{name: "ASCII", input: []byte(`{"a":1}`)},
+		{name: "Multibyte characters", input: []byte(`{"name":"café 😀"}`)},
+		{name: "Encoded replacement character", input: []byte("\"\xef\xbf\xbd\"")},
+		{
+			name:          "Invalid continuation byte",
+			input:         []byte("{\"a\":\"caf\xc3\x28\"}"),
+			errorContains: "byte 0xc3 at offset 9",
+		},
+		{
+			name:          "Stray continuation byte",
+			input:         []byte("\"\x80\""),
+			errorContains: "byte 0x80 at offset 1",
+		},
+		{
+			name:          "Truncated sequence",
+			input:         []byte("\"\xe2\x82"),
+			errorContains: "byte 0xe2 at offset 1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckUTF8(tc.input)
+			if tc.errorContains == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidUTF8) {
+				t.Fatalf("expected ErrInvalidUTF8 but got %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.errorContains) {
+				t.Errorf("expected error containing %q but got %v", tc.errorContains, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// escapeString returns the JSON-escaped form of s without the surrounding quotes
+func escapeString(s string) string {
+	quoted, err := json.Marshal(s)
+	if err != nil {
+		// Marshaling a string cannot fail
+		return s
+	}
+	return string(quoted[1 : len(quoted)-1])
+}
+
+// mapStrings returns a copy of a parsed JSON document with values applied to
+// every string value and keys to every object key. Either may be nil to leave
+// those strings untouched. location is the JSON pointer of data, used in errors.
+func mapStrings(data interface{}, values, keys func(string) string, location []string) (interface{}, error) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		// Sorted keys make the reported conflict the same on every run
+		origins := make(map[string]string, len(v))
+		for _, key := range sortedKeys(v) {
+			mappedKey := key
+			if keys != nil {
+				mappedKey = keys(key)
+				if origin, exists := origins[mappedKey]; exists {
+					return nil, keyConflict(origin, key, mappedKey, location)
+				}
+				origins[mappedKey] = key
+			}
+			child, err := mapStrings(v[key], values, keys, append(location[:len(location):len(location)], key))
+			if err != nil {
+				return nil, err
+			}
+			result[mappedKey] = child
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, element := range v {
+			child, err := mapStrings(element, values, keys, append(location[:len(location):len(location)], strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = child
+		}
+		return result, nil
+	case string:
+		if values != nil {
+			return values(v), nil
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// keyConflict reports two keys of the object at location that map to the same key
+func keyConflict(first, second, mapped string, location []string) error {
+	object := "the top-level object"
+	if len(location) > 0 {
+		object = "the object at " + formatPointer(location)
+	}
+	return fmt.Errorf("keys %q and %q of %s both become %q", first, second, object, mapped)
+}
+
+// MapStrings returns a copy of a parsed JSON document, as returned by Parse, with
+// values applied to every string value and keys to every object key. Either may
+// be nil to leave those strings unchanged. Numbers, booleans and null are never
+// touched. Two keys of an object that map to the same key are an error, as one
+// of their values would be lost.
+func MapStrings(data interface{}, values, keys func(string) string) (interface{}, error) {
+	return mapStrings(data, values, keys, nil)
+}
+
+// EscapeValues returns a copy of a parsed JSON document in which every string
+// value is replaced by its escaped representation, as it would appear inside
+// the output of Encode. Object keys and the document structure are unchanged,
+// so the result can be pretty-printed to show escaped values in a readable layout.
+func EscapeValues(data interface{}) interface{} {
+	// Without a key function mapping cannot fail
+	result, _ := mapStrings(data, escapeString, nil, nil)
+	return result
+}
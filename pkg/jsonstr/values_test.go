@@ -0,0 +1,134 @@
+package jsonstr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEscapeValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Plain strings unchanged",
+			input:    `{"name":"John","age":30}`,
+			expected: `{"age":30,"name":"John"}`,
+		},
+		{
+			name:     "Quotes in values",
+			input:    `{"quote":"say \"hi\""}`,
+			expected: `{"quote":"say \\\"hi\\\""}`,
+		},
+		{
+			name:     "Nested strings with escapes",
+			input:    `{"outer":{"list":["a\tb","line\nbreak",{"path":"C:\\dir"}]}}`,
+			expected: `{"outer":{"list":["a\\tb","line\\nbreak",{"path":"C:\\\\dir"}]}}`,
+		},
+		{
+			name:     "Keys are untouched",
+			input:    `{"a\"b":"c\"d"}`,
+			expected: `{"a\"b":"c\\\"d"}`,
+		},
+		{
+			name:     "Non-string scalars unchanged",
+			input:    `[1.50,true,null]`,
+			expected: `[1.50,true,null]`,
+		},
+		{
+			name:     "Top-level string",
+			input:    `"a\"b"`,
+			expected: `"a\\\"b"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Parse([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, err := json.Marshal(EscapeValues(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestMapStrings(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		values        func(string) string
+		keys          func(string) string
+		expected      string
+		errorContains string
+	}{
+		{
+			name:     "Values only",
+			input:    `{" a ":"  hi  ","n":[" x ",1,true,null]}`,
+			values:   strings.TrimSpace,
+			expected: `{" a ":"hi","n":["x",1,true,null]}`,
+		},
+		{
+			name:     "Keys only",
+			input:    `{" a ":"  hi  ","b":{" c":" d "}}`,
+			keys:     strings.TrimSpace,
+			expected: `{"a":"  hi  ","b":{"c":" d "}}`,
+		},
+		{
+			name:     "Values and keys",
+			input:    `[{" a ":" b "}]`,
+			values:   strings.TrimSpace,
+			keys:     strings.TrimSpace,
+			expected: `[{"a":"b"}]`,
+		},
+		{name: "Top-level string", input: `"\t x \n"`, values: strings.TrimSpace, expected: `"x"`},
+		{name: "Numbers are untouched", input: `[1.50,2e3]`, values: strings.ToUpper, expected: `[1.50,2e3]`},
+		{
+			name:          "Keys that collide",
+			input:         `{"a":1,"a ":2}`,
+			keys:          strings.TrimSpace,
+			errorContains: `keys "a" and "a " of the top-level object both become "a"`,
+		},
+		{
+			name:          "Nested keys that collide",
+			input:         `{"x":[{"B":1,"b":2}]}`,
+			keys:          strings.ToLower,
+			errorContains: `keys "B" and "b" of the object at /x/0 both become "b"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Parse([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			mapped, err := MapStrings(data, tc.values, tc.keys)
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Errorf("expected error containing %q but got %v", tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, err := json.Marshal(mapped)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, result)
+			}
+		})
+	}
+}
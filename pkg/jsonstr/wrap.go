@@ -0,0 +1,154 @@
+package jsonstr
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// minWrapWidth is the narrowest segment a string value is split into, so deeply
+// indented values still wrap into readable pieces
+const minWrapWidth = 10
+
+// wrapStrings soft-wraps long string values in pretty-printed JSON so that lines
+// stay within width columns where possible. A long value is split into several
+// quoted segments, one per line, aligned under the start of the value. The result
+// is meant for display only: JSON has no string concatenation, so it no longer parses.
+func wrapStrings(pretty string, width int) string {
+	lines := strings.Split(pretty, "\n")
+	for i, line := range lines {
+		lines[i] = wrapLine(line, width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapLine wraps the string value on a single line of pretty-printed JSON
+func wrapLine(line string, width int) string {
+	if utf8.RuneCountInString(line) <= width {
+		return line
+	}
+
+	start := stringValueStart(line)
+	if start < 0 {
+		return line
+	}
+	end := stringEnd(line, start)
+	if end < 0 {
+		return line
+	}
+
+	prefix, content, suffix := line[:start], line[start+1:end], line[end+1:]
+	column := utf8.RuneCountInString(prefix)
+	available := width - column - 2
+	if available < minWrapWidth {
+		available = minWrapWidth
+	}
+
+	segments := splitEscaped(content, available)
+	if len(segments) == 1 {
+		return line
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	for i, segment := range segments {
+		if i > 0 {
+			b.WriteByte('\n')
+			b.WriteString(strings.Repeat(" ", column))
+		}
+		b.WriteByte('"')
+		b.WriteString(segment)
+		b.WriteByte('"')
+	}
+	b.WriteString(suffix)
+	return b.String()
+}
+
+// stringValueStart returns the index of the opening quote of the string value on
+// a line of pretty-printed JSON, or -1 if the line holds no string value
+func stringValueStart(line string) int {
+	start := len(line) - len(strings.TrimLeft(line, " \t"))
+	if start == len(line) || line[start] != '"' {
+		return -1
+	}
+
+	end := stringEnd(line, start)
+	if end < 0 {
+		return -1
+	}
+	// Keys may be padded with spaces when colons are aligned
+	rest := strings.TrimLeft(line[end+1:], " ")
+	if !strings.HasPrefix(rest, ": ") {
+		// An array element
+		return start
+	}
+
+	// An object member, whose value follows the key
+	value := len(line) - len(rest) + 2
+	if value < len(line) && line[value] == '"' {
+		return value
+	}
+	return -1
+}
+
+// stringEnd returns the index of the quote closing the string that opens at start
+func stringEnd(line string, start int) int {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// splitEscaped splits escaped string content into segments of at most width
+// columns, preferring to break after a space. Escape sequences and multi-byte
+// characters are never split.
+func splitEscaped(content string, width int) []string {
+	var segments []string
+	segmentStart, length, lastSpace := 0, 0, -1
+
+	for i := 0; i < len(content); {
+		size, columns := escapedToken(content[i:])
+		if length > 0 && length+columns > width {
+			cut := i
+			if lastSpace > segmentStart {
+				cut = lastSpace
+			}
+			segments = append(segments, content[segmentStart:cut])
+			segmentStart, length, lastSpace = cut, escapedWidth(content[cut:i]), -1
+		}
+		if content[i] == ' ' {
+			lastSpace = i + size
+		}
+		i += size
+		length += columns
+	}
+	return append(segments, content[segmentStart:])
+}
+
+// escapedToken returns the size in bytes and display width of the first escape
+// sequence or character in s
+func escapedToken(s string) (size, columns int) {
+	if s[0] == '\\' && len(s) > 1 {
+		if s[1] == 'u' && len(s) >= 6 {
+			return 6, 6
+		}
+		return 2, 2
+	}
+	_, size = utf8.DecodeRuneInString(s)
+	return size, 1
+}
+
+// escapedWidth returns the display width of escaped string content
+func escapedWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		size, columns := escapedToken(s[i:])
+		i += size
+		width += columns
+	}
+	return width
+}
@@ -0,0 +1,138 @@
+package jsonstr
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWrapStrings(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		width    int
+		expected string
+	}{
+		{
+			name:     "Short lines are unchanged",
+			input:    "{\n  \"a\": \"short\"\n}",
+			width:    40,
+			expected: "{\n  \"a\": \"short\"\n}",
+		},
+		{
+			name:  "Object member breaks after spaces",
+			input: "{\n  \"text\": \"one two three four five six\",\n  \"n\": 1\n}",
+			width: 24,
+			expected: "{\n" +
+				"  \"text\": \"one two \"\n" +
+				"          \"three four \"\n" +
+				"          \"five six\",\n" +
+				"  \"n\": 1\n}",
+		},
+		{
+			name:  "Array element",
+			input: "[\n  \"abcdefghijklmnopqrstuvwxyz\"\n]",
+			width: 16,
+			expected: "[\n" +
+				"  \"abcdefghijkl\"\n" +
+				"  \"mnopqrstuvwx\"\n" +
+				"  \"yz\"\n]",
+		},
+		{
+			name:     "Escape sequences are kept whole",
+			input:    `"abc\ndef\u00e9gh"`,
+			width:    15,
+			expected: "\"abc\\ndef\"\n\"\\u00e9gh\"",
+		},
+		{
+			name:     "Numbers and keys are never wrapped",
+			input:    "{\n  \"a very long key that goes past the width\": 12345678901234567890\n}",
+			width:    20,
+			expected: "{\n  \"a very long key that goes past the width\": 12345678901234567890\n}",
+		},
+		{
+			name:  "Aligned object member",
+			input: "{\n  \"id\"  : 1,\n  \"text\": \"one two three\"\n}",
+			width: 20,
+			expected: "{\n" +
+				"  \"id\"  : 1,\n" +
+				"  \"text\": \"one two \"\n" +
+				"          \"three\"\n}",
+		},
+		{
+			name:  "Padded key with a string value",
+			input: "{\n  \"a\"   : \"one two three\",\n  \"abcd\": 1\n}",
+			width: 20,
+			expected: "{\n" +
+				"  \"a\"   : \"one two \"\n" +
+				"          \"three\",\n" +
+				"  \"abcd\": 1\n}",
+		},
+		{
+			name:  "Deep indentation keeps a minimum segment width",
+			input: "[\n      \"abcdefghijklmnopqrst\"\n]",
+			width: 10,
+			expected: "[\n" +
+				"      \"abcdefghij\"\n" +
+				"      \"klmnopqrst\"\n]",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := wrapStrings(tc.input, tc.width)
+			if result != tc.expected {
+				t.Errorf("expected\n%s\nbut got\n%s", tc.expected, result)
+			}
+		})
+	}
+}
+
+// Test that joining the wrapped segments reproduces the original value
+func TestWrapStringsPreservesContent(t *testing.T) {
+	value := strings.Repeat("héllo wörld \\\"quoted\\\" \\u263a ", 10)
+	line := `  "key": "` + value + `"`
+
+	wrapped := wrapLine(line, 30)
+	lines := strings.Split(wrapped, "\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected the value to be wrapped but got %s", wrapped)
+	}
+
+	var joined strings.Builder
+	for i, l := range lines {
+		if utf8.RuneCountInString(l) > 30 {
+			t.Errorf("line %d is longer than 30 columns: %s", i, l)
+		}
+		if i == 0 {
+			l = strings.TrimPrefix(l, `  "key": `)
+		}
+		l = strings.TrimSpace(l)
+		joined.WriteString(l[1 : len(l)-1])
+	}
+	if joined.String() != value {
+		t.Errorf("expected segments to join to\n%s\nbut got\n%s", value, joined.String())
+	}
+}
+
+func TestDecodeWithWrap(t *testing.T) {
+	input := []byte(`{\"text\":\"one two three four five six\"}`)
+
+	result, err := DecodeWithOptions(input, EncodeOptions{Pretty: true, Wrap: 24})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "{\n  \"text\": \"one two \"\n          \"three four \"\n          \"five six\"\n}"
+	if result != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, result)
+	}
+
+	// Wrapping only applies to pretty output
+	result, err = DecodeWithOptions(input, EncodeOptions{Wrap: 24})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != `{"text":"one two three four five six"}` {
+		t.Errorf("expected compact output to be unchanged but got %s", result)
+	}
+}